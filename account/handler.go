@@ -0,0 +1,171 @@
+package account
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/problem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+var (
+	// ErrInvalidArgument occurs when payload argument is invalid
+	ErrInvalidArgument = transaction.NewError(transaction.KindInvalid, "invalid_argument", "invalid argument")
+)
+
+// MakeHandler create RestAPI handler, requests on the profile of a customer are authenticated by authenticate
+func MakeHandler(s Service, authenticate auth.Middleware) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/customers", func(w http.ResponseWriter, r *http.Request) {
+		payload := struct {
+			Name        string `json:"name"`
+			Email       string `json:"email"`
+			PhoneNumber string `json:"phone_number"`
+			Address     string `json:"address"`
+			Password    string `json:"password"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		c, err := s.Signup(r.Context(), payload.Name, payload.Email, payload.PhoneNumber, payload.Address, payload.Password)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+		payload := struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		token, err := s.Login(r.Context(), payload.Email, payload.Password)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(token); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.With(authenticate).Get("/customer/{customer_id}", func(w http.ResponseWriter, r *http.Request) {
+		customerID := chi.URLParam(r, "customer_id")
+
+		c, err := s.ViewProfile(r.Context(), customerID)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.With(authenticate).Put("/customer/{customer_id}", func(w http.ResponseWriter, r *http.Request) {
+		customerID := chi.URLParam(r, "customer_id")
+
+		payload := struct {
+			Name        string `json:"name"`
+			Email       string `json:"email"`
+			PhoneNumber string `json:"phone_number"`
+			Address     string `json:"address"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		c, err := s.UpdateProfile(r.Context(), customerID, payload.Name, payload.Email, payload.PhoneNumber, payload.Address)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.With(authenticate).Post("/customer/{customer_id}/verification", func(w http.ResponseWriter, r *http.Request) {
+		customerID := chi.URLParam(r, "customer_id")
+
+		payload := struct {
+			Contact transaction.ContactKind `json:"contact"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		if err := s.RequestVerification(r.Context(), customerID, payload.Contact); err != nil {
+			problem.Write(w, err)
+			return
+		}
+		var response = map[string]interface{}{
+			"sent": true,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Post("/customer/{customer_id}/verify", func(w http.ResponseWriter, r *http.Request) {
+		customerID := chi.URLParam(r, "customer_id")
+
+		payload := struct {
+			Contact transaction.ContactKind `json:"contact"`
+			Token   string                  `json:"token"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		c, err := s.VerifyContact(r.Context(), customerID, payload.Contact, payload.Token)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	return r
+}
@@ -0,0 +1,75 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type instrumentingService struct {
+	request *prometheus.CounterVec
+	latency *prometheus.SummaryVec
+	Service
+}
+
+// NewInstrumentingService create new instrumenting service
+func NewInstrumentingService(
+	request *prometheus.CounterVec,
+	latency *prometheus.SummaryVec,
+	s Service,
+) Service {
+	prometheus.MustRegister(request, latency)
+	return &instrumentingService{request, latency, s}
+}
+
+func (s *instrumentingService) Signup(ctx context.Context, name, email, phoneNumber, address, password string) (customer *transaction.Customer, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("signup", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("signup", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.Signup(ctx, name, email, phoneNumber, address, password)
+}
+
+func (s *instrumentingService) Login(ctx context.Context, email, password string) (token *auth.Token, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("login", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("login", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.Login(ctx, email, password)
+}
+
+func (s *instrumentingService) ViewProfile(ctx context.Context, customerID string) (customer *transaction.Customer, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("view_profile", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("view_profile", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.ViewProfile(ctx, customerID)
+}
+
+func (s *instrumentingService) UpdateProfile(ctx context.Context, customerID, name, email, phoneNumber, address string) (customer *transaction.Customer, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("update_profile", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("update_profile", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.UpdateProfile(ctx, customerID, name, email, phoneNumber, address)
+}
+
+func (s *instrumentingService) RequestVerification(ctx context.Context, customerID string, kind transaction.ContactKind) (err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("request_verification", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("request_verification", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.RequestVerification(ctx, customerID, kind)
+}
+
+func (s *instrumentingService) VerifyContact(ctx context.Context, customerID string, kind transaction.ContactKind, token string) (customer *transaction.Customer, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("verify_contact", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("verify_contact", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.VerifyContact(ctx, customerID, kind, token)
+}
@@ -0,0 +1,102 @@
+package account
+
+import (
+	"context"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	log "github.com/sirupsen/logrus"
+)
+
+type loggingService struct {
+	log *log.Logger
+	Service
+}
+
+// NewLoggingService create new logging service, passwords and tokens are never logged
+func NewLoggingService(log *log.Logger, s Service) Service {
+	return &loggingService{log, s}
+}
+
+func (s *loggingService) Signup(ctx context.Context, name, email, phoneNumber, address, password string) (customer *transaction.Customer, err error) {
+	defer func(begin time.Time) {
+		var customerID string
+		if customer != nil {
+			customerID = customer.ID
+		}
+		s.log.WithFields(log.Fields{
+			"method":       "signup",
+			"customer_id":  customerID,
+			"email":        email,
+			"phone_number": phoneNumber,
+			"took":         time.Since(begin),
+			"err":          err,
+		}).Println()
+	}(time.Now())
+	return s.Service.Signup(ctx, name, email, phoneNumber, address, password)
+}
+
+func (s *loggingService) Login(ctx context.Context, email, password string) (token *auth.Token, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method": "login",
+			"email":  email,
+			"took":   time.Since(begin),
+			"err":    err,
+		}).Println()
+	}(time.Now())
+	return s.Service.Login(ctx, email, password)
+}
+
+func (s *loggingService) ViewProfile(ctx context.Context, customerID string) (customer *transaction.Customer, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":      "view_profile",
+			"customer_id": customerID,
+			"took":        time.Since(begin),
+			"err":         err,
+		}).Println()
+	}(time.Now())
+	return s.Service.ViewProfile(ctx, customerID)
+}
+
+func (s *loggingService) UpdateProfile(ctx context.Context, customerID, name, email, phoneNumber, address string) (customer *transaction.Customer, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":       "update_profile",
+			"customer_id":  customerID,
+			"email":        email,
+			"phone_number": phoneNumber,
+			"took":         time.Since(begin),
+			"err":          err,
+		}).Println()
+	}(time.Now())
+	return s.Service.UpdateProfile(ctx, customerID, name, email, phoneNumber, address)
+}
+
+func (s *loggingService) RequestVerification(ctx context.Context, customerID string, kind transaction.ContactKind) (err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":      "request_verification",
+			"customer_id": customerID,
+			"kind":        kind,
+			"took":        time.Since(begin),
+			"err":         err,
+		}).Println()
+	}(time.Now())
+	return s.Service.RequestVerification(ctx, customerID, kind)
+}
+
+func (s *loggingService) VerifyContact(ctx context.Context, customerID string, kind transaction.ContactKind, token string) (customer *transaction.Customer, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":      "verify_contact",
+			"customer_id": customerID,
+			"kind":        kind,
+			"took":        time.Since(begin),
+			"err":         err,
+		}).Println()
+	}(time.Now())
+	return s.Service.VerifyContact(ctx, customerID, kind, token)
+}
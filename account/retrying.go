@@ -0,0 +1,45 @@
+package account
+
+import (
+	"context"
+	"errors"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type retryingService struct {
+	attempts int
+	Service
+}
+
+// NewRetryingService creates new retrying service. Commands failed with transaction.ErrConcurrentModification
+// are re-run up to attempts times, each run loads a fresh customer from the repository.
+func NewRetryingService(attempts int, s Service) Service {
+	return &retryingService{attempts, s}
+}
+
+func (s *retryingService) retry(fn func() error) (err error) {
+	for i := 0; i < s.attempts; i++ {
+		err = fn()
+		if !errors.Is(err, transaction.ErrConcurrentModification) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *retryingService) UpdateProfile(ctx context.Context, customerID, name, email, phoneNumber, address string) (customer *transaction.Customer, err error) {
+	err = s.retry(func() error {
+		customer, err = s.Service.UpdateProfile(ctx, customerID, name, email, phoneNumber, address)
+		return err
+	})
+	return customer, err
+}
+
+func (s *retryingService) VerifyContact(ctx context.Context, customerID string, kind transaction.ContactKind, token string) (customer *transaction.Customer, err error) {
+	err = s.retry(func() error {
+		customer, err = s.Service.VerifyContact(ctx, customerID, kind, token)
+		return err
+	})
+	return customer, err
+}
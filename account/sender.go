@@ -0,0 +1,29 @@
+package account
+
+import (
+	"context"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	log "github.com/sirupsen/logrus"
+)
+
+type loggingSender struct {
+	log *log.Logger
+}
+
+// NewLoggingSender creates contact verification sender writing the tokens to the log, it's the default sender
+// until verifications are delivered by email and SMS.
+func NewLoggingSender(log *log.Logger) transaction.ContactVerificationSender {
+	return &loggingSender{log}
+}
+
+func (s *loggingSender) SendContactVerification(ctx context.Context, customer *transaction.Customer, kind transaction.ContactKind, token string) {
+	contact, _ := customer.Contact(kind)
+	s.log.WithFields(log.Fields{
+		"event":       "contact_verification",
+		"customer_id": customer.ID,
+		"kind":        kind,
+		"contact":     contact,
+		"token":       token,
+	}).Infoln()
+}
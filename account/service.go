@@ -0,0 +1,206 @@
+// Package account contains process of customers signing up, logging in, managing their profile and verifying their contacts.
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+const (
+	// verificationTTL is how long a verification token can be used after it's sent
+	verificationTTL = 24 * time.Hour
+	// undoTimeout bounds undoing a signup that couldn't be completed, it's done even when the request is canceled
+	undoTimeout = 5 * time.Second
+)
+
+// Service is the interface that provides account methods. Profile methods act on behalf of the customer authenticated
+// in the context, other customers are rejected with auth.ErrForbidden.
+type Service interface {
+	// Signup registers new customer with the password, verifications of the email and the phone number are sent to the customer
+	Signup(ctx context.Context, name, email, phoneNumber, address, password string) (*transaction.Customer, error)
+	// Login checks the email and the password of the customer and issues token authenticating the customer
+	Login(ctx context.Context, email, password string) (*auth.Token, error)
+	// ViewProfile views the profile of the customer
+	ViewProfile(ctx context.Context, customerID string) (*transaction.Customer, error)
+	// UpdateProfile changes the profile of the customer, a changed email or phone number is unverified
+	// and its verification is sent to the customer
+	UpdateProfile(ctx context.Context, customerID, name, email, phoneNumber, address string) (*transaction.Customer, error)
+	// RequestVerification sends another verification of the contact to the customer
+	RequestVerification(ctx context.Context, customerID string, kind transaction.ContactKind) error
+	// VerifyContact verifies the contact of the customer with the token sent to it, the token is enough to authenticate the customer
+	VerifyContact(ctx context.Context, customerID string, kind transaction.ContactKind, token string) (*transaction.Customer, error)
+}
+
+type service struct {
+	customers   transaction.CustomerRepository
+	credentials transaction.CredentialRepository
+	tokens      auth.TokenIssuer
+	sender      transaction.ContactVerificationSender
+	secret      []byte
+	now         func() time.Time
+}
+
+// NewService creates an account service with necessary dependencies,
+// secret signs the verification tokens so they don't need to be stored
+func NewService(
+	customers transaction.CustomerRepository,
+	credentials transaction.CredentialRepository,
+	tokens auth.TokenIssuer,
+	sender transaction.ContactVerificationSender,
+	secret []byte,
+) Service {
+	return &service{
+		customers:   customers,
+		credentials: credentials,
+		tokens:      tokens,
+		sender:      sender,
+		secret:      secret,
+		now:         time.Now,
+	}
+}
+
+func (s *service) Signup(ctx context.Context, name, email, phoneNumber, address, password string) (*transaction.Customer, error) {
+	c, err := transaction.NewCustomer(name, email, phoneNumber, address)
+	if err != nil {
+		return nil, err
+	}
+	// the password is hashed before the customer is stored so an invalid one leaves nothing behind
+	credential, err := transaction.NewCredential("", password)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.customers.Store(ctx, c); err != nil {
+		return nil, err
+	}
+	credential.CustomerID = c.ID
+	if err := s.credentials.Store(ctx, credential); err != nil {
+		// a customer without password can never log in, nor sign up again with the same email, so it's removed.
+		// The request context is not used since it may be the reason the credential wasn't stored.
+		undoCtx, cancel := context.WithTimeout(context.Background(), undoTimeout)
+		defer cancel()
+		if undoErr := s.customers.Delete(undoCtx, c.ID); undoErr != nil {
+			return nil, fmt.Errorf("%w, undoing signup: %v", err, undoErr)
+		}
+		return nil, err
+	}
+
+	s.sendVerification(ctx, c, transaction.ContactEmail)
+	s.sendVerification(ctx, c, transaction.ContactPhone)
+	return c, nil
+}
+
+func (s *service) Login(ctx context.Context, email, password string) (*auth.Token, error) {
+	c, err := s.customers.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, transaction.ErrCustomerNotFound) {
+		return nil, transaction.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	credential, err := s.credentials.FindByCustomerID(ctx, c.ID)
+	if errors.Is(err, transaction.ErrCredentialNotFound) {
+		return nil, transaction.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := credential.CheckPassword(password); err != nil {
+		return nil, err
+	}
+	return s.tokens.Issue(c.ID)
+}
+
+func (s *service) ViewProfile(ctx context.Context, customerID string) (*transaction.Customer, error) {
+	if err := auth.AuthorizeCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+	return s.customers.FindByID(ctx, customerID)
+}
+
+func (s *service) UpdateProfile(ctx context.Context, customerID, name, email, phoneNumber, address string) (*transaction.Customer, error) {
+	if err := auth.AuthorizeCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+	c, err := s.customers.FindByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	previous := *c
+
+	if err := c.EditProfile(name, address); err != nil {
+		return nil, err
+	}
+	if err := c.ChangeEmail(email); err != nil {
+		return nil, err
+	}
+	if err := c.ChangePhoneNumber(phoneNumber); err != nil {
+		return nil, err
+	}
+	if err := s.customers.Update(ctx, c); err != nil {
+		return nil, err
+	}
+
+	if c.Email != previous.Email {
+		s.sendVerification(ctx, c, transaction.ContactEmail)
+	}
+	if c.PhoneNumber != previous.PhoneNumber {
+		s.sendVerification(ctx, c, transaction.ContactPhone)
+	}
+	return c, nil
+}
+
+func (s *service) RequestVerification(ctx context.Context, customerID string, kind transaction.ContactKind) error {
+	if err := auth.AuthorizeCustomer(ctx, customerID); err != nil {
+		return err
+	}
+	c, err := s.customers.FindByID(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if _, err := c.Contact(kind); err != nil {
+		return err
+	}
+
+	s.sendVerification(ctx, c, kind)
+	return nil
+}
+
+func (s *service) VerifyContact(ctx context.Context, customerID string, kind transaction.ContactKind, token string) (*transaction.Customer, error) {
+	c, err := s.customers.FindByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := parseVerification(s.secret, token)
+	if err != nil {
+		return nil, err
+	}
+	if v.CustomerID != c.ID || v.Kind != kind || !s.now().Before(v.ExpiresAt) {
+		return nil, transaction.ErrInvalidVerification
+	}
+	if err := c.VerifyContact(kind, v.Contact); err != nil {
+		return nil, err
+	}
+	if err := s.customers.Update(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// sendVerification sends token for the current contact of the customer
+func (s *service) sendVerification(ctx context.Context, c *transaction.Customer, kind transaction.ContactKind) {
+	contact, _ := c.Contact(kind)
+	token := signVerification(s.secret, verification{
+		CustomerID: c.ID,
+		Kind:       kind,
+		Contact:    contact,
+		ExpiresAt:  s.now().Add(verificationTTL),
+	})
+	s.sender.SendContactVerification(ctx, c, kind, token)
+}
@@ -0,0 +1,280 @@
+package account_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/account"
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/persistent/inmem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// sender records the last token sent to each contact
+type sender struct {
+	tokens map[transaction.ContactKind]string
+}
+
+func (s *sender) SendContactVerification(ctx context.Context, customer *transaction.Customer, kind transaction.ContactKind, token string) {
+	s.tokens[kind] = token
+}
+
+func newService() (account.Service, *sender) {
+	snd := &sender{tokens: make(map[transaction.ContactKind]string)}
+	tokens := auth.NewJWTIssuer([]byte("secret"), "customer", time.Hour)
+	return account.NewService(inmem.NewCustomerRepository(), inmem.NewCredentialRepository(), tokens, snd, []byte("secret")), snd
+}
+
+// customerContext returns context authenticated as the customer
+func customerContext(customerID string) context.Context {
+	return auth.WithCustomer(context.Background(), &transaction.Customer{ID: customerID})
+}
+
+func TestSignup(t *testing.T) {
+	s, snd := newService()
+	ctx := context.Background()
+
+	tt := []struct {
+		Name        string
+		CName       string
+		Email       string
+		PhoneNumber string
+		Password    string
+		Err         error
+	}{
+		{Name: "Success", CName: "Kiki", Email: "Kiki@Mail.com", PhoneNumber: "+62 812 3456", Password: "password"},
+		{Name: "Email Taken", CName: "Kika", Email: "kiki@mail.com", PhoneNumber: "+628999", Password: "password", Err: transaction.ErrEmailTaken},
+		{Name: "Phone Number Taken", CName: "Kika", Email: "kika@mail.com", PhoneNumber: "+628123456", Password: "password", Err: transaction.ErrPhoneNumberTaken},
+		{Name: "No Name", CName: "", Email: "kiko@mail.com", PhoneNumber: "+628777", Password: "password", Err: transaction.ErrInvalidCustomer},
+		{Name: "Invalid Email", CName: "Kiko", Email: "kiko", PhoneNumber: "+628777", Password: "password", Err: transaction.ErrInvalidCustomer},
+		{Name: "Invalid Phone Number", CName: "Kiko", Email: "kiko@mail.com", PhoneNumber: "phone", Password: "password", Err: transaction.ErrInvalidCustomer},
+		{Name: "Short Password", CName: "Kiko", Email: "kiko@mail.com", PhoneNumber: "+628777", Password: "pass", Err: transaction.ErrInvalidPassword},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			snd.tokens = make(map[transaction.ContactKind]string)
+			c, err := s.Signup(ctx, tc.CName, tc.Email, tc.PhoneNumber, "Jakarta", tc.Password)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if err != nil {
+				if len(snd.tokens) != 0 {
+					t.Errorf("got %d verifications sent, expected 0", len(snd.tokens))
+				}
+				return
+			}
+			if c.ID == "" || c.EmailVerified || c.PhoneVerified {
+				t.Errorf("got customer %+v, expected stored with unverified contacts", c)
+			}
+			if c.Email != strings.ToLower(tc.Email) {
+				t.Errorf("got email %q, expected %q", c.Email, strings.ToLower(tc.Email))
+			}
+			if len(snd.tokens) != 2 {
+				t.Errorf("got %d verifications sent, expected 2", len(snd.tokens))
+			}
+		})
+	}
+}
+
+// failingCredentials fails storing credentials while fail is set
+type failingCredentials struct {
+	transaction.CredentialRepository
+	fail bool
+}
+
+var errCredentialStore = errors.New("credential store is down")
+
+func (r *failingCredentials) Store(ctx context.Context, credential *transaction.Credential) error {
+	if r.fail {
+		return errCredentialStore
+	}
+	return r.CredentialRepository.Store(ctx, credential)
+}
+
+func TestSignupCredentialFailure(t *testing.T) {
+	var (
+		customers   = inmem.NewCustomerRepository()
+		credentials = &failingCredentials{CredentialRepository: inmem.NewCredentialRepository(), fail: true}
+		snd         = &sender{tokens: make(map[transaction.ContactKind]string)}
+		s           = account.NewService(customers, credentials, auth.NewJWTIssuer([]byte("secret"), "customer", time.Hour), snd, []byte("secret"))
+		ctx         = context.Background()
+	)
+
+	if _, err := s.Signup(ctx, "Kiki", "kiki@mail.com", "+628123456", "Jakarta", "password"); !errors.Is(err, errCredentialStore) {
+		t.Fatalf("got %v, expected %v", err, errCredentialStore)
+	}
+	if _, err := customers.FindByEmail(ctx, "kiki@mail.com"); !errors.Is(err, transaction.ErrCustomerNotFound) {
+		t.Errorf("customer without credential: got %v, expected %v", err, transaction.ErrCustomerNotFound)
+	}
+	if len(snd.tokens) != 0 {
+		t.Errorf("got %d verifications sent, expected 0", len(snd.tokens))
+	}
+
+	// the same email and phone number can sign up again once the credential is stored
+	credentials.fail = false
+	if _, err := s.Signup(ctx, "Kiki", "kiki@mail.com", "+628123456", "Jakarta", "password"); err != nil {
+		t.Fatalf("signup again: got %v, expected nil", err)
+	}
+	if _, err := s.Login(ctx, "kiki@mail.com", "password"); err != nil {
+		t.Errorf("login: got %v, expected nil", err)
+	}
+}
+
+func TestVerifyContact(t *testing.T) {
+	s, snd := newService()
+	ctx := context.Background()
+
+	c, err := s.Signup(ctx, "Kiki", "kiki@mail.com", "+628123456", "Jakarta", "password")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	emailToken, phoneToken := snd.tokens[transaction.ContactEmail], snd.tokens[transaction.ContactPhone]
+
+	other, err := s.Signup(ctx, "Kika", "kika@mail.com", "+628999", "Jakarta", "password")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	otherToken := snd.tokens[transaction.ContactEmail]
+
+	tt := []struct {
+		Name  string
+		Kind  transaction.ContactKind
+		Token string
+		Err   error
+	}{
+		{Name: "Tampered Token", Kind: transaction.ContactEmail, Token: emailToken + "x", Err: transaction.ErrInvalidVerification},
+		{Name: "Malformed Token", Kind: transaction.ContactEmail, Token: "token", Err: transaction.ErrInvalidVerification},
+		{Name: "Token Of Another Customer", Kind: transaction.ContactEmail, Token: otherToken, Err: transaction.ErrInvalidVerification},
+		{Name: "Token Of Another Contact", Kind: transaction.ContactEmail, Token: phoneToken, Err: transaction.ErrInvalidVerification},
+		{Name: "Invalid Contact", Kind: "fax", Token: emailToken, Err: transaction.ErrInvalidVerification},
+		{Name: "Email", Kind: transaction.ContactEmail, Token: emailToken},
+		{Name: "Phone", Kind: transaction.ContactPhone, Token: phoneToken},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := s.VerifyContact(ctx, c.ID, tc.Kind, tc.Token)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+		})
+	}
+
+	c, err = s.ViewProfile(customerContext(c.ID), c.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if !c.EmailVerified || !c.PhoneVerified {
+		t.Errorf("got customer %+v, expected verified contacts", c)
+	}
+
+	other, err = s.ViewProfile(customerContext(other.ID), other.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if other.EmailVerified {
+		t.Errorf("got email of another customer verified, expected unverified")
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	s, snd := newService()
+	ctx := context.Background()
+
+	c, err := s.Signup(ctx, "Kiki", "kiki@mail.com", "+628123456", "Jakarta", "password")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	oldEmailToken := snd.tokens[transaction.ContactEmail]
+	if _, err := s.VerifyContact(ctx, c.ID, transaction.ContactPhone, snd.tokens[transaction.ContactPhone]); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := s.Signup(ctx, "Kika", "kika@mail.com", "+628999", "Jakarta", "password"); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	ctx = customerContext(c.ID)
+	snd.tokens = make(map[transaction.ContactKind]string)
+	c, err = s.UpdateProfile(ctx, c.ID, "Kiki K", "kiki.k@mail.com", "+628123456", "Bandung")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if c.Name != "Kiki K" || c.Address != "Bandung" || c.EmailVerified || !c.PhoneVerified {
+		t.Errorf("got customer %+v, expected changed email unverified and unchanged phone verified", c)
+	}
+	if _, ok := snd.tokens[transaction.ContactPhone]; ok || snd.tokens[transaction.ContactEmail] == "" {
+		t.Errorf("got verifications sent %v, expected only of the email", snd.tokens)
+	}
+
+	if _, err := s.VerifyContact(ctx, c.ID, transaction.ContactEmail, oldEmailToken); !errors.Is(err, transaction.ErrInvalidVerification) {
+		t.Errorf("got %v verifying previous email, expected %v", err, transaction.ErrInvalidVerification)
+	}
+	if _, err := s.UpdateProfile(ctx, c.ID, "Kiki K", "kika@mail.com", "+628123456", "Bandung"); !errors.Is(err, transaction.ErrEmailTaken) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrEmailTaken)
+	}
+	if _, err := s.UpdateProfile(ctx, "CUSTOMER1", "Kiki K", "kiki.k@mail.com", "+628123456", "Bandung"); !errors.Is(err, auth.ErrForbidden) {
+		t.Errorf("got %v, expected %v", err, auth.ErrForbidden)
+	}
+	if _, err := s.UpdateProfile(context.Background(), c.ID, "Kiki K", "kiki.k@mail.com", "+628123456", "Bandung"); !errors.Is(err, auth.ErrUnauthenticated) {
+		t.Errorf("got %v, expected %v", err, auth.ErrUnauthenticated)
+	}
+	if err := s.RequestVerification(ctx, c.ID, "fax"); !errors.Is(err, transaction.ErrInvalidContact) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrInvalidContact)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	s, _ := newService()
+	ctx := context.Background()
+
+	c, err := s.Signup(ctx, "Kiki", "kiki@mail.com", "+628123456", "Jakarta", "password")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	tt := []struct {
+		Name     string
+		Email    string
+		Password string
+		Subject  string
+		Err      error
+	}{
+		{Name: "Success", Email: " Kiki@Mail.com", Password: "password", Subject: c.ID},
+		{Name: "Predefined Customer", Email: "example@email.com", Password: "password", Subject: "CUSTOMER1"},
+		{Name: "Wrong Password", Email: "kiki@mail.com", Password: "Password", Err: transaction.ErrInvalidCredentials},
+		{Name: "Unknown Email", Email: "kika@mail.com", Password: "password", Err: transaction.ErrInvalidCredentials},
+	}
+
+	tokens := auth.NewJWTIssuer([]byte("secret"), "customer", time.Hour)
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			token, err := s.Login(ctx, tc.Email, tc.Password)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if err != nil {
+				return
+			}
+			subject, err := tokens.Parse(token.Token)
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if subject != tc.Subject {
+				t.Errorf("got subject %q, expected %q", subject, tc.Subject)
+			}
+		})
+	}
+
+	admins := auth.NewJWTIssuer([]byte("secret"), "admin", time.Hour)
+	token, err := s.Login(ctx, "kiki@mail.com", "password")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := admins.Parse(token.Token); !errors.Is(err, auth.ErrUnauthenticated) {
+		t.Errorf("got %v parsing token of another audience, expected %v", err, auth.ErrUnauthenticated)
+	}
+}
@@ -0,0 +1,55 @@
+package account
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// verification is the content of a verification token, it's bound to the contact
+// so the token can't verify a contact changed after the token was sent
+type verification struct {
+	CustomerID string                  `json:"customer_id"`
+	Kind       transaction.ContactKind `json:"kind"`
+	Contact    string                  `json:"contact"`
+	ExpiresAt  time.Time               `json:"expires_at"`
+}
+
+// signVerification encodes v and its HMAC-SHA256 signature as the token
+func signVerification(secret []byte, v verification) string {
+	payload, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sign(secret, payload))
+}
+
+// parseVerification decodes the token, tokens not signed with secret are invalid
+func parseVerification(secret []byte, token string) (*verification, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, transaction.ErrInvalidVerification
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, transaction.ErrInvalidVerification
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, sign(secret, payload)) {
+		return nil, transaction.ErrInvalidVerification
+	}
+
+	var v verification
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return nil, transaction.ErrInvalidVerification
+	}
+	return &v, nil
+}
+
+func sign(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
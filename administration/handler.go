@@ -0,0 +1,154 @@
+package administration
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/problem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+var (
+	// ErrInvalidArgument occurs when payload argument is invalid
+	ErrInvalidArgument = transaction.NewError(transaction.KindInvalid, "invalid_argument", "invalid argument")
+)
+
+// MakeHandler create RestAPI handler, requests other than logging in are authenticated by authenticate.
+// The key of an API key is only returned when it's created or rotated.
+func MakeHandler(s Service, authenticate auth.Middleware) http.Handler {
+	r := chi.NewRouter()
+
+	r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+		payload := struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		token, err := s.Login(r.Context(), payload.Email, payload.Password)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(token); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.With(authenticate).Post("/admins", func(w http.ResponseWriter, r *http.Request) {
+		payload := struct {
+			Name     string           `json:"name"`
+			Email    string           `json:"email"`
+			Role     transaction.Role `json:"role"`
+			Password string           `json:"password"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		a, err := s.RegisterAdmin(r.Context(), payload.Name, payload.Email, payload.Role, payload.Password)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(a); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.With(authenticate).Post("/apikeys", func(w http.ResponseWriter, r *http.Request) {
+		payload := struct {
+			Name      string                   `json:"name"`
+			Scopes    []transaction.Permission `json:"scopes"`
+			ExpiresAt time.Time                `json:"expires_at"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		k, key, err := s.CreateAPIKey(r.Context(), payload.Name, payload.Scopes, payload.ExpiresAt)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		encodeAPIKey(k, key, w)
+	})
+
+	r.With(authenticate).Get("/apikeys", func(w http.ResponseWriter, r *http.Request) {
+		keys, err := s.ListAPIKeys(r.Context())
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(keys); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.With(authenticate).Post("/apikey/{api_key_id}/rotate", func(w http.ResponseWriter, r *http.Request) {
+		payload := struct {
+			ExpiresAt time.Time `json:"expires_at"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		k, key, err := s.RotateAPIKey(r.Context(), chi.URLParam(r, "api_key_id"), payload.ExpiresAt)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		encodeAPIKey(k, key, w)
+	})
+
+	r.With(authenticate).Post("/apikey/{api_key_id}/revoke", func(w http.ResponseWriter, r *http.Request) {
+		k, err := s.RevokeAPIKey(r.Context(), chi.URLParam(r, "api_key_id"))
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(k); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	return r
+}
+
+// encodeAPIKey writes the API key together with its key, which is the only time the key is shown
+func encodeAPIKey(k *transaction.APIKey, key string, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"api_key": k,
+		"key":     key,
+	}); err != nil {
+		problem.Write(w, err)
+	}
+}
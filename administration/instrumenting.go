@@ -0,0 +1,75 @@
+package administration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type instrumentingService struct {
+	request *prometheus.CounterVec
+	latency *prometheus.SummaryVec
+	Service
+}
+
+// NewInstrumentingService create new instrumenting service
+func NewInstrumentingService(
+	request *prometheus.CounterVec,
+	latency *prometheus.SummaryVec,
+	s Service,
+) Service {
+	prometheus.MustRegister(request, latency)
+	return &instrumentingService{request, latency, s}
+}
+
+func (s *instrumentingService) Login(ctx context.Context, email, password string) (token *auth.Token, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("login", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("login", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.Login(ctx, email, password)
+}
+
+func (s *instrumentingService) RegisterAdmin(ctx context.Context, name, email string, role transaction.Role, password string) (admin *transaction.Admin, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("register_admin", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("register_admin", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.RegisterAdmin(ctx, name, email, role, password)
+}
+
+func (s *instrumentingService) CreateAPIKey(ctx context.Context, name string, scopes []transaction.Permission, expiresAt time.Time) (apiKey *transaction.APIKey, key string, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("create_api_key", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("create_api_key", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.CreateAPIKey(ctx, name, scopes, expiresAt)
+}
+
+func (s *instrumentingService) ListAPIKeys(ctx context.Context) (apiKeys []transaction.APIKey, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("list_api_keys", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("list_api_keys", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.ListAPIKeys(ctx)
+}
+
+func (s *instrumentingService) RotateAPIKey(ctx context.Context, apiKeyID string, expiresAt time.Time) (apiKey *transaction.APIKey, key string, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("rotate_api_key", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("rotate_api_key", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.RotateAPIKey(ctx, apiKeyID, expiresAt)
+}
+
+func (s *instrumentingService) RevokeAPIKey(ctx context.Context, apiKeyID string) (apiKey *transaction.APIKey, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("revoke_api_key", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("revoke_api_key", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.RevokeAPIKey(ctx, apiKeyID)
+}
@@ -0,0 +1,119 @@
+package administration
+
+import (
+	"context"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	log "github.com/sirupsen/logrus"
+)
+
+type loggingService struct {
+	log *log.Logger
+	Service
+}
+
+// NewLoggingService create new logging service, passwords and tokens are never logged
+func NewLoggingService(log *log.Logger, s Service) Service {
+	return &loggingService{log, s}
+}
+
+func (s *loggingService) Login(ctx context.Context, email, password string) (token *auth.Token, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method": "login",
+			"email":  email,
+			"took":   time.Since(begin),
+			"err":    err,
+		}).Println()
+	}(time.Now())
+	return s.Service.Login(ctx, email, password)
+}
+
+func (s *loggingService) RegisterAdmin(ctx context.Context, name, email string, role transaction.Role, password string) (admin *transaction.Admin, err error) {
+	defer func(begin time.Time) {
+		var registeredID string
+		if admin != nil {
+			registeredID = admin.ID
+		}
+		s.log.WithFields(log.Fields{
+			"method":        "register_admin",
+			"admin_id":      adminID(ctx),
+			"registered_id": registeredID,
+			"email":         email,
+			"role":          role,
+			"took":          time.Since(begin),
+			"err":           err,
+		}).Println()
+	}(time.Now())
+	return s.Service.RegisterAdmin(ctx, name, email, role, password)
+}
+
+func (s *loggingService) CreateAPIKey(ctx context.Context, name string, scopes []transaction.Permission, expiresAt time.Time) (apiKey *transaction.APIKey, key string, err error) {
+	defer func(begin time.Time) {
+		var apiKeyID string
+		if apiKey != nil {
+			apiKeyID = apiKey.ID
+		}
+		s.log.WithFields(log.Fields{
+			"method":     "create_api_key",
+			"admin_id":   adminID(ctx),
+			"api_key_id": apiKeyID,
+			"name":       name,
+			"scopes":     scopes,
+			"expires_at": expiresAt,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.CreateAPIKey(ctx, name, scopes, expiresAt)
+}
+
+func (s *loggingService) ListAPIKeys(ctx context.Context) (apiKeys []transaction.APIKey, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":   "list_api_keys",
+			"admin_id": adminID(ctx),
+			"count":    len(apiKeys),
+			"took":     time.Since(begin),
+			"err":      err,
+		}).Println()
+	}(time.Now())
+	return s.Service.ListAPIKeys(ctx)
+}
+
+func (s *loggingService) RotateAPIKey(ctx context.Context, apiKeyID string, expiresAt time.Time) (apiKey *transaction.APIKey, key string, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "rotate_api_key",
+			"admin_id":   adminID(ctx),
+			"api_key_id": apiKeyID,
+			"expires_at": expiresAt,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.RotateAPIKey(ctx, apiKeyID, expiresAt)
+}
+
+func (s *loggingService) RevokeAPIKey(ctx context.Context, apiKeyID string) (apiKey *transaction.APIKey, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "revoke_api_key",
+			"admin_id":   adminID(ctx),
+			"api_key_id": apiKeyID,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.RevokeAPIKey(ctx, apiKeyID)
+}
+
+// adminID returns the ID of the admin authenticated in ctx, empty when there is none
+func adminID(ctx context.Context) string {
+	if a, ok := auth.AdminFromContext(ctx); ok {
+		return a.ID
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package administration
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type retryingService struct {
+	attempts int
+	Service
+}
+
+// NewRetryingService creates new retrying service. Commands failed with transaction.ErrConcurrentModification
+// are re-run up to attempts times, each run loads a fresh API key from the repository.
+func NewRetryingService(attempts int, s Service) Service {
+	return &retryingService{attempts, s}
+}
+
+func (s *retryingService) retry(fn func() error) (err error) {
+	for i := 0; i < s.attempts; i++ {
+		err = fn()
+		if !errors.Is(err, transaction.ErrConcurrentModification) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *retryingService) RotateAPIKey(ctx context.Context, apiKeyID string, expiresAt time.Time) (apiKey *transaction.APIKey, key string, err error) {
+	err = s.retry(func() error {
+		apiKey, key, err = s.Service.RotateAPIKey(ctx, apiKeyID, expiresAt)
+		return err
+	})
+	return apiKey, key, err
+}
+
+func (s *retryingService) RevokeAPIKey(ctx context.Context, apiKeyID string) (apiKey *transaction.APIKey, err error) {
+	err = s.retry(func() error {
+		apiKey, err = s.Service.RevokeAPIKey(ctx, apiKeyID)
+		return err
+	})
+	return apiKey, err
+}
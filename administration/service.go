@@ -0,0 +1,134 @@
+// Package administration contains process of admins logging in, superadmins registering other admins
+// and managing API keys of other systems.
+package administration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// Service is the interface that provides administration methods.
+type Service interface {
+	// Login checks the email and the password of the admin and issues token authenticating the admin
+	Login(ctx context.Context, email, password string) (*auth.Token, error)
+	// RegisterAdmin registers new admin with the role and the password, requires transaction.PermissionManageAdmins
+	RegisterAdmin(ctx context.Context, name, email string, role transaction.Role, password string) (*transaction.Admin, error)
+	// CreateAPIKey creates API key of the scopes expiring at expiresAt, the returned key is not kept and can't be shown again.
+	// Requires transaction.PermissionManageAPIKeys
+	CreateAPIKey(ctx context.Context, name string, scopes []transaction.Permission, expiresAt time.Time) (*transaction.APIKey, string, error)
+	// ListAPIKeys lists every API key, the oldest first. Requires transaction.PermissionManageAPIKeys
+	ListAPIKeys(ctx context.Context) ([]transaction.APIKey, error)
+	// RotateAPIKey replaces the key of the API key with a new one expiring at expiresAt, the previous key stops working at once.
+	// Requires transaction.PermissionManageAPIKeys
+	RotateAPIKey(ctx context.Context, apiKeyID string, expiresAt time.Time) (*transaction.APIKey, string, error)
+	// RevokeAPIKey stops the API key from working for good, requires transaction.PermissionManageAPIKeys
+	RevokeAPIKey(ctx context.Context, apiKeyID string) (*transaction.APIKey, error)
+}
+
+type service struct {
+	admins transaction.AdminRepository
+	keys   transaction.APIKeyRepository
+	tokens auth.TokenIssuer
+	now    func() time.Time
+}
+
+// NewService creates an administration service with necessary dependencies,
+// tokens must have an audience of their own so admin tokens are never accepted as customer tokens and vice versa
+func NewService(admins transaction.AdminRepository, keys transaction.APIKeyRepository, tokens auth.TokenIssuer) Service {
+	return &service{
+		admins: admins,
+		keys:   keys,
+		tokens: tokens,
+		now:    time.Now,
+	}
+}
+
+func (s *service) Login(ctx context.Context, email, password string) (*auth.Token, error) {
+	a, err := s.admins.FindByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, transaction.ErrAdminNotFound) {
+		return nil, transaction.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := a.CheckPassword(password); err != nil {
+		return nil, err
+	}
+	return s.tokens.Issue(a.ID)
+}
+
+func (s *service) RegisterAdmin(ctx context.Context, name, email string, role transaction.Role, password string) (*transaction.Admin, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageAdmins); err != nil {
+		return nil, err
+	}
+	a, err := transaction.NewAdmin(name, email, role, password)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.admins.Store(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *service) CreateAPIKey(ctx context.Context, name string, scopes []transaction.Permission, expiresAt time.Time) (*transaction.APIKey, string, error) {
+	admin, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageAPIKeys)
+	if err != nil {
+		return nil, "", err
+	}
+	k, key, err := transaction.NewAPIKey(name, scopes, expiresAt, admin.ID, s.now())
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.keys.Store(ctx, k); err != nil {
+		return nil, "", err
+	}
+	return k, key, nil
+}
+
+func (s *service) ListAPIKeys(ctx context.Context) ([]transaction.APIKey, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageAPIKeys); err != nil {
+		return nil, err
+	}
+	return s.keys.FindAll(ctx)
+}
+
+func (s *service) RotateAPIKey(ctx context.Context, apiKeyID string, expiresAt time.Time) (*transaction.APIKey, string, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageAPIKeys); err != nil {
+		return nil, "", err
+	}
+	k, err := s.keys.FindByID(ctx, apiKeyID)
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := k.Rotate(expiresAt, s.now())
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.keys.Update(ctx, k); err != nil {
+		return nil, "", err
+	}
+	return k, key, nil
+}
+
+func (s *service) RevokeAPIKey(ctx context.Context, apiKeyID string) (*transaction.APIKey, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageAPIKeys); err != nil {
+		return nil, err
+	}
+	k, err := s.keys.FindByID(ctx, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.Revoke(s.now()); err != nil {
+		return nil, err
+	}
+	if err := s.keys.Update(ctx, k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
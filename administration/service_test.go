@@ -0,0 +1,191 @@
+package administration_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/administration"
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/persistent/inmem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// adminContext returns context authenticated as the admin having the role
+func adminContext(adminID string, role transaction.Role) context.Context {
+	return auth.WithAdmin(context.Background(), &transaction.Admin{ID: adminID, Role: role})
+}
+
+func TestRegisterAdmin(t *testing.T) {
+	s := administration.NewService(inmem.NewAdminRepository(), inmem.NewAPIKeyRepository(), auth.NewJWTIssuer([]byte("secret"), "admin", time.Hour))
+
+	tt := []struct {
+		Name     string
+		Ctx      context.Context
+		AName    string
+		Email    string
+		Role     transaction.Role
+		Password string
+		Err      error
+	}{
+		{Name: "Success", Ctx: adminContext("ADMIN1", transaction.RoleSuperadmin), AName: "Kiki", Email: "Kiki@Mail.com", Role: transaction.RoleFinance, Password: "password"},
+		{Name: "Email Taken", Ctx: adminContext("ADMIN1", transaction.RoleSuperadmin), AName: "Kika", Email: "kiki@mail.com", Role: transaction.RoleViewer, Password: "password", Err: transaction.ErrAdminEmailTaken},
+		{Name: "Unknown Role", Ctx: adminContext("ADMIN1", transaction.RoleSuperadmin), AName: "Kika", Email: "kika@mail.com", Role: "owner", Password: "password", Err: transaction.ErrInvalidAdmin},
+		{Name: "Invalid Email", Ctx: adminContext("ADMIN1", transaction.RoleSuperadmin), AName: "Kika", Email: "kika", Role: transaction.RoleViewer, Password: "password", Err: transaction.ErrInvalidAdmin},
+		{Name: "Short Password", Ctx: adminContext("ADMIN1", transaction.RoleSuperadmin), AName: "Kika", Email: "kika@mail.com", Role: transaction.RoleViewer, Password: "pass", Err: transaction.ErrInvalidPassword},
+		{Name: "Not Superadmin", Ctx: adminContext("ADMIN2", transaction.RoleFulfillment), AName: "Kika", Email: "kika@mail.com", Role: transaction.RoleSuperadmin, Password: "password", Err: auth.ErrForbidden},
+		{Name: "Unauthenticated", Ctx: context.Background(), AName: "Kika", Email: "kika@mail.com", Role: transaction.RoleViewer, Password: "password", Err: auth.ErrUnauthenticated},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			a, err := s.RegisterAdmin(tc.Ctx, tc.AName, tc.Email, tc.Role, tc.Password)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if err != nil {
+				return
+			}
+			if a.ID == "" || a.Email != "kiki@mail.com" || a.Role != tc.Role {
+				t.Errorf("got admin %+v, expected stored with lower-cased email and the role", a)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	admins := auth.NewJWTIssuer([]byte("secret"), "admin", time.Hour)
+	s := administration.NewService(inmem.NewAdminRepository(), inmem.NewAPIKeyRepository(), admins)
+	ctx := context.Background()
+
+	a, err := s.RegisterAdmin(adminContext("ADMIN1", transaction.RoleSuperadmin), "Kiki", "kiki@mail.com", transaction.RoleViewer, "password")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	tt := []struct {
+		Name     string
+		Email    string
+		Password string
+		Subject  string
+		Err      error
+	}{
+		{Name: "Success", Email: " Kiki@Mail.com", Password: "password", Subject: a.ID},
+		{Name: "No Predefined Superadmin", Email: "admin@email.com", Password: "password", Err: transaction.ErrInvalidCredentials},
+		{Name: "Wrong Password", Email: "kiki@mail.com", Password: "Password", Err: transaction.ErrInvalidCredentials},
+		{Name: "Unknown Email", Email: "kika@mail.com", Password: "password", Err: transaction.ErrInvalidCredentials},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			token, err := s.Login(ctx, tc.Email, tc.Password)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if err != nil {
+				return
+			}
+			subject, err := admins.Parse(token.Token)
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if subject != tc.Subject {
+				t.Errorf("got subject %q, expected %q", subject, tc.Subject)
+			}
+		})
+	}
+
+	customers := auth.NewJWTIssuer([]byte("secret"), "customer", time.Hour)
+	token, err := s.Login(ctx, "kiki@mail.com", "password")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := customers.Parse(token.Token); !errors.Is(err, auth.ErrUnauthenticated) {
+		t.Errorf("got %v parsing token of another audience, expected %v", err, auth.ErrUnauthenticated)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys := inmem.NewAPIKeyRepository()
+	s := administration.NewService(inmem.NewAdminRepository(), keys, auth.NewJWTIssuer([]byte("secret"), "admin", time.Hour))
+	ctx := adminContext("ADMIN1", transaction.RoleSuperadmin)
+	expiresAt := time.Now().Add(24 * time.Hour)
+	scopes := []transaction.Permission{transaction.PermissionViewOrders, transaction.PermissionShipOrders}
+
+	tt := []struct {
+		Name      string
+		Ctx       context.Context
+		KName     string
+		Scopes    []transaction.Permission
+		ExpiresAt time.Time
+		Err       error
+	}{
+		{Name: "Success", Ctx: ctx, KName: "Warehouse", Scopes: scopes, ExpiresAt: expiresAt},
+		{Name: "No Name", Ctx: ctx, KName: " ", Scopes: scopes, ExpiresAt: expiresAt, Err: transaction.ErrInvalidAPIKey},
+		{Name: "No Scope", Ctx: ctx, KName: "Warehouse", ExpiresAt: expiresAt, Err: transaction.ErrInvalidAPIKey},
+		{Name: "Unknown Scope", Ctx: ctx, KName: "Warehouse", Scopes: []transaction.Permission{"orders:write"}, ExpiresAt: expiresAt, Err: transaction.ErrInvalidAPIKey},
+		{Name: "Expired", Ctx: ctx, KName: "Warehouse", Scopes: scopes, ExpiresAt: time.Now().Add(-time.Hour), Err: transaction.ErrInvalidAPIKey},
+		{Name: "Not Superadmin", Ctx: adminContext("ADMIN2", transaction.RoleFulfillment), KName: "Warehouse", Scopes: scopes, ExpiresAt: expiresAt, Err: auth.ErrForbidden},
+		{Name: "By API Key", Ctx: auth.WithAPIKey(context.Background(), &transaction.APIKey{ID: "KEY1", Scopes: []transaction.Permission{transaction.PermissionManageAPIKeys}}), KName: "Warehouse", Scopes: scopes, ExpiresAt: expiresAt, Err: auth.ErrForbidden},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			k, key, err := s.CreateAPIKey(tc.Ctx, tc.KName, tc.Scopes, tc.ExpiresAt)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if err != nil {
+				return
+			}
+			if k.ID == "" || k.CreatedBy != "ADMIN1" || !strings.HasPrefix(key, transaction.APIKeyPrefix) {
+				t.Errorf("got api key %+v with key %q, expected stored, created by the admin", k, key)
+			}
+			found, err := keys.FindByHash(context.Background(), transaction.HashAPIKey(key))
+			if err != nil || found.ID != k.ID {
+				t.Errorf("got %+v, %v looking up the key, expected the api key", found, err)
+			}
+		})
+	}
+
+	all, err := s.ListAPIKeys(ctx)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("got %d api keys, expected 1", len(all))
+	}
+	previous, err := keys.FindByID(context.Background(), all[0].ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	k, key, err := s.RotateAPIKey(ctx, all[0].ID, expiresAt.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if k.RotatedAt == nil || !k.ExpiresAt.Equal(expiresAt.Add(time.Hour).UTC()) {
+		t.Errorf("got api key %+v, expected rotated with the new expiry", k)
+	}
+	if _, err := keys.FindByHash(context.Background(), previous.Hash); !errors.Is(err, transaction.ErrAPIKeyNotFound) {
+		t.Errorf("got %v looking up the previous key, expected %v", err, transaction.ErrAPIKeyNotFound)
+	}
+	if _, err := keys.FindByHash(context.Background(), transaction.HashAPIKey(key)); err != nil {
+		t.Errorf("got %v looking up the rotated key, expected nil", err)
+	}
+
+	if _, err := s.RevokeAPIKey(ctx, k.ID); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := s.RevokeAPIKey(ctx, k.ID); !errors.Is(err, transaction.ErrAPIKeyRevoked) {
+		t.Errorf("got %v revoking twice, expected %v", err, transaction.ErrAPIKeyRevoked)
+	}
+	if _, _, err := s.RotateAPIKey(ctx, k.ID, expiresAt); !errors.Is(err, transaction.ErrAPIKeyRevoked) {
+		t.Errorf("got %v rotating revoked api key, expected %v", err, transaction.ErrAPIKeyRevoked)
+	}
+	if _, _, err := s.RotateAPIKey(ctx, "KEY404", expiresAt); !errors.Is(err, transaction.ErrAPIKeyNotFound) {
+		t.Errorf("got %v rotating unknown api key, expected %v", err, transaction.ErrAPIKeyNotFound)
+	}
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type contextKey int
+
+const (
+	customerKey contextKey = iota
+	adminKey
+	apiKeyKey
+)
+
+// WithCustomer returns copy of ctx carrying the authenticated customer
+func WithCustomer(ctx context.Context, customer *transaction.Customer) context.Context {
+	return context.WithValue(ctx, customerKey, customer)
+}
+
+// CustomerFromContext returns the authenticated customer carried by ctx
+func CustomerFromContext(ctx context.Context) (*transaction.Customer, bool) {
+	c, ok := ctx.Value(customerKey).(*transaction.Customer)
+	return c, ok
+}
+
+// AuthorizeCustomer checks the authenticated customer is the customer of customerID,
+// it's used before reading or changing anything owned by a customer.
+func AuthorizeCustomer(ctx context.Context, customerID string) error {
+	c, ok := CustomerFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if c.ID != customerID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// WithAdmin returns copy of ctx carrying the authenticated admin
+func WithAdmin(ctx context.Context, admin *transaction.Admin) context.Context {
+	return context.WithValue(ctx, adminKey, admin)
+}
+
+// AdminFromContext returns the authenticated admin carried by ctx
+func AdminFromContext(ctx context.Context) (*transaction.Admin, bool) {
+	a, ok := ctx.Value(adminKey).(*transaction.Admin)
+	return a, ok
+}
+
+// AuthorizeAdmin checks the role of the authenticated admin allows the permission,
+// the admin is returned so the caller can record who made the change. API keys are rejected with ErrForbidden.
+func AuthorizeAdmin(ctx context.Context, permission transaction.Permission) (*transaction.Admin, error) {
+	if _, ok := APIKeyFromContext(ctx); ok {
+		return nil, ErrForbidden
+	}
+	a, ok := AdminFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if !a.Can(permission) {
+		return nil, ErrForbidden
+	}
+	return a, nil
+}
+
+// WithAPIKey returns copy of ctx carrying the authenticated API key
+func WithAPIKey(ctx context.Context, key *transaction.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey, key)
+}
+
+// APIKeyFromContext returns the authenticated API key carried by ctx
+func APIKeyFromContext(ctx context.Context) (*transaction.APIKey, bool) {
+	k, ok := ctx.Value(apiKeyKey).(*transaction.APIKey)
+	return k, ok
+}
+
+// Authorize checks the role of the authenticated admin or the scopes of the authenticated API key allow the permission,
+// the actor is returned so the caller can record who made the change. Operations only people may do use AuthorizeAdmin instead.
+func Authorize(ctx context.Context, permission transaction.Permission) (actor string, err error) {
+	if k, ok := APIKeyFromContext(ctx); ok {
+		if !k.Can(permission) {
+			return "", ErrForbidden
+		}
+		return transaction.APIKeyActor(k.ID), nil
+	}
+	a, err := AuthorizeAdmin(ctx, permission)
+	if err != nil {
+		return "", err
+	}
+	return transaction.AdminActor(a.ID), nil
+}
+
+// ActorFromContext returns the actor of the authenticated customer, admin or API key carried by ctx
+func ActorFromContext(ctx context.Context) (string, bool) {
+	if c, ok := CustomerFromContext(ctx); ok {
+		return transaction.CustomerActor(c.ID), true
+	}
+	if k, ok := APIKeyFromContext(ctx); ok {
+		return transaction.APIKeyActor(k.ID), true
+	}
+	if a, ok := AdminFromContext(ctx); ok {
+		return transaction.AdminActor(a.ID), true
+	}
+	return "", false
+}
@@ -0,0 +1,70 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+func TestAuthorizeCustomer(t *testing.T) {
+	tt := []struct {
+		Name string
+		Ctx  context.Context
+		Err  error
+	}{
+		{Name: "Same Customer", Ctx: auth.WithCustomer(context.Background(), &transaction.Customer{ID: "CUSTOMER1"})},
+		{Name: "Another Customer", Ctx: auth.WithCustomer(context.Background(), &transaction.Customer{ID: "CUSTOMER2"}), Err: auth.ErrForbidden},
+		{Name: "Admin", Ctx: auth.WithAdmin(context.Background(), &transaction.Admin{ID: "ADMIN1", Role: transaction.RoleSuperadmin}), Err: auth.ErrUnauthenticated},
+		{Name: "Unauthenticated", Ctx: context.Background(), Err: auth.ErrUnauthenticated},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := auth.AuthorizeCustomer(tc.Ctx, "CUSTOMER1"); !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	var (
+		viewer = auth.WithAdmin(context.Background(), &transaction.Admin{ID: "ADMIN1", Role: transaction.RoleViewer})
+		apiKey = auth.WithAPIKey(context.Background(), &transaction.APIKey{ID: "KEY1", Scopes: []transaction.Permission{transaction.PermissionShipOrders}})
+	)
+
+	tt := []struct {
+		Name       string
+		Ctx        context.Context
+		Permission transaction.Permission
+		Actor      string
+		Err        error
+		AdminErr   error
+	}{
+		{Name: "Role Allows", Ctx: viewer, Permission: transaction.PermissionViewOrders, Actor: transaction.AdminActor("ADMIN1")},
+		{Name: "Role Forbids", Ctx: viewer, Permission: transaction.PermissionShipOrders, Err: auth.ErrForbidden, AdminErr: auth.ErrForbidden},
+		{Name: "Scope Allows", Ctx: apiKey, Permission: transaction.PermissionShipOrders, Actor: transaction.APIKeyActor("KEY1"), AdminErr: auth.ErrForbidden},
+		{Name: "Scope Forbids", Ctx: apiKey, Permission: transaction.PermissionViewOrders, Err: auth.ErrForbidden, AdminErr: auth.ErrForbidden},
+		{Name: "Customer", Ctx: auth.WithCustomer(context.Background(), &transaction.Customer{ID: "CUSTOMER1"}), Permission: transaction.PermissionViewOrders, Err: auth.ErrUnauthenticated, AdminErr: auth.ErrUnauthenticated},
+		{Name: "Unauthenticated", Ctx: context.Background(), Permission: transaction.PermissionViewOrders, Err: auth.ErrUnauthenticated, AdminErr: auth.ErrUnauthenticated},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			actor, err := auth.Authorize(tc.Ctx, tc.Permission)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if actor != tc.Actor {
+				t.Errorf("got actor %q, expected %q", actor, tc.Actor)
+			}
+			// API keys are never admins
+			if _, err := auth.AuthorizeAdmin(tc.Ctx, tc.Permission); !errors.Is(err, tc.AdminErr) {
+				t.Errorf("AuthorizeAdmin: got %v, expected %v", err, tc.AdminErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/problem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// Middleware wraps handler of requests
+type Middleware func(http.Handler) http.Handler
+
+// AuthenticateCustomer creates middleware authenticating the customer by the bearer token of the request,
+// the customer is put into the context. Requests without valid token of an existing customer are rejected with 401.
+func AuthenticateCustomer(tokens TokenIssuer, customers transaction.CustomerRepository) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject, err := tokens.Parse(bearerToken(r))
+			if err != nil {
+				problem.Write(w, err)
+				return
+			}
+			c, err := customers.FindByID(r.Context(), subject)
+			if errors.Is(err, transaction.ErrCustomerNotFound) {
+				err = ErrUnauthenticated
+			}
+			if err != nil {
+				problem.Write(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithCustomer(r.Context(), c)))
+		})
+	}
+}
+
+// AuthenticateAdmin creates middleware authenticating the admin or the API key by the bearer token of the request,
+// the admin or the API key is put into the context. Requests without valid token of an existing admin,
+// or without active API key, are rejected with 401.
+func AuthenticateAdmin(tokens TokenIssuer, admins transaction.AdminRepository, keys transaction.APIKeyRepository) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+			if strings.HasPrefix(token, transaction.APIKeyPrefix) {
+				k, err := keys.FindByHash(r.Context(), transaction.HashAPIKey(token))
+				if errors.Is(err, transaction.ErrAPIKeyNotFound) || (err == nil && !k.Active(time.Now())) {
+					err = ErrUnauthenticated
+				}
+				if err != nil {
+					problem.Write(w, err)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(WithAPIKey(r.Context(), k)))
+				return
+			}
+
+			subject, err := tokens.Parse(token)
+			if err != nil {
+				problem.Write(w, err)
+				return
+			}
+			a, err := admins.FindByID(r.Context(), subject)
+			if errors.Is(err, transaction.ErrAdminNotFound) {
+				err = ErrUnauthenticated
+			}
+			if err != nil {
+				problem.Write(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithAdmin(r.Context(), a)))
+		})
+	}
+}
+
+// bearerToken returns the token of the Authorization header, empty when there is none
+func bearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if len(h) < len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(h[len(prefix):])
+}
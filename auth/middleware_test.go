@@ -0,0 +1,142 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/persistent/inmem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// actorHandler responds with the actor authenticated in the context of the request
+var actorHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.ActorFromContext(r.Context())
+	_, _ = w.Write([]byte(actor))
+})
+
+// serve serves request with the authorization header through the middleware, it returns the status and the authenticated actor
+func serve(m auth.Middleware, authorization string) (int, string) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	m(actorHandler).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		return w.Code, ""
+	}
+	return w.Code, w.Body.String()
+}
+
+func TestAuthenticateCustomer(t *testing.T) {
+	var (
+		tokens       = auth.NewJWTIssuer([]byte("secret"), "customer", time.Hour)
+		adminTokens  = auth.NewJWTIssuer([]byte("secret"), "admin", time.Hour)
+		authenticate = auth.AuthenticateCustomer(tokens, inmem.NewCustomerRepository())
+	)
+
+	issue := func(issuer auth.TokenIssuer, subject string) string {
+		tk, err := issuer.Issue(subject)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		return tk.Token
+	}
+	token := issue(tokens, "CUSTOMER1")
+
+	tt := []struct {
+		Name          string
+		Authorization string
+		Status        int
+		Actor         string
+	}{
+		{Name: "Bearer Token", Authorization: "Bearer " + token, Status: http.StatusOK, Actor: transaction.CustomerActor("CUSTOMER1")},
+		{Name: "Case Insensitive Scheme", Authorization: "bearer " + token, Status: http.StatusOK, Actor: transaction.CustomerActor("CUSTOMER1")},
+		{Name: "Missing Header", Status: http.StatusUnauthorized},
+		{Name: "Another Scheme", Authorization: "Basic " + token, Status: http.StatusUnauthorized},
+		{Name: "Missing Token", Authorization: "Bearer ", Status: http.StatusUnauthorized},
+		{Name: "Token Without Scheme", Authorization: token, Status: http.StatusUnauthorized},
+		{Name: "Malformed Token", Authorization: "Bearer malformed", Status: http.StatusUnauthorized},
+		{Name: "Admin Token", Authorization: "Bearer " + issue(adminTokens, "CUSTOMER1"), Status: http.StatusUnauthorized},
+		{Name: "Unknown Customer", Authorization: "Bearer " + issue(tokens, "UNKNOWN"), Status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			status, actor := serve(authenticate, tc.Authorization)
+			if status != tc.Status || actor != tc.Actor {
+				t.Errorf("got status %d and actor %q, expected %d and %q", status, actor, tc.Status, tc.Actor)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAdmin(t *testing.T) {
+	var (
+		tokens       = auth.NewJWTIssuer([]byte("secret"), "admin", time.Hour)
+		admins       = inmem.NewAdminRepository()
+		keys         = inmem.NewAPIKeyRepository()
+		authenticate = auth.AuthenticateAdmin(tokens, admins, keys)
+		ctx          = context.Background()
+		now          = time.Now()
+	)
+
+	a, err := transaction.NewAdmin("Hari", "hari@email.com", transaction.RoleViewer, "password")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := admins.Store(ctx, a); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	tk, err := tokens.Issue(a.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	storeKey := func(createdAt, expiresAt time.Time, revoked bool) (*transaction.APIKey, string) {
+		k, secret, err := transaction.NewAPIKey("Warehouse", []transaction.Permission{transaction.PermissionViewOrders}, expiresAt, a.ID, createdAt)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if revoked {
+			if err := k.Revoke(now); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+		}
+		if err := keys.Store(ctx, k); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		return k, secret
+	}
+	active, activeSecret := storeKey(now, now.Add(time.Hour), false)
+	_, expiredSecret := storeKey(now.Add(-time.Hour), now.Add(-time.Minute), false)
+	_, revokedSecret := storeKey(now, now.Add(time.Hour), true)
+
+	tt := []struct {
+		Name          string
+		Authorization string
+		Status        int
+		Actor         string
+	}{
+		{Name: "Admin Token", Authorization: "Bearer " + tk.Token, Status: http.StatusOK, Actor: transaction.AdminActor(a.ID)},
+		{Name: "API Key", Authorization: "Bearer " + activeSecret, Status: http.StatusOK, Actor: transaction.APIKeyActor(active.ID)},
+		{Name: "Missing Header", Status: http.StatusUnauthorized},
+		{Name: "Malformed Token", Authorization: "Bearer malformed", Status: http.StatusUnauthorized},
+		{Name: "Expired API Key", Authorization: "Bearer " + expiredSecret, Status: http.StatusUnauthorized},
+		{Name: "Revoked API Key", Authorization: "Bearer " + revokedSecret, Status: http.StatusUnauthorized},
+		{Name: "Unknown API Key", Authorization: "Bearer " + transaction.APIKeyPrefix + "unknown", Status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			status, actor := serve(authenticate, tc.Authorization)
+			if status != tc.Status || actor != tc.Actor {
+				t.Errorf("got status %d and actor %q, expected %d and %q", status, actor, tc.Status, tc.Actor)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package auth authenticates the callers of the API with signed tokens
+// and keeps the authenticated caller in the context of the request.
+package auth
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+var (
+	// ErrUnauthenticated tells that the request carries no valid token
+	ErrUnauthenticated = transaction.NewError(transaction.KindUnauthenticated, "unauthenticated", "error unauthenticated")
+	// ErrForbidden tells that the authenticated caller is not allowed to access the resource
+	ErrForbidden = transaction.NewError(transaction.KindForbidden, "forbidden", "error forbidden")
+)
+
+// Token is a signed token telling who its subject is until it expires
+type Token struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TokenIssuer issues tokens for subjects and parses them back, parsing fails with ErrUnauthenticated
+// when the token is not signed by the issuer, is expired or was issued for another audience.
+type TokenIssuer interface {
+	Issue(subject string) (*Token, error)
+	Parse(token string) (subject string, err error)
+}
+
+type jwtIssuer struct {
+	secret   []byte
+	audience string
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewJWTIssuer creates token issuer of JWTs signed with HMAC-SHA256, tokens of another audience
+// are rejected so a customer token can't be used where an admin token is expected.
+func NewJWTIssuer(secret []byte, audience string, ttl time.Duration) TokenIssuer {
+	return &jwtIssuer{secret: secret, audience: audience, ttl: ttl, now: time.Now}
+}
+
+func (i *jwtIssuer) Issue(subject string) (*Token, error) {
+	now := i.now()
+	expiresAt := now.Add(i.ttl)
+	claims := jwt.RegisteredClaims{
+		Subject:   subject,
+		Audience:  jwt.ClaimStrings{i.audience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+	if err != nil {
+		return nil, err
+	}
+	return &Token{Token: signed, ExpiresAt: expiresAt.UTC().Truncate(time.Second)}, nil
+}
+
+func (i *jwtIssuer) Parse(token string) (string, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return i.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || claims.Subject == "" || !claims.VerifyAudience(i.audience, true) {
+		return "", ErrUnauthenticated
+	}
+	return claims.Subject, nil
+}
@@ -0,0 +1,56 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+)
+
+func TestJWTIssuer(t *testing.T) {
+	var (
+		secret   = []byte("secret")
+		issuer   = auth.NewJWTIssuer(secret, "customer", time.Hour)
+		expired  = auth.NewJWTIssuer(secret, "customer", -time.Minute)
+		wrongKey = auth.NewJWTIssuer([]byte("another secret"), "customer", time.Hour)
+		admin    = auth.NewJWTIssuer(secret, "admin", time.Hour)
+	)
+
+	issue := func(issuer auth.TokenIssuer) string {
+		tk, err := issuer.Issue("CUSTOMER1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		return tk.Token
+	}
+	valid := issue(issuer)
+
+	tt := []struct {
+		Name    string
+		Token   string
+		Subject string
+		Err     error
+	}{
+		{Name: "Valid", Token: valid, Subject: "CUSTOMER1"},
+		{Name: "Expired", Token: issue(expired), Err: auth.ErrUnauthenticated},
+		{Name: "Wrong Key", Token: issue(wrongKey), Err: auth.ErrUnauthenticated},
+		{Name: "Another Audience", Token: issue(admin), Err: auth.ErrUnauthenticated},
+		{Name: "Tampered", Token: valid[:len(valid)-2] + "xx", Err: auth.ErrUnauthenticated},
+		{Name: "Malformed", Token: "not.a.token", Err: auth.ErrUnauthenticated},
+		{Name: "Unsigned", Token: "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJzdWIiOiJDVVNUT01FUjEiLCJhdWQiOlsiY3VzdG9tZXIiXX0.", Err: auth.ErrUnauthenticated},
+		{Name: "Empty", Token: "", Err: auth.ErrUnauthenticated},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			subject, err := issuer.Parse(tc.Token)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if subject != tc.Subject {
+				t.Errorf("got subject %q, expected %q", subject, tc.Subject)
+			}
+		})
+	}
+}
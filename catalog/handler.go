@@ -0,0 +1,159 @@
+package catalog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/muktihari/order-transaction-ddd/problem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrInvalidArgument occurs when query argument is invalid
+	ErrInvalidArgument = transaction.NewError(transaction.KindInvalid, "invalid_argument", "invalid argument")
+)
+
+// MakeHandler create RestAPI handler
+func MakeHandler(s Service) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/products", func(w http.ResponseWriter, r *http.Request) {
+		filter, page, err := decodeProductQuery(r.URL.Query())
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		result, err := s.ListProducts(r.Context(), filter, page)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	// e.g: /search?q=xperia&limit=10
+	r.Get("/search", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var limit int
+		if v := q.Get("limit"); v != "" {
+			var err error
+			if limit, err = strconv.Atoi(v); err != nil {
+				problem.Write(w, ErrInvalidArgument)
+				return
+			}
+		}
+
+		products, err := s.SearchProducts(r.Context(), q.Get("q"), limit)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		var response = map[string]interface{}{
+			"products": products,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Get("/product/{product_id}", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		p, err := s.ViewProduct(r.Context(), productID)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(p); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Get("/categories", func(w http.ResponseWriter, r *http.Request) {
+		categories, err := s.ListCategories(r.Context())
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		var response = map[string]interface{}{
+			"categories": categories,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	return r
+}
+
+// decodeProductQuery decodes product filter and page from query, e.g:
+// ?min_price=10&max_price=500.50&availability=in_stock&category=CATEGORY1&tag=android&sort=price_lowest&limit=20&cursor=...
+// category can be repeated to list products of any of the categories.
+func decodeProductQuery(q url.Values) (filter transaction.ProductFilter, page transaction.Page, err error) {
+	if val := q.Get("min_price"); val != "" {
+		if filter.MinPrice.Decimal, err = decimal.NewFromString(val); err != nil {
+			return filter, page, ErrInvalidArgument
+		}
+		filter.MinPrice.Valid = true
+	}
+	if val := q.Get("max_price"); val != "" {
+		if filter.MaxPrice.Decimal, err = decimal.NewFromString(val); err != nil {
+			return filter, page, ErrInvalidArgument
+		}
+		filter.MaxPrice.Valid = true
+	}
+
+	switch q.Get("availability") {
+	case "", "any":
+		filter.Availability = transaction.ProductAvailabilityAny
+	case "in_stock":
+		filter.Availability = transaction.ProductAvailabilityInStock
+	case "out_of_stock":
+		filter.Availability = transaction.ProductAvailabilityOutOfStock
+	default:
+		return filter, page, ErrInvalidArgument
+	}
+
+	filter.Categories = q["category"]
+	filter.Tag = q.Get("tag")
+
+	switch q.Get("sort") {
+	case "", "name":
+		filter.Sort = transaction.ProductSortName
+	case "price_lowest":
+		filter.Sort = transaction.ProductSortPriceLowest
+	case "price_highest":
+		filter.Sort = transaction.ProductSortPriceHighest
+	default:
+		return filter, page, ErrInvalidArgument
+	}
+
+	page.Cursor = q.Get("cursor")
+	if val := q.Get("limit"); val != "" {
+		if page.Limit, err = strconv.Atoi(val); err != nil {
+			return filter, page, ErrInvalidArgument
+		}
+	}
+
+	return filter, page, nil
+}
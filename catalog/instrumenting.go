@@ -0,0 +1,58 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type instrumentingService struct {
+	request *prometheus.CounterVec
+	latency *prometheus.SummaryVec
+	Service
+}
+
+// NewInstrumentingService create new instrumenting service
+func NewInstrumentingService(
+	request *prometheus.CounterVec,
+	latency *prometheus.SummaryVec,
+	s Service,
+) Service {
+	prometheus.MustRegister(request, latency)
+	return &instrumentingService{request, latency, s}
+}
+
+func (s *instrumentingService) ListProducts(ctx context.Context, filter transaction.ProductFilter, page transaction.Page) (result *transaction.ProductPage, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("list_products", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("list_products", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.ListProducts(ctx, filter, page)
+}
+
+func (s *instrumentingService) ViewProduct(ctx context.Context, productID string) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("view_product", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("view_product", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.ViewProduct(ctx, productID)
+}
+
+func (s *instrumentingService) ListCategories(ctx context.Context) (categories transaction.Categories, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("list_categories", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("list_categories", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.ListCategories(ctx)
+}
+
+func (s *instrumentingService) SearchProducts(ctx context.Context, query string, limit int) (products []transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("search_products", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("search_products", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.SearchProducts(ctx, query, limit)
+}
@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"context"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	log "github.com/sirupsen/logrus"
+)
+
+type loggingService struct {
+	log *log.Logger
+	Service
+}
+
+// NewLoggingService create new logging service
+func NewLoggingService(log *log.Logger, s Service) Service {
+	return &loggingService{log, s}
+}
+
+func (s *loggingService) ListProducts(ctx context.Context, filter transaction.ProductFilter, page transaction.Page) (result *transaction.ProductPage, err error) {
+	defer func(begin time.Time) {
+		var count int
+		if result != nil {
+			count = len(result.Products)
+		}
+		s.log.WithFields(log.Fields{
+			"method": "list_products",
+			"filter": filter,
+			"limit":  page.Size(),
+			"count":  count,
+			"took":   time.Since(begin),
+			"err":    err,
+		}).Println()
+	}(time.Now())
+	return s.Service.ListProducts(ctx, filter, page)
+}
+
+func (s *loggingService) ViewProduct(ctx context.Context, productID string) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "view_product",
+			"product_id": productID,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.ViewProduct(ctx, productID)
+}
+
+func (s *loggingService) ListCategories(ctx context.Context) (categories transaction.Categories, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method": "list_categories",
+			"count":  len(categories),
+			"took":   time.Since(begin),
+			"err":    err,
+		}).Println()
+	}(time.Now())
+	return s.Service.ListCategories(ctx)
+}
+
+func (s *loggingService) SearchProducts(ctx context.Context, query string, limit int) (products []transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method": "search_products",
+			"query":  query,
+			"limit":  limit,
+			"count":  len(products),
+			"took":   time.Since(begin),
+			"err":    err,
+		}).Println()
+	}(time.Now())
+	return s.Service.SearchProducts(ctx, query, limit)
+}
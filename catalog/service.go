@@ -0,0 +1,106 @@
+// Package catalog contains process of browsing products by customer
+package catalog
+
+import (
+	"context"
+	"sort"
+
+	"github.com/muktihari/order-transaction-ddd/search"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// Service is the interface that provides catalog methods. Products are listed and searched from the search index,
+// which is synced by search.Indexer, so changes show up once the index catches up.
+type Service interface {
+	// ListProducts lists products on sale matching the filter, a page at a time.
+	// Products of the subcategories of filtered categories are listed as well.
+	ListProducts(ctx context.Context, filter transaction.ProductFilter, page transaction.Page) (*transaction.ProductPage, error)
+	// ViewProduct views product details, quantity of a bundle is how many of it its components make up
+	ViewProduct(ctx context.Context, productID string) (*transaction.Product, error)
+	// ListCategories lists the whole category tree
+	ListCategories(ctx context.Context) (transaction.Categories, error)
+	// SearchProducts searches products on sale by name, description and tags, most relevant first.
+	// Limit is bounded the same way as the limit of a page.
+	SearchProducts(ctx context.Context, query string, limit int) ([]transaction.Product, error)
+}
+
+type service struct {
+	products   transaction.ProductRepository
+	categories transaction.CategoryRepository
+	index      *search.Index
+}
+
+// NewService creates a catalog service with necessary dependencies
+func NewService(products transaction.ProductRepository, categories transaction.CategoryRepository, index *search.Index) Service {
+	return &service{
+		products:   products,
+		categories: categories,
+		index:      index,
+	}
+}
+
+func (s *service) ListProducts(ctx context.Context, filter transaction.ProductFilter, page transaction.Page) (*transaction.ProductPage, error) {
+	cursor, err := transaction.DecodeProductCursor(page.Cursor, filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(filter.Categories) != 0 {
+		categories, err := s.categories.FindAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var subtrees []string
+		for _, id := range filter.Categories {
+			if !categories.Has(id) {
+				return nil, transaction.ErrCategoryNotFound
+			}
+			subtrees = append(subtrees, categories.Subtree(id)...)
+		}
+		filter.Categories = subtrees
+	}
+
+	// archived products are not indexed
+	all := s.index.Products()
+	products := make([]transaction.Product, 0, len(all))
+	for i := range all {
+		if !filter.Match(&all[i]) {
+			continue
+		}
+		if cursor != nil && !cursor.After(&all[i]) {
+			continue
+		}
+		products = append(products, all[i])
+	}
+
+	sort.Slice(products, func(i, j int) bool { return filter.Less(&products[i], &products[j]) })
+	if len(products) > page.Size()+1 {
+		products = products[:page.Size()+1]
+	}
+
+	return transaction.NewProductPage(products, filter, page), nil
+}
+
+func (s *service) ViewProduct(ctx context.Context, productID string) (*transaction.Product, error) {
+	p, err := s.products.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := transaction.ResolveBundle(ctx, s.products, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *service) ListCategories(ctx context.Context) (transaction.Categories, error) {
+	return s.categories.FindAll(ctx)
+}
+
+func (s *service) SearchProducts(ctx context.Context, query string, limit int) ([]transaction.Product, error) {
+	hits := s.index.Search(query, transaction.Page{Limit: limit}.Size())
+	products := make([]transaction.Product, 0, len(hits))
+	for _, h := range hits {
+		products = append(products, h.Product)
+	}
+	return products, nil
+}
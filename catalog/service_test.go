@@ -0,0 +1,242 @@
+package catalog_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/order-transaction-ddd/catalog"
+	"github.com/muktihari/order-transaction-ddd/persistent/inmem"
+	"github.com/muktihari/order-transaction-ddd/search"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+)
+
+// sync brings the index up to date with the products, as search.Indexer does periodically
+func sync(t *testing.T, products transaction.ProductRepository, index *search.Index) {
+	t.Helper()
+	if err := search.NewIndexer(products, index).Sync(context.Background()); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+}
+
+func TestListProducts(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		index    = search.NewIndex()
+		s        = catalog.NewService(products, inmem.NewCategoryRepository(), index)
+	)
+
+	ctx := context.Background()
+	p2, err := products.FindByID(ctx, "PRODUCT2")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	p2.Quantity = 0
+	if err := products.Update(ctx, p2); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	sync(t, products, index)
+
+	price := func(v int64) decimal.NullDecimal {
+		return decimal.NullDecimal{Decimal: decimal.NewFromInt(v), Valid: true}
+	}
+
+	tt := []struct {
+		Name     string
+		Filter   transaction.ProductFilter
+		Expected []string
+	}{
+		{Name: "Sort By Name", Filter: transaction.ProductFilter{}, Expected: []string{"PRODUCT1", "PRODUCT2"}},
+		{Name: "Sort By Lowest Price", Filter: transaction.ProductFilter{Sort: transaction.ProductSortPriceLowest}, Expected: []string{"PRODUCT2", "PRODUCT1"}},
+		{Name: "Sort By Highest Price", Filter: transaction.ProductFilter{Sort: transaction.ProductSortPriceHighest}, Expected: []string{"PRODUCT1", "PRODUCT2"}},
+		{Name: "Min Price", Filter: transaction.ProductFilter{MinPrice: price(5), Sort: transaction.ProductSortPriceLowest}, Expected: []string{"PRODUCT2", "PRODUCT1"}},
+		{Name: "Max Price", Filter: transaction.ProductFilter{MaxPrice: price(499)}, Expected: []string{"PRODUCT2"}},
+		{Name: "Price Range", Filter: transaction.ProductFilter{MinPrice: price(6), MaxPrice: price(500)}, Expected: []string{"PRODUCT1"}},
+		{Name: "In Stock", Filter: transaction.ProductFilter{Availability: transaction.ProductAvailabilityInStock}, Expected: []string{"PRODUCT1"}},
+		{Name: "Out Of Stock", Filter: transaction.ProductFilter{Availability: transaction.ProductAvailabilityOutOfStock}, Expected: []string{"PRODUCT2"}},
+		{Name: "No Match", Filter: transaction.ProductFilter{MinPrice: price(1000)}, Expected: []string{}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				ids  = make([]string, 0)
+				page = transaction.Page{Limit: 1}
+			)
+			for {
+				result, err := s.ListProducts(ctx, tc.Filter, page)
+				if err != nil {
+					t.Fatalf("got %v, expected nil", err)
+				}
+				for _, p := range result.Products {
+					ids = append(ids, p.ID)
+				}
+				if result.NextCursor == "" {
+					break
+				}
+				page.Cursor = result.NextCursor
+			}
+			if len(ids) != len(tc.Expected) {
+				t.Fatalf("got %v, expected %v", ids, tc.Expected)
+			}
+			for i := range ids {
+				if ids[i] != tc.Expected[i] {
+					t.Fatalf("got %v, expected %v", ids, tc.Expected)
+				}
+			}
+		})
+	}
+
+	t.Run("Invalid Cursor", func(t *testing.T) {
+		result, err := s.ListProducts(ctx, transaction.ProductFilter{}, transaction.Page{Limit: 1})
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		page := transaction.Page{Cursor: result.NextCursor}
+		filter := transaction.ProductFilter{Sort: transaction.ProductSortPriceLowest}
+		if _, err := s.ListProducts(ctx, filter, page); !errors.Is(err, transaction.ErrInvalidCursor) {
+			t.Fatalf("got %v, expected %v", err, transaction.ErrInvalidCursor)
+		}
+		page.Cursor = "malformed"
+		if _, err := s.ListProducts(ctx, transaction.ProductFilter{}, page); !errors.Is(err, transaction.ErrInvalidCursor) {
+			t.Fatalf("got %v, expected %v", err, transaction.ErrInvalidCursor)
+		}
+	})
+}
+
+func TestListProductsByTaxonomy(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		index    = search.NewIndex()
+		s        = catalog.NewService(products, inmem.NewCategoryRepository(), index)
+	)
+
+	ctx := context.Background()
+	categorize := func(productID, categoryID string, tags ...string) {
+		p, err := products.FindByID(ctx, productID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := p.Categorize(categoryID, tags); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := products.Update(ctx, p); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+	}
+	categorize("PRODUCT1", "CATEGORY2", "Android", "phone")
+	categorize("PRODUCT2", "CATEGORY3", "snack")
+	sync(t, products, index)
+
+	tt := []struct {
+		Name     string
+		Filter   transaction.ProductFilter
+		Expected []string
+		Err      error
+	}{
+		{Name: "Category", Filter: transaction.ProductFilter{Categories: []string{"CATEGORY2"}}, Expected: []string{"PRODUCT1"}},
+		{Name: "Subcategories", Filter: transaction.ProductFilter{Categories: []string{"CATEGORY1"}}, Expected: []string{"PRODUCT1"}},
+		{Name: "Many Categories", Filter: transaction.ProductFilter{Categories: []string{"CATEGORY1", "CATEGORY3"}}, Expected: []string{"PRODUCT1", "PRODUCT2"}},
+		{Name: "Tag", Filter: transaction.ProductFilter{Tag: "ANDROID"}, Expected: []string{"PRODUCT1"}},
+		{Name: "Category And Tag", Filter: transaction.ProductFilter{Categories: []string{"CATEGORY3"}, Tag: "android"}, Expected: []string{}},
+		{Name: "Unknown Category", Filter: transaction.ProductFilter{Categories: []string{"UNKNOWN"}}, Err: transaction.ErrCategoryNotFound},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			result, err := s.ListProducts(ctx, tc.Filter, transaction.Page{})
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if err != nil {
+				return
+			}
+			ids := make([]string, 0)
+			for _, p := range result.Products {
+				ids = append(ids, p.ID)
+			}
+			if diff := cmp.Diff(tc.Expected, ids); diff != "" {
+				t.Fatalf("(-expected +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestViewProduct(t *testing.T) {
+	s := catalog.NewService(inmem.NewProductRepository(), inmem.NewCategoryRepository(), search.NewIndex())
+
+	p, err := s.ViewProduct(context.Background(), "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if p.ID != "PRODUCT1" || p.Name != "Sony Xperia 10" {
+		t.Errorf("got %+v, expected PRODUCT1", p)
+	}
+
+	if _, err := s.ViewProduct(context.Background(), "UNKNOWN"); !errors.Is(err, transaction.ErrProductNotFound) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrProductNotFound)
+	}
+}
+
+func TestSearchProducts(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		index    = search.NewIndex()
+		s        = catalog.NewService(products, inmem.NewCategoryRepository(), index)
+		ctx      = context.Background()
+	)
+	sync(t, products, index)
+
+	find := func(query string) []string {
+		t.Helper()
+		result, err := s.SearchProducts(ctx, query, 0)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		ids := make([]string, 0)
+		for _, p := range result {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	if diff := cmp.Diff([]string{"PRODUCT1"}, find("xperia")); diff != "" {
+		t.Fatalf("(-expected +got): %s", diff)
+	}
+
+	// changed products are found once the index is synced
+	p, err := products.FindByID(ctx, "PRODUCT2")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := p.Describe("Compatible with Xperia chargers"); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := products.Update(ctx, p); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if diff := cmp.Diff([]string{"PRODUCT1"}, find("xperia")); diff != "" {
+		t.Fatalf("(-expected +got): %s", diff)
+	}
+	sync(t, products, index)
+	if diff := cmp.Diff([]string{"PRODUCT1", "PRODUCT2"}, find("xperia")); diff != "" {
+		t.Fatalf("(-expected +got): %s", diff)
+	}
+
+	p, err = products.FindByID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := p.Archive(); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := products.Update(ctx, p); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	sync(t, products, index)
+	if diff := cmp.Diff([]string{"PRODUCT2"}, find("xperia")); diff != "" {
+		t.Fatalf("(-expected +got): %s", diff)
+	}
+}
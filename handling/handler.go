@@ -3,26 +3,60 @@ package handling
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/problem"
 	"github.com/muktihari/order-transaction-ddd/transaction"
 )
 
-// MakeHandler create RestAPI handler
-func MakeHandler(s Service) http.Handler {
+var (
+	// ErrInvalidArgument occurs when query argument is invalid
+	ErrInvalidArgument = transaction.NewError(transaction.KindInvalid, "invalid_argument", "invalid argument")
+)
+
+// MakeHandler create RestAPI handler, every request is authenticated by authenticate,
+// then POST and PUT requests carrying an idempotency key are made idempotent by idempotent
+func MakeHandler(s Service, authenticate, idempotent auth.Middleware) http.Handler {
 	r := chi.NewRouter()
+	r.Use(authenticate)
+	r.Use(idempotent)
 
 	r.Get("/order/{order_id}/view", func(w http.ResponseWriter, r *http.Request) {
 		orderID := chi.URLParam(r, "order_id")
 		o, err := s.ViewOrder(r.Context(), orderID)
 		if err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		if err := json.NewEncoder(w).Encode(o); err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Get("/orders", func(w http.ResponseWriter, r *http.Request) {
+		filter, page, err := decodeOrderQuery(r.URL.Query())
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		result, err := s.FindOrders(r.Context(), filter, page)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			problem.Write(w, err)
 			return
 		}
 	})
@@ -30,7 +64,7 @@ func MakeHandler(s Service) http.Handler {
 	r.Post("/order/{order_id}/cancel", func(w http.ResponseWriter, r *http.Request) {
 		orderID := chi.URLParam(r, "order_id")
 		if err := s.CancelOrder(r.Context(), orderID); err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 	})
@@ -39,7 +73,7 @@ func MakeHandler(s Service) http.Handler {
 		orderID := chi.URLParam(r, "order_id")
 		shippingID, err := s.ShipOrderToLogisticsPartner(r.Context(), orderID)
 		if err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 
@@ -49,7 +83,25 @@ func MakeHandler(s Service) http.Handler {
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Get("/products/low-stock", func(w http.ResponseWriter, r *http.Request) {
+		products, err := s.ListLowStockProducts(r.Context())
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		var response = map[string]interface{}{
+			"products": products,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			problem.Write(w, err)
 			return
 		}
 	})
@@ -57,20 +109,50 @@ func MakeHandler(s Service) http.Handler {
 	return r
 }
 
-func encodeError(err error, w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+// decodeOrderQuery decodes order filter and page from query, e.g:
+// ?customer_id=CUSTOMER1&status=2&status=3&created_from=2021-01-01T00:00:00Z&created_to=2021-02-01T00:00:00Z
+// &coupon_code=DISCOUNT_20%25&product_id=PRODUCT1&sort=oldest&limit=20&cursor=...
+func decodeOrderQuery(q url.Values) (filter transaction.OrderFilter, page transaction.Page, err error) {
+	filter.CustomerID = q.Get("customer_id")
+	filter.CouponCode = q.Get("coupon_code")
+	filter.ProductID = q.Get("product_id")
+
+	for _, val := range q["status"] {
+		for _, s := range strings.Split(val, ",") {
+			status, err := strconv.Atoi(s)
+			if err != nil {
+				return filter, page, ErrInvalidArgument
+			}
+			filter.Statuses = append(filter.Statuses, transaction.OrderStatus(status))
+		}
+	}
+
+	if val := q.Get("created_from"); val != "" {
+		if filter.CreatedFrom, err = time.Parse(time.RFC3339, val); err != nil {
+			return filter, page, ErrInvalidArgument
+		}
+	}
+	if val := q.Get("created_to"); val != "" {
+		if filter.CreatedTo, err = time.Parse(time.RFC3339, val); err != nil {
+			return filter, page, ErrInvalidArgument
+		}
+	}
 
-	switch err {
-	case transaction.ErrOrderNotFound:
-		w.WriteHeader(http.StatusNotFound)
-	case transaction.ErrOrderIsAlreadyFinalized:
-		w.WriteHeader(http.StatusConflict)
+	switch q.Get("sort") {
+	case "", "newest":
+		filter.Sort = transaction.OrderSortNewest
+	case "oldest":
+		filter.Sort = transaction.OrderSortOldest
 	default:
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return filter, page, ErrInvalidArgument
 	}
 
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": err.Error(),
-	})
+	page.Cursor = q.Get("cursor")
+	if val := q.Get("limit"); val != "" {
+		if page.Limit, err = strconv.Atoi(val); err != nil {
+			return filter, page, ErrInvalidArgument
+		}
+	}
+
+	return filter, page, nil
 }
@@ -33,6 +33,14 @@ func (s *instrumentingService) ViewOrder(ctx context.Context, orderID string) (o
 	return s.Service.ViewOrder(ctx, orderID)
 }
 
+func (s *instrumentingService) FindOrders(ctx context.Context, filter transaction.OrderFilter, page transaction.Page) (result *transaction.OrderPage, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("find_orders", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("find_orders", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.FindOrders(ctx, filter, page)
+}
+
 func (s *instrumentingService) CancelOrder(ctx context.Context, orderID string) (err error) {
 	defer func(begin time.Time) {
 		s.request.WithLabelValues("cancel_order", fmt.Sprintf("%t", err != nil)).Inc()
@@ -48,3 +56,11 @@ func (s *instrumentingService) ShipOrderToLogisticsPartner(ctx context.Context,
 	}(time.Now())
 	return s.Service.ShipOrderToLogisticsPartner(ctx, orderID)
 }
+
+func (s *instrumentingService) ListLowStockProducts(ctx context.Context) (products []transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("list_low_stock_products", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("list_low_stock_products", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.ListLowStockProducts(ctx)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/muktihari/order-transaction-ddd/auth"
 	"github.com/muktihari/order-transaction-ddd/transaction"
 	log "github.com/sirupsen/logrus"
 )
@@ -21,22 +22,46 @@ func NewLoggingService(log *log.Logger, s Service) Service {
 func (s *loggingService) ViewOrder(ctx context.Context, orderID string) (order *transaction.Order, err error) {
 	defer func(begin time.Time) {
 		s.log.WithFields(log.Fields{
-			"method":   "view_order",
-			"order_id": orderID,
-			"took":     time.Since(begin),
-			"err":      err,
+			"method":     "view_order",
+			"admin_id":   adminID(ctx),
+			"api_key_id": apiKeyID(ctx),
+			"order_id":   orderID,
+			"took":       time.Since(begin),
+			"err":        err,
 		}).Println()
 	}(time.Now())
 	return s.Service.ViewOrder(ctx, orderID)
 }
 
+func (s *loggingService) FindOrders(ctx context.Context, filter transaction.OrderFilter, page transaction.Page) (result *transaction.OrderPage, err error) {
+	defer func(begin time.Time) {
+		var count int
+		if result != nil {
+			count = len(result.Orders)
+		}
+		s.log.WithFields(log.Fields{
+			"method":     "find_orders",
+			"admin_id":   adminID(ctx),
+			"api_key_id": apiKeyID(ctx),
+			"filter":     filter,
+			"limit":      page.Size(),
+			"count":      count,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.FindOrders(ctx, filter, page)
+}
+
 func (s *loggingService) CancelOrder(ctx context.Context, orderID string) (err error) {
 	defer func(begin time.Time) {
 		s.log.WithFields(log.Fields{
-			"method":   "cancel_order",
-			"order_id": orderID,
-			"took":     time.Since(begin),
-			"err":      err,
+			"method":     "cancel_order",
+			"admin_id":   adminID(ctx),
+			"api_key_id": apiKeyID(ctx),
+			"order_id":   orderID,
+			"took":       time.Since(begin),
+			"err":        err,
 		}).Println()
 	}(time.Now())
 	return s.Service.CancelOrder(ctx, orderID)
@@ -46,6 +71,8 @@ func (s *loggingService) ShipOrderToLogisticsPartner(ctx context.Context, orderI
 	defer func(begin time.Time) {
 		s.log.WithFields(log.Fields{
 			"method":      "ship_order_to_logistics_partner",
+			"admin_id":    adminID(ctx),
+			"api_key_id":  apiKeyID(ctx),
 			"order_id":    orderID,
 			"shipping_id": shippingID,
 			"took":        time.Since(begin),
@@ -54,3 +81,33 @@ func (s *loggingService) ShipOrderToLogisticsPartner(ctx context.Context, orderI
 	}(time.Now())
 	return s.Service.ShipOrderToLogisticsPartner(ctx, orderID)
 }
+
+func (s *loggingService) ListLowStockProducts(ctx context.Context) (products []transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "list_low_stock_products",
+			"admin_id":   adminID(ctx),
+			"api_key_id": apiKeyID(ctx),
+			"count":      len(products),
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.ListLowStockProducts(ctx)
+}
+
+// adminID returns the ID of the admin authenticated in ctx, empty when there is none
+func adminID(ctx context.Context) string {
+	if a, ok := auth.AdminFromContext(ctx); ok {
+		return a.ID
+	}
+	return ""
+}
+
+// apiKeyID returns the ID of the API key authenticated in ctx, empty when there is none
+func apiKeyID(ctx context.Context) string {
+	if k, ok := auth.APIKeyFromContext(ctx); ok {
+		return k.ID
+	}
+	return ""
+}
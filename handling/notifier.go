@@ -0,0 +1,29 @@
+package handling
+
+import (
+	"context"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	log "github.com/sirupsen/logrus"
+)
+
+type loggingNotifier struct {
+	log *log.Logger
+}
+
+// NewLoggingNotifier creates stock notifier writing alerts to the log, it's the default notifier
+// until alerts are delivered to the people in charge of reordering.
+func NewLoggingNotifier(log *log.Logger) transaction.StockNotifier {
+	return &loggingNotifier{log}
+}
+
+func (n *loggingNotifier) NotifyStockAlert(ctx context.Context, alert transaction.StockAlert) {
+	n.log.WithFields(log.Fields{
+		"event":             "stock_alert",
+		"kind":              alert.Kind,
+		"product_id":        alert.ProductID,
+		"name":              alert.Name,
+		"quantity":          alert.Quantity,
+		"reorder_threshold": alert.ReorderThreshold,
+	}).Warnln()
+}
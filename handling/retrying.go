@@ -0,0 +1,37 @@
+package handling
+
+import (
+	"context"
+	"errors"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type retryingService struct {
+	attempts int
+	Service
+}
+
+// NewRetryingService creates new retrying service. Commands failed with transaction.ErrConcurrentModification
+// are re-run up to attempts times, each run loads fresh aggregates from the repositories.
+//
+// ShipOrderToLogisticsPartner is not retried since registering a shipment to logistics partner can not be undone.
+func NewRetryingService(attempts int, s Service) Service {
+	return &retryingService{attempts, s}
+}
+
+func (s *retryingService) retry(fn func() error) (err error) {
+	for i := 0; i < s.attempts; i++ {
+		err = fn()
+		if !errors.Is(err, transaction.ErrConcurrentModification) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *retryingService) CancelOrder(ctx context.Context, orderID string) error {
+	return s.retry(func() error {
+		return s.Service.CancelOrder(ctx, orderID)
+	})
+}
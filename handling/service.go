@@ -4,24 +4,36 @@ package handling
 
 import (
 	"context"
+	"sort"
 
+	"github.com/muktihari/order-transaction-ddd/auth"
 	"github.com/muktihari/order-transaction-ddd/transaction"
 )
 
-// Service is the interface that provides handling method.
+// Service is the interface that provides handling method. Methods act on behalf of the admin or the API key authenticated
+// in the context, each method requires the permission told by its comment. Admins whose role lacks it and API keys
+// whose scopes lack it are rejected with auth.ErrForbidden.
 type Service interface {
-	// ViewOrder views order details
+	// ViewOrder views order details, requires transaction.PermissionViewOrders
 	ViewOrder(ctx context.Context, orderID string) (*transaction.Order, error)
-	// CancelOrder cancels order
+	// FindOrders lists orders matching the filter, a page at a time, requires transaction.PermissionViewOrders
+	FindOrders(ctx context.Context, filter transaction.OrderFilter, page transaction.Page) (*transaction.OrderPage, error)
+	// CancelOrder cancels order, the released stock is recorded as released by the admin or the API key. Requires transaction.PermissionCancelOrders
 	CancelOrder(ctx context.Context, orderID string) error
 	// ShipOrderToLogisticsPartner ships the order to logistics partner. It will update shippingID on order
+	// and record the admin or the API key who shipped it.
+	// Requires transaction.PermissionShipOrders
 	ShipOrderToLogisticsPartner(ctx context.Context, orderID string) (transaction.ShippingID, error)
+	// ListLowStockProducts lists products on sale having stock at or below their reorder threshold, the lowest stock first,
+	// requires transaction.PermissionViewStock
+	ListLowStockProducts(ctx context.Context) ([]transaction.Product, error)
 }
 
 type service struct {
 	orders    transaction.OrderRepository
 	products  transaction.ProductRepository
 	logistics transaction.LogisticsPartner
+	uow       transaction.UnitOfWork
 }
 
 // NewService creates a handling service with necessary dependencies
@@ -29,38 +41,119 @@ func NewService(
 	orders transaction.OrderRepository,
 	products transaction.ProductRepository,
 	logistics transaction.LogisticsPartner,
+	uow transaction.UnitOfWork,
 ) Service {
 	return &service{
 		orders:    orders,
 		products:  products,
 		logistics: logistics,
+		uow:       uow,
 	}
 }
 
 func (s *service) ViewOrder(ctx context.Context, orderID string) (*transaction.Order, error) {
+	if _, err := auth.Authorize(ctx, transaction.PermissionViewOrders); err != nil {
+		return nil, err
+	}
 	return s.orders.FindByID(ctx, orderID)
 }
 
-func (s *service) CancelOrder(ctx context.Context, orderID string) error {
-	o, err := s.orders.FindByID(ctx, orderID)
-	if err != nil {
-		return err
-	}
-
-	if err := o.ChangeStatusTo(transaction.OrderStatusCancelled); err != nil {
-		return err
+func (s *service) FindOrders(ctx context.Context, filter transaction.OrderFilter, page transaction.Page) (*transaction.OrderPage, error) {
+	if _, err := auth.Authorize(ctx, transaction.PermissionViewOrders); err != nil {
+		return nil, err
 	}
+	return s.orders.Find(ctx, filter, page)
+}
 
-	if err := s.orders.CancelAndReleaseProducts(ctx, o); err != nil {
+func (s *service) CancelOrder(ctx context.Context, orderID string) error {
+	actor, err := auth.Authorize(ctx, transaction.PermissionCancelOrders)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.uow.Do(ctx, func(ctx context.Context, r transaction.Repositories) error {
+		o, err := r.Orders().FindByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		var released []string
+		if o.HoldsReservation() {
+			if o.HasCoupon() {
+				c, err := r.Coupons().FindByCode(ctx, o.Coupon.Code)
+				if err != nil {
+					return err
+				}
+				c.Release()
+				if err := r.Coupons().Update(ctx, c); err != nil {
+					return err
+				}
+			}
+
+			for _, cartItem := range o.Cart {
+				if len(cartItem.Components) != 0 {
+					bundleReleased, err := transaction.ReleaseBundle(ctx, r, o, cartItem, actor)
+					if err != nil {
+						return err
+					}
+					released = append(released, bundleReleased...)
+					continue
+				}
+				// backordered quantity was never taken from stock
+				reserved := cartItem.Quantity - cartItem.Backordered
+				if reserved == 0 {
+					continue
+				}
+				p, err := r.Products().FindByID(ctx, cartItem.Product.ID)
+				if err != nil {
+					return err
+				}
+				p.RollbackQuantity(cartItem.SKU, reserved)
+				if err := r.Products().Update(ctx, p); err != nil {
+					return err
+				}
+				released = append(released, p.ID)
+
+				m := transaction.NewInventoryMovement(p.ID, cartItem.SKU, reserved, transaction.MovementReasonOrderRelease, actor)
+				m.OrderID = o.ID
+				if err := r.Inventory().Store(ctx, m); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := o.ChangeStatusTo(transaction.OrderStatusCancelled); err != nil {
+			return err
+		}
+		if err := r.Orders().Update(ctx, o); err != nil {
+			return err
+		}
+
+		// released stock goes to the orders waiting for it, once the canceled order no longer waits itself
+		for _, productID := range released {
+			p, err := r.Products().FindByID(ctx, productID)
+			if err != nil {
+				return err
+			}
+			if err := transaction.AllocateBackorders(ctx, r, p); err != nil {
+				return err
+			}
+			if err := r.Products().Update(ctx, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (s *service) ShipOrderToLogisticsPartner(ctx context.Context, orderID string) (transaction.ShippingID, error) {
 	var shippingID transaction.ShippingID
 
+	actor, err := auth.Authorize(ctx, transaction.PermissionShipOrders)
+	if err != nil {
+		return shippingID, err
+	}
+
 	o, err := s.orders.FindByID(ctx, orderID)
 	if err != nil {
 		return shippingID, err
@@ -75,12 +168,16 @@ func (s *service) ShipOrderToLogisticsPartner(ctx context.Context, orderID strin
 		return shippingID, err
 	}
 
+	// reloaded since the order may have changed while registering the shipment, the status is checked again on it
 	o, err = s.orders.FindByID(ctx, orderID)
 	if err != nil {
 		return shippingID, err
 	}
 
-	o.SpecifyShippingID(shippingID)
+	if err := o.ChangeStatusTo(transaction.OrderStatusShipped); err != nil {
+		return shippingID, err
+	}
+	o.SpecifyShippingID(shippingID, actor)
 
 	if err := s.orders.Update(ctx, o); err != nil {
 		return shippingID, err
@@ -88,3 +185,28 @@ func (s *service) ShipOrderToLogisticsPartner(ctx context.Context, orderID strin
 
 	return shippingID, nil
 }
+
+func (s *service) ListLowStockProducts(ctx context.Context) ([]transaction.Product, error) {
+	if _, err := auth.Authorize(ctx, transaction.PermissionViewStock); err != nil {
+		return nil, err
+	}
+	all, err := s.products.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]transaction.Product, 0)
+	for i := range all {
+		if all[i].LowStock() {
+			products = append(products, all[i])
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		if products[i].Quantity != products[j].Quantity {
+			return products[i].Quantity < products[j].Quantity
+		}
+		return products[i].ID < products[j].ID
+	})
+	return products, nil
+}
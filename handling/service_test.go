@@ -0,0 +1,180 @@
+package handling_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/handling"
+	"github.com/muktihari/order-transaction-ddd/ordering"
+	"github.com/muktihari/order-transaction-ddd/persistent/inmem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type stockNotifier struct{}
+
+func (stockNotifier) NotifyStockAlert(ctx context.Context, alert transaction.StockAlert) {}
+
+// adminContext returns context authenticated as the admin having the role
+func adminContext(adminID string, role transaction.Role) context.Context {
+	return auth.WithAdmin(context.Background(), &transaction.Admin{ID: adminID, Role: role})
+}
+
+// apiKeyContext returns context authenticated by the API key having the scopes
+func apiKeyContext(apiKeyID string, scopes ...transaction.Permission) context.Context {
+	return auth.WithAPIKey(context.Background(), &transaction.APIKey{ID: apiKeyID, Scopes: scopes})
+}
+
+func TestPermissions(t *testing.T) {
+	var (
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = handling.NewService(orders, products, inmem.NewLogisticsParner(), uow)
+	)
+
+	var (
+		view = func(ctx context.Context) error {
+			_, err := s.ViewOrder(ctx, "ORDER_WITH_PRODUCT_AND_COUPON")
+			return err
+		}
+		find = func(ctx context.Context) error {
+			_, err := s.FindOrders(ctx, transaction.OrderFilter{}, transaction.Page{})
+			return err
+		}
+		cancel = func(ctx context.Context) error {
+			return s.CancelOrder(ctx, "ORDER_WITH_PRODUCT_AND_COUPON")
+		}
+		ship = func(ctx context.Context) error {
+			_, err := s.ShipOrderToLogisticsPartner(ctx, "ORDER_WITH_PRODUCT_AND_COUPON")
+			return err
+		}
+		lowStock = func(ctx context.Context) error {
+			_, err := s.ListLowStockProducts(ctx)
+			return err
+		}
+	)
+
+	tt := []struct {
+		Name string
+		Ctx  context.Context
+		Call func(ctx context.Context) error
+		Err  error
+	}{
+		{Name: "Unauthenticated View", Ctx: context.Background(), Call: view, Err: auth.ErrUnauthenticated},
+		{Name: "Unauthenticated Low Stock", Ctx: context.Background(), Call: lowStock, Err: auth.ErrUnauthenticated},
+		{Name: "Unknown Role View", Ctx: adminContext("ADMIN1", "owner"), Call: view, Err: auth.ErrForbidden},
+		{Name: "Viewer View", Ctx: adminContext("ADMIN1", transaction.RoleViewer), Call: view},
+		{Name: "Viewer Find", Ctx: adminContext("ADMIN1", transaction.RoleViewer), Call: find},
+		{Name: "Viewer Low Stock", Ctx: adminContext("ADMIN1", transaction.RoleViewer), Call: lowStock},
+		{Name: "Viewer Cancel", Ctx: adminContext("ADMIN1", transaction.RoleViewer), Call: cancel, Err: auth.ErrForbidden},
+		{Name: "Viewer Ship", Ctx: adminContext("ADMIN1", transaction.RoleViewer), Call: ship, Err: auth.ErrForbidden},
+		{Name: "Fulfillment Cancel", Ctx: adminContext("ADMIN1", transaction.RoleFulfillment), Call: cancel, Err: auth.ErrForbidden},
+		{Name: "Finance Ship", Ctx: adminContext("ADMIN1", transaction.RoleFinance), Call: ship, Err: auth.ErrForbidden},
+		{Name: "Superadmin View", Ctx: adminContext("ADMIN1", transaction.RoleSuperadmin), Call: view},
+		{Name: "API Key Read View", Ctx: apiKeyContext("KEY1", transaction.PermissionViewOrders), Call: view},
+		{Name: "API Key Read Find", Ctx: apiKeyContext("KEY1", transaction.PermissionViewOrders), Call: find},
+		{Name: "API Key Read Ship", Ctx: apiKeyContext("KEY1", transaction.PermissionViewOrders), Call: ship, Err: auth.ErrForbidden},
+		{Name: "API Key Ship Cancel", Ctx: apiKeyContext("KEY1", transaction.PermissionShipOrders), Call: cancel, Err: auth.ErrForbidden},
+		{Name: "API Key Ship View", Ctx: apiKeyContext("KEY1", transaction.PermissionShipOrders), Call: view, Err: auth.ErrForbidden},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := tc.Call(tc.Ctx); !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+		})
+	}
+
+	o, err := orders.FindByID(context.Background(), "ORDER_WITH_PRODUCT_AND_COUPON")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if o.Status == transaction.OrderStatusCancelled || o.ShippingID != "" || o.ShippedBy != "" {
+		t.Errorf("got order %+v, expected unchanged by forbidden admins", o)
+	}
+}
+
+func TestCancelOrderActor(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		o         = ordering.NewService(orders, customers, products, coupons, logistics, stockNotifier{}, uow)
+		s         = handling.NewService(orders, products, logistics, uow)
+	)
+
+	customerCtx := auth.WithCustomer(context.Background(), &transaction.Customer{ID: "CUSTOMER1"})
+	if err := o.SubmitOrder(customerCtx, "ORDER_WITH_PRODUCT_AND_COUPON"); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	if err := s.CancelOrder(adminContext("ADMIN2", transaction.RoleFinance), "ORDER_WITH_PRODUCT_AND_COUPON"); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	movements, err := inventory.FindByProductID(context.Background(), "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	var released bool
+	for _, m := range movements {
+		if m.Reason != transaction.MovementReasonOrderRelease {
+			continue
+		}
+		released = true
+		if m.Actor != transaction.AdminActor("ADMIN2") {
+			t.Errorf("got release by %q, expected %q", m.Actor, transaction.AdminActor("ADMIN2"))
+		}
+	}
+	if !released {
+		t.Errorf("got movements %+v, expected release of the canceled order", movements)
+	}
+}
+
+func TestShipOrderActor(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		o         = ordering.NewService(orders, customers, products, coupons, logistics, stockNotifier{}, uow)
+		s         = handling.NewService(orders, products, logistics, uow)
+	)
+
+	customerCtx := auth.WithCustomer(context.Background(), &transaction.Customer{ID: "CUSTOMER1"})
+	if err := o.SubmitOrder(customerCtx, "ORDER_WITH_PRODUCT_AND_COUPON"); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	shippingID, err := s.ShipOrderToLogisticsPartner(apiKeyContext("KEY1", transaction.PermissionShipOrders), "ORDER_WITH_PRODUCT_AND_COUPON")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	shipped, err := orders.FindByID(context.Background(), "ORDER_WITH_PRODUCT_AND_COUPON")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if shipped.Status != transaction.OrderStatusShipped {
+		t.Errorf("got status %s, expected %s", shipped.Status, transaction.OrderStatusShipped)
+	}
+	if shipped.ShippingID != shippingID || shipped.ShippedBy != transaction.APIKeyActor("KEY1") {
+		t.Errorf("got shipping ID %q shipped by %q, expected %q shipped by %q", shipped.ShippingID, shipped.ShippedBy, shippingID, transaction.APIKeyActor("KEY1"))
+	}
+
+	if _, err := s.ShipOrderToLogisticsPartner(apiKeyContext("KEY1", transaction.PermissionShipOrders), "ORDER_WITH_PRODUCT_AND_COUPON"); !errors.Is(err, transaction.ErrOrderIsAlreadyShipped) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrOrderIsAlreadyShipped)
+	}
+}
@@ -0,0 +1,183 @@
+// Package idempotency contains middleware replaying the stored response to repeats of a request made with an idempotency key,
+// so clients retrying on flaky networks don't make an order or ship it twice.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/problem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+const (
+	// HeaderKey is the request header carrying the idempotency key
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed is set on responses replayed from a previous request
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	// storeTimeout bounds storing the response, it's done even when the request is canceled
+	storeTimeout = 5 * time.Second
+)
+
+var (
+	// ErrInvalidKey tells that the idempotency key is longer than 255 characters
+	ErrInvalidKey = transaction.NewError(transaction.KindInvalid, "invalid_idempotency_key", "error invalid idempotency key")
+	// ErrKeyReused tells that the idempotency key was used by another request of the client
+	ErrKeyReused = transaction.NewError(transaction.KindUnprocessable, "idempotency_key_reused", "error idempotency key is reused by a different request")
+	// ErrRequestInProgress tells that the request made with the idempotency key hasn't been responded yet
+	ErrRequestInProgress = transaction.NewError(transaction.KindConflict, "idempotency_request_in_progress", "error request of the idempotency key is in progress")
+)
+
+// Middleware creates middleware making POST and PUT requests carrying Idempotency-Key header idempotent.
+// The response is stored per key and authenticated client, repeats of the request get the stored response
+// and a different request reusing the key is rejected with 422. Only successful responses and client errors the repeat would get again
+// are stored, server errors, conflicts, rate limits and panics release the key, so the request can be retried.
+// It has to be used after the authenticating middleware, requests of unauthenticated clients are passed through.
+func Middleware(records transaction.IdempotencyRepository) auth.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+			client, ok := auth.ActorFromContext(r.Context())
+			if key == "" || !ok || (r.Method != http.MethodPost && r.Method != http.MethodPut) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				problem.Write(w, ErrInvalidKey)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				problem.Write(w, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			record := &transaction.IdempotencyRecord{
+				Client:      client,
+				Key:         key,
+				Fingerprint: fingerprint(r, body),
+				CreatedAt:   time.Now().UTC(),
+			}
+			if err := records.Reserve(r.Context(), record); err != nil {
+				if errors.Is(err, transaction.ErrIdempotencyKeyExists) {
+					replay(records, record, w, r)
+					return
+				}
+				problem.Write(w, err)
+				return
+			}
+
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				// runs when the handler panics too, a reservation left behind would reject every retry until it expires.
+				// The request context is not used since it's canceled when the client goes away.
+				ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+				defer cancel()
+				_ = records.Release(ctx, client, key)
+			}()
+
+			rec := &recorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			record.StatusCode = rec.statusCode()
+			if !storable(record.StatusCode) {
+				return
+			}
+			record.ContentType = rec.Header().Get("Content-Type")
+			record.Body = rec.body.Bytes()
+
+			// the response has been made, it's stored even when the client is gone so its retry gets it
+			ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+			defer cancel()
+			completed = records.Complete(ctx, record) == nil
+		})
+	}
+}
+
+// replay writes the stored response of the request of the record's key, when it's a repeat of the request
+func replay(records transaction.IdempotencyRepository, record *transaction.IdempotencyRecord, w http.ResponseWriter, r *http.Request) {
+	stored, err := records.Find(r.Context(), record.Client, record.Key)
+	if errors.Is(err, transaction.ErrIdempotencyKeyNotFound) {
+		// released in the meantime by a request ending with server error
+		err = ErrRequestInProgress
+	}
+	if err != nil {
+		problem.Write(w, err)
+		return
+	}
+	if !bytes.Equal(stored.Fingerprint, record.Fingerprint) {
+		problem.Write(w, ErrKeyReused)
+		return
+	}
+	if !stored.Completed() {
+		problem.Write(w, ErrRequestInProgress)
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(stored.StatusCode)
+	_, _ = w.Write(stored.Body)
+}
+
+// storable tells whether the response of the status code is stored, it's not when the repeat of the request may succeed
+func storable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooEarly, http.StatusTooManyRequests:
+		return false
+	}
+	return statusCode < http.StatusInternalServerError
+}
+
+// fingerprint returns the hash of the method, the path and the body of the request
+func fingerprint(r *http.Request, body []byte) []byte {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	_, _ = h.Write(body)
+	return h.Sum(nil)
+}
+
+// recorder keeps the status code and the body written to the response
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(statusCode int) {
+	if rec.status == 0 {
+		rec.status = statusCode
+	}
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// statusCode returns the status code of the response, handlers writing nothing respond with 200
+func (rec *recorder) statusCode() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
@@ -0,0 +1,206 @@
+package idempotency_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/idempotency"
+	"github.com/muktihari/order-transaction-ddd/persistent/inmem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// customerContext returns context authenticated as the customer
+func customerContext(customerID string) context.Context {
+	return auth.WithCustomer(context.Background(), &transaction.Customer{ID: customerID})
+}
+
+// contextRecords fails writes made with a done context, as a database would
+type contextRecords struct {
+	transaction.IdempotencyRepository
+}
+
+func (r contextRecords) Complete(ctx context.Context, record *transaction.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.IdempotencyRepository.Complete(ctx, record)
+}
+
+func (r contextRecords) Release(ctx context.Context, client, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.IdempotencyRepository.Release(ctx, client, key)
+}
+
+func TestMiddleware(t *testing.T) {
+	var calls int
+	failWith := 0
+	handler := idempotency.Middleware(inmem.NewIdempotencyRepository())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if failWith != 0 {
+			w.WriteHeader(failWith)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprintf(w, `{"call":%d}`, calls)
+	}))
+
+	tt := []struct {
+		Name     string
+		Ctx      context.Context
+		Method   string
+		Path     string
+		Key      string
+		Body     string
+		FailWith int
+		Status   int
+		Response string
+		Replayed bool
+		Calls    int
+	}{
+		{Name: "First Request", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/make", Key: "KEY1", Body: `{"customer_id":"CUSTOMER1"}`, Status: http.StatusOK, Response: `{"call":1}`, Calls: 1},
+		{Name: "Repeat Replayed", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/make", Key: "KEY1", Body: `{"customer_id":"CUSTOMER1"}`, Status: http.StatusOK, Response: `{"call":1}`, Replayed: true, Calls: 1},
+		{Name: "Key Reused With Different Body", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/make", Key: "KEY1", Body: `{"customer_id":"CUSTOMER2"}`, Status: http.StatusUnprocessableEntity, Calls: 1},
+		{Name: "Key Reused On Different Path", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/ORDER1/submit", Key: "KEY1", Body: `{"customer_id":"CUSTOMER1"}`, Status: http.StatusUnprocessableEntity, Calls: 1},
+		{Name: "Same Key Of Other Client", Ctx: customerContext("CUSTOMER2"), Method: http.MethodPost, Path: "/order/make", Key: "KEY1", Body: `{"customer_id":"CUSTOMER1"}`, Status: http.StatusOK, Response: `{"call":2}`, Calls: 2},
+		{Name: "Without Key", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/make", Body: `{"customer_id":"CUSTOMER1"}`, Status: http.StatusOK, Response: `{"call":3}`, Calls: 3},
+		{Name: "GET Passed Through", Ctx: customerContext("CUSTOMER1"), Method: http.MethodGet, Path: "/order/ORDER1/status", Key: "KEY1", Status: http.StatusOK, Response: `{"call":4}`, Calls: 4},
+		{Name: "Key Too Long", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPut, Path: "/order/ORDER1/applycoupon", Key: strings.Repeat("K", 256), Status: http.StatusBadRequest, Calls: 4},
+		{Name: "Server Error Not Stored", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/ORDER1/submit", Key: "KEY2", FailWith: http.StatusInternalServerError, Status: http.StatusInternalServerError, Calls: 5},
+		{Name: "Retry After Server Error", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/ORDER1/submit", Key: "KEY2", Status: http.StatusOK, Response: `{"call":6}`, Calls: 6},
+		{Name: "Conflict Not Stored", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/ORDER1/ship", Key: "KEY3", FailWith: http.StatusConflict, Status: http.StatusConflict, Calls: 7},
+		{Name: "Retry After Conflict", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/ORDER1/ship", Key: "KEY3", Status: http.StatusOK, Response: `{"call":8}`, Calls: 8},
+		{Name: "Rate Limited Not Stored", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/ORDER1/cancel", Key: "KEY4", FailWith: http.StatusTooManyRequests, Status: http.StatusTooManyRequests, Calls: 9},
+		{Name: "Retry After Rate Limited", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPost, Path: "/order/ORDER1/cancel", Key: "KEY4", Status: http.StatusOK, Response: `{"call":10}`, Calls: 10},
+		{Name: "Validation Error Stored", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPut, Path: "/order/ORDER1/applycoupon", Key: "KEY5", FailWith: http.StatusBadRequest, Status: http.StatusBadRequest, Calls: 11},
+		{Name: "Repeat Of Validation Error Replayed", Ctx: customerContext("CUSTOMER1"), Method: http.MethodPut, Path: "/order/ORDER1/applycoupon", Key: "KEY5", Status: http.StatusBadRequest, Replayed: true, Calls: 11},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			failWith = tc.FailWith
+			r := httptest.NewRequest(tc.Method, tc.Path, strings.NewReader(tc.Body)).WithContext(tc.Ctx)
+			if tc.Key != "" {
+				r.Header.Set(idempotency.HeaderKey, tc.Key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tc.Status {
+				t.Fatalf("got status %d, expected %d", w.Code, tc.Status)
+			}
+			if tc.Response != "" && w.Body.String() != tc.Response {
+				t.Errorf("got response %s, expected %s", w.Body.String(), tc.Response)
+			}
+			if replayed := w.Header().Get(idempotency.HeaderReplayed) == "true"; replayed != tc.Replayed {
+				t.Errorf("got replayed %t, expected %t", replayed, tc.Replayed)
+			}
+			if calls != tc.Calls {
+				t.Errorf("got %d calls of the handler, expected %d", calls, tc.Calls)
+			}
+		})
+	}
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	records := inmem.NewIdempotencyRepository()
+	handler := idempotency.Middleware(records)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first request is still being handled when its repeat arrives
+		repeat := httptest.NewRequest(http.MethodPost, "/order/ORDER1/ship", nil).WithContext(r.Context())
+		repeat.Header.Set(idempotency.HeaderKey, "KEY1")
+		rw := httptest.NewRecorder()
+		idempotency.Middleware(records)(http.NotFoundHandler()).ServeHTTP(rw, repeat)
+		if rw.Code != http.StatusConflict {
+			t.Errorf("got status %d of the repeat, expected %d", rw.Code, http.StatusConflict)
+		}
+	}))
+
+	ctx := auth.WithAdmin(context.Background(), &transaction.Admin{ID: "ADMIN1", Role: transaction.RoleSuperadmin})
+	r := httptest.NewRequest(http.MethodPost, "/order/ORDER1/ship", nil).WithContext(ctx)
+	r.Header.Set(idempotency.HeaderKey, "KEY1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, expected %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestMiddlewareCanceled(t *testing.T) {
+	var calls int
+	var cancel context.CancelFunc
+	outcome := ""
+	handler := idempotency.Middleware(contextRecords{inmem.NewIdempotencyRepository()})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch outcome {
+		case "canceled":
+			// the client goes away while the order is being made, the order is made anyway
+			cancel()
+		case "canceled failing":
+			cancel()
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case "panic":
+			panic("handler panics")
+		}
+		fmt.Fprintf(w, `{"call":%d}`, calls)
+	}))
+
+	tt := []struct {
+		Name     string
+		Outcome  string
+		Key      string
+		Status   int
+		Response string
+		Replayed bool
+		Calls    int
+	}{
+		{Name: "Canceled", Outcome: "canceled", Key: "KEY1", Status: http.StatusOK, Response: `{"call":1}`, Calls: 1},
+		{Name: "Retry After Canceled Replayed", Key: "KEY1", Status: http.StatusOK, Response: `{"call":1}`, Replayed: true, Calls: 1},
+		{Name: "Canceled With Server Error", Outcome: "canceled failing", Key: "KEY2", Status: http.StatusInternalServerError, Calls: 2},
+		{Name: "Retry After Canceled With Server Error", Key: "KEY2", Status: http.StatusOK, Response: `{"call":3}`, Calls: 3},
+		{Name: "Panic", Outcome: "panic", Key: "KEY3", Calls: 4},
+		{Name: "Retry After Panic", Key: "KEY3", Status: http.StatusOK, Response: `{"call":5}`, Calls: 5},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			outcome = tc.Outcome
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(customerContext("CUSTOMER1"))
+			defer cancel()
+			r := httptest.NewRequest(http.MethodPost, "/order/make", strings.NewReader(`{"customer_id":"CUSTOMER1"}`)).WithContext(ctx)
+			r.Header.Set(idempotency.HeaderKey, tc.Key)
+			w := httptest.NewRecorder()
+
+			if tc.Outcome == "panic" {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("got no panic, expected the panic of the handler")
+					}
+				}()
+				handler.ServeHTTP(w, r)
+				return
+			}
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tc.Status {
+				t.Fatalf("got status %d, expected %d", w.Code, tc.Status)
+			}
+			if tc.Response != "" && w.Body.String() != tc.Response {
+				t.Errorf("got response %s, expected %s", w.Body.String(), tc.Response)
+			}
+			if replayed := w.Header().Get(idempotency.HeaderReplayed) == "true"; replayed != tc.Replayed {
+				t.Errorf("got replayed %t, expected %t", replayed, tc.Replayed)
+			}
+			if calls != tc.Calls {
+				t.Errorf("got %d calls of the handler, expected %d", calls, tc.Calls)
+			}
+		})
+	}
+}
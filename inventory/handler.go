@@ -0,0 +1,339 @@
+package inventory
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/problem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrInvalidArgument occurs when payload argument is invalid
+	ErrInvalidArgument = transaction.NewError(transaction.KindInvalid, "invalid_argument", "invalid argument")
+)
+
+// MakeHandler create RestAPI handler, every request is authenticated by authenticate
+func MakeHandler(s Service, authenticate auth.Middleware) http.Handler {
+	r := chi.NewRouter()
+	r.Use(authenticate)
+
+	r.Post("/product", func(w http.ResponseWriter, r *http.Request) {
+		payload := struct {
+			Name     string          `json:"name"`
+			Price    decimal.Decimal `json:"price"`
+			Quantity int64           `json:"quantity"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		p, err := s.CreateProduct(r.Context(), payload.Name, payload.Price, payload.Quantity)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(p); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Put("/product/{product_id}", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		payload := struct {
+			Name        string          `json:"name"`
+			Description string          `json:"description"`
+			Price       decimal.Decimal `json:"price"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		p, err := s.EditProduct(r.Context(), productID, payload.Name, payload.Description, payload.Price)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(p); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Post("/product/{product_id}/archive", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		if err := s.ArchiveProduct(r.Context(), productID); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Post("/product/{product_id}/variant", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		payload := struct {
+			SKU   string           `json:"sku"`
+			Name  string           `json:"name"`
+			Price *decimal.Decimal `json:"price"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		p, err := s.AddVariant(r.Context(), productID, payload.SKU, payload.Name, payload.Price)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(p); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Put("/product/{product_id}/variant/{sku}", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		sku := chi.URLParam(r, "sku")
+		payload := struct {
+			Name  string           `json:"name"`
+			Price *decimal.Decimal `json:"price"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		p, err := s.EditVariant(r.Context(), productID, sku, payload.Name, payload.Price)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(p); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Post("/product/{product_id}/stock", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		payload := struct {
+			SKU      string                     `json:"sku"`
+			Quantity int64                      `json:"quantity"`
+			Reason   transaction.MovementReason `json:"reason"`
+			Note     string                     `json:"note"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		m, err := s.AdjustStock(r.Context(), productID, payload.SKU, payload.Quantity, payload.Reason, payload.Note)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(m); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Put("/product/{product_id}/reorder-threshold", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		payload := struct {
+			ReorderThreshold int64 `json:"reorder_threshold"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		p, err := s.SetReorderThreshold(r.Context(), productID, payload.ReorderThreshold)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(p); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Put("/product/{product_id}/backorder", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		payload := struct {
+			Policy      transaction.BackorderPolicy `json:"policy"`
+			AvailableAt *time.Time                  `json:"available_at"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		p, err := s.SetBackorder(r.Context(), productID, payload.Policy, payload.AvailableAt)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(p); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Put("/product/{product_id}/components", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		payload := struct {
+			Components []transaction.Component `json:"components"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		p, err := s.SetComponents(r.Context(), productID, payload.Components)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(p); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Get("/product/{product_id}/movements", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		movements, err := s.ListMovements(r.Context(), productID)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		var response = map[string]interface{}{
+			"movements": movements,
+			"stock":     transaction.Stock(movements),
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Put("/product/{product_id}/taxonomy", func(w http.ResponseWriter, r *http.Request) {
+		productID := chi.URLParam(r, "product_id")
+		payload := struct {
+			CategoryID string   `json:"category_id"`
+			Tags       []string `json:"tags"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		p, err := s.CategorizeProduct(r.Context(), productID, payload.CategoryID, payload.Tags)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(p); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Post("/category", func(w http.ResponseWriter, r *http.Request) {
+		payload := struct {
+			Name     string `json:"name"`
+			ParentID string `json:"parent_id"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		c, err := s.CreateCategory(r.Context(), payload.Name, payload.ParentID)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Put("/category/{category_id}", func(w http.ResponseWriter, r *http.Request) {
+		categoryID := chi.URLParam(r, "category_id")
+		payload := struct {
+			Name     string `json:"name"`
+			ParentID string `json:"parent_id"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			problem.Write(w, ErrInvalidArgument)
+			return
+		}
+
+		c, err := s.EditCategory(r.Context(), categoryID, payload.Name, payload.ParentID)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Delete("/category/{category_id}", func(w http.ResponseWriter, r *http.Request) {
+		categoryID := chi.URLParam(r, "category_id")
+		if err := s.DeleteCategory(r.Context(), categoryID); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	return r
+}
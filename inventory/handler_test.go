@@ -0,0 +1,98 @@
+package inventory_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/inventory"
+	"github.com/muktihari/order-transaction-ddd/persistent/inmem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+func TestHandlerActor(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		coupons  = inmem.NewCouponRepository()
+		orders   = inmem.NewOrderRepository()
+		ledger   = inmem.NewInventoryRepository()
+		admins   = inmem.NewAdminRepository()
+		uow      = inmem.NewUnitOfWork(orders, products, coupons, ledger)
+		s        = inventory.NewService(products, inmem.NewCategoryRepository(), ledger, &stockNotifier{}, uow)
+		tokens   = auth.NewJWTIssuer([]byte("secret"), "admin", time.Hour)
+		handler  = inventory.MakeHandler(s, auth.AuthenticateAdmin(tokens, admins, inmem.NewAPIKeyRepository()))
+		ctx      = context.Background()
+	)
+
+	token := func(role transaction.Role) string {
+		a, err := transaction.NewAdmin("Stock Keeper", string(role)+"@email.com", role, "password")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := admins.Store(ctx, a); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		tk, err := tokens.Issue(a.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		return tk.Token
+	}
+	superadmin := token(transaction.RoleSuperadmin)
+	viewer := token(transaction.RoleViewer)
+
+	tt := []struct {
+		Name      string
+		Token     string
+		Status    int
+		Movements int
+	}{
+		{Name: "Unauthenticated", Status: http.StatusUnauthorized},
+		{Name: "Role Without Permission", Token: viewer, Status: http.StatusForbidden},
+		{Name: "Authenticated", Token: superadmin, Status: http.StatusOK, Movements: 1},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			before, err := ledger.FindByProductID(ctx, "PRODUCT1")
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/product/PRODUCT1/stock", strings.NewReader(`{"quantity": 5, "reason": "restock"}`))
+			// the ledger actor is the authenticated admin, a header naming another one is ignored
+			r.Header.Set("X-Admin-ID", "ADMIN2")
+			if tc.Token != "" {
+				r.Header.Set("Authorization", "Bearer "+tc.Token)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tc.Status {
+				t.Fatalf("got status %d, expected %d: %s", w.Code, tc.Status, w.Body)
+			}
+
+			after, err := ledger.FindByProductID(ctx, "PRODUCT1")
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if len(after)-len(before) != tc.Movements {
+				t.Fatalf("got %d new movements, expected %d", len(after)-len(before), tc.Movements)
+			}
+			if tc.Movements == 0 {
+				return
+			}
+			subject, err := tokens.Parse(tc.Token)
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if actor := after[len(after)-1].Actor; actor != transaction.AdminActor(subject) {
+				t.Errorf("got actor %q, expected %q", actor, transaction.AdminActor(subject))
+			}
+		})
+	}
+}
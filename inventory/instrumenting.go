@@ -0,0 +1,139 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shopspring/decimal"
+)
+
+type instrumentingService struct {
+	request *prometheus.CounterVec
+	latency *prometheus.SummaryVec
+	Service
+}
+
+// NewInstrumentingService create new instrumenting service
+func NewInstrumentingService(
+	request *prometheus.CounterVec,
+	latency *prometheus.SummaryVec,
+	s Service,
+) Service {
+	prometheus.MustRegister(request, latency)
+	return &instrumentingService{request, latency, s}
+}
+
+func (s *instrumentingService) CreateProduct(ctx context.Context, name string, price decimal.Decimal, quantity int64) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("create_product", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("create_product", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.CreateProduct(ctx, name, price, quantity)
+}
+
+func (s *instrumentingService) EditProduct(ctx context.Context, productID, name, description string, price decimal.Decimal) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("edit_product", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("edit_product", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.EditProduct(ctx, productID, name, description, price)
+}
+
+func (s *instrumentingService) ArchiveProduct(ctx context.Context, productID string) (err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("archive_product", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("archive_product", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.ArchiveProduct(ctx, productID)
+}
+
+func (s *instrumentingService) AddVariant(ctx context.Context, productID, sku, name string, price *decimal.Decimal) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("add_variant", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("add_variant", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.AddVariant(ctx, productID, sku, name, price)
+}
+
+func (s *instrumentingService) EditVariant(ctx context.Context, productID, sku, name string, price *decimal.Decimal) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("edit_variant", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("edit_variant", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.EditVariant(ctx, productID, sku, name, price)
+}
+
+func (s *instrumentingService) AdjustStock(ctx context.Context, productID, sku string, quantity int64, reason transaction.MovementReason, note string) (movement *transaction.InventoryMovement, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("adjust_stock", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("adjust_stock", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.AdjustStock(ctx, productID, sku, quantity, reason, note)
+}
+
+func (s *instrumentingService) ListMovements(ctx context.Context, productID string) (movements []transaction.InventoryMovement, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("list_movements", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("list_movements", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.ListMovements(ctx, productID)
+}
+
+func (s *instrumentingService) SetReorderThreshold(ctx context.Context, productID string, threshold int64) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("set_reorder_threshold", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("set_reorder_threshold", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.SetReorderThreshold(ctx, productID, threshold)
+}
+
+func (s *instrumentingService) SetBackorder(ctx context.Context, productID string, policy transaction.BackorderPolicy, availableAt *time.Time) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("set_backorder", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("set_backorder", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.SetBackorder(ctx, productID, policy, availableAt)
+}
+
+func (s *instrumentingService) SetComponents(ctx context.Context, productID string, components []transaction.Component) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("set_components", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("set_components", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.SetComponents(ctx, productID, components)
+}
+
+func (s *instrumentingService) CreateCategory(ctx context.Context, name, parentID string) (category *transaction.Category, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("create_category", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("create_category", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.CreateCategory(ctx, name, parentID)
+}
+
+func (s *instrumentingService) EditCategory(ctx context.Context, categoryID, name, parentID string) (category *transaction.Category, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("edit_category", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("edit_category", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.EditCategory(ctx, categoryID, name, parentID)
+}
+
+func (s *instrumentingService) DeleteCategory(ctx context.Context, categoryID string) (err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("delete_category", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("delete_category", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.DeleteCategory(ctx, categoryID)
+}
+
+func (s *instrumentingService) CategorizeProduct(ctx context.Context, productID, categoryID string, tags []string) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("categorize_product", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("categorize_product", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.CategorizeProduct(ctx, productID, categoryID, tags)
+}
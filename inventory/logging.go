@@ -0,0 +1,239 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+)
+
+type loggingService struct {
+	log *log.Logger
+	Service
+}
+
+// NewLoggingService create new logging service
+func NewLoggingService(log *log.Logger, s Service) Service {
+	return &loggingService{log, s}
+}
+
+func (s *loggingService) CreateProduct(ctx context.Context, name string, price decimal.Decimal, quantity int64) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		var productID string
+		if product != nil {
+			productID = product.ID
+		}
+		s.log.WithFields(log.Fields{
+			"method":     "create_product",
+			"admin_id":   adminID(ctx),
+			"product_id": productID,
+			"name":       name,
+			"price":      price,
+			"quantity":   quantity,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.CreateProduct(ctx, name, price, quantity)
+}
+
+func (s *loggingService) EditProduct(ctx context.Context, productID, name, description string, price decimal.Decimal) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "edit_product",
+			"admin_id":   adminID(ctx),
+			"product_id": productID,
+			"name":       name,
+			"price":      price,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.EditProduct(ctx, productID, name, description, price)
+}
+
+func (s *loggingService) ArchiveProduct(ctx context.Context, productID string) (err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "archive_product",
+			"admin_id":   adminID(ctx),
+			"product_id": productID,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.ArchiveProduct(ctx, productID)
+}
+
+func (s *loggingService) AddVariant(ctx context.Context, productID, sku, name string, price *decimal.Decimal) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "add_variant",
+			"admin_id":   adminID(ctx),
+			"product_id": productID,
+			"sku":        sku,
+			"name":       name,
+			"price":      price,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.AddVariant(ctx, productID, sku, name, price)
+}
+
+func (s *loggingService) EditVariant(ctx context.Context, productID, sku, name string, price *decimal.Decimal) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "edit_variant",
+			"admin_id":   adminID(ctx),
+			"product_id": productID,
+			"sku":        sku,
+			"name":       name,
+			"price":      price,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.EditVariant(ctx, productID, sku, name, price)
+}
+
+func (s *loggingService) AdjustStock(ctx context.Context, productID, sku string, quantity int64, reason transaction.MovementReason, note string) (movement *transaction.InventoryMovement, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "adjust_stock",
+			"admin_id":   adminID(ctx),
+			"product_id": productID,
+			"sku":        sku,
+			"quantity":   quantity,
+			"reason":     reason,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.AdjustStock(ctx, productID, sku, quantity, reason, note)
+}
+
+func (s *loggingService) ListMovements(ctx context.Context, productID string) (movements []transaction.InventoryMovement, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "list_movements",
+			"admin_id":   adminID(ctx),
+			"product_id": productID,
+			"count":      len(movements),
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.ListMovements(ctx, productID)
+}
+
+func (s *loggingService) SetReorderThreshold(ctx context.Context, productID string, threshold int64) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "set_reorder_threshold",
+			"admin_id":   adminID(ctx),
+			"product_id": productID,
+			"threshold":  threshold,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.SetReorderThreshold(ctx, productID, threshold)
+}
+
+func (s *loggingService) SetBackorder(ctx context.Context, productID string, policy transaction.BackorderPolicy, availableAt *time.Time) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":       "set_backorder",
+			"admin_id":     adminID(ctx),
+			"product_id":   productID,
+			"policy":       policy,
+			"available_at": availableAt,
+			"took":         time.Since(begin),
+			"err":          err,
+		}).Println()
+	}(time.Now())
+	return s.Service.SetBackorder(ctx, productID, policy, availableAt)
+}
+
+func (s *loggingService) SetComponents(ctx context.Context, productID string, components []transaction.Component) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":     "set_components",
+			"admin_id":   adminID(ctx),
+			"product_id": productID,
+			"components": components,
+			"took":       time.Since(begin),
+			"err":        err,
+		}).Println()
+	}(time.Now())
+	return s.Service.SetComponents(ctx, productID, components)
+}
+
+func (s *loggingService) CreateCategory(ctx context.Context, name, parentID string) (category *transaction.Category, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":    "create_category",
+			"admin_id":  adminID(ctx),
+			"name":      name,
+			"parent_id": parentID,
+			"took":      time.Since(begin),
+			"err":       err,
+		}).Println()
+	}(time.Now())
+	return s.Service.CreateCategory(ctx, name, parentID)
+}
+
+func (s *loggingService) EditCategory(ctx context.Context, categoryID, name, parentID string) (category *transaction.Category, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":      "edit_category",
+			"admin_id":    adminID(ctx),
+			"category_id": categoryID,
+			"name":        name,
+			"parent_id":   parentID,
+			"took":        time.Since(begin),
+			"err":         err,
+		}).Println()
+	}(time.Now())
+	return s.Service.EditCategory(ctx, categoryID, name, parentID)
+}
+
+func (s *loggingService) DeleteCategory(ctx context.Context, categoryID string) (err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":      "delete_category",
+			"admin_id":    adminID(ctx),
+			"category_id": categoryID,
+			"took":        time.Since(begin),
+			"err":         err,
+		}).Println()
+	}(time.Now())
+	return s.Service.DeleteCategory(ctx, categoryID)
+}
+
+func (s *loggingService) CategorizeProduct(ctx context.Context, productID, categoryID string, tags []string) (product *transaction.Product, err error) {
+	defer func(begin time.Time) {
+		s.log.WithFields(log.Fields{
+			"method":      "categorize_product",
+			"admin_id":    adminID(ctx),
+			"product_id":  productID,
+			"category_id": categoryID,
+			"tags":        tags,
+			"took":        time.Since(begin),
+			"err":         err,
+		}).Println()
+	}(time.Now())
+	return s.Service.CategorizeProduct(ctx, productID, categoryID, tags)
+}
+
+// adminID returns the ID of the admin authenticated in ctx, empty when there is none
+func adminID(ctx context.Context) string {
+	if a, ok := auth.AdminFromContext(ctx); ok {
+		return a.ID
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+)
+
+type retryingService struct {
+	attempts int
+	Service
+}
+
+// NewRetryingService creates new retrying service. Commands failed with transaction.ErrConcurrentModification
+// are re-run up to attempts times, each run loads fresh aggregates from the repositories.
+func NewRetryingService(attempts int, s Service) Service {
+	return &retryingService{attempts, s}
+}
+
+func (s *retryingService) retry(fn func() error) (err error) {
+	for i := 0; i < s.attempts; i++ {
+		err = fn()
+		if !errors.Is(err, transaction.ErrConcurrentModification) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *retryingService) EditProduct(ctx context.Context, productID, name, description string, price decimal.Decimal) (product *transaction.Product, err error) {
+	err = s.retry(func() error {
+		product, err = s.Service.EditProduct(ctx, productID, name, description, price)
+		return err
+	})
+	return product, err
+}
+
+func (s *retryingService) ArchiveProduct(ctx context.Context, productID string) error {
+	return s.retry(func() error {
+		return s.Service.ArchiveProduct(ctx, productID)
+	})
+}
+
+func (s *retryingService) AddVariant(ctx context.Context, productID, sku, name string, price *decimal.Decimal) (product *transaction.Product, err error) {
+	err = s.retry(func() error {
+		product, err = s.Service.AddVariant(ctx, productID, sku, name, price)
+		return err
+	})
+	return product, err
+}
+
+func (s *retryingService) EditVariant(ctx context.Context, productID, sku, name string, price *decimal.Decimal) (product *transaction.Product, err error) {
+	err = s.retry(func() error {
+		product, err = s.Service.EditVariant(ctx, productID, sku, name, price)
+		return err
+	})
+	return product, err
+}
+
+func (s *retryingService) AdjustStock(ctx context.Context, productID, sku string, quantity int64, reason transaction.MovementReason, note string) (movement *transaction.InventoryMovement, err error) {
+	err = s.retry(func() error {
+		movement, err = s.Service.AdjustStock(ctx, productID, sku, quantity, reason, note)
+		return err
+	})
+	return movement, err
+}
+
+func (s *retryingService) SetReorderThreshold(ctx context.Context, productID string, threshold int64) (product *transaction.Product, err error) {
+	err = s.retry(func() error {
+		product, err = s.Service.SetReorderThreshold(ctx, productID, threshold)
+		return err
+	})
+	return product, err
+}
+
+func (s *retryingService) SetBackorder(ctx context.Context, productID string, policy transaction.BackorderPolicy, availableAt *time.Time) (product *transaction.Product, err error) {
+	err = s.retry(func() error {
+		product, err = s.Service.SetBackorder(ctx, productID, policy, availableAt)
+		return err
+	})
+	return product, err
+}
+
+func (s *retryingService) SetComponents(ctx context.Context, productID string, components []transaction.Component) (product *transaction.Product, err error) {
+	err = s.retry(func() error {
+		product, err = s.Service.SetComponents(ctx, productID, components)
+		return err
+	})
+	return product, err
+}
+
+func (s *retryingService) EditCategory(ctx context.Context, categoryID, name, parentID string) (category *transaction.Category, err error) {
+	err = s.retry(func() error {
+		category, err = s.Service.EditCategory(ctx, categoryID, name, parentID)
+		return err
+	})
+	return category, err
+}
+
+func (s *retryingService) CategorizeProduct(ctx context.Context, productID, categoryID string, tags []string) (product *transaction.Product, err error) {
+	err = s.retry(func() error {
+		product, err = s.Service.CategorizeProduct(ctx, productID, categoryID, tags)
+		return err
+	})
+	return product, err
+}
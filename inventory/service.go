@@ -0,0 +1,434 @@
+// Package inventory contains process of managing products and their stock by admin,
+// every stock change is recorded in the inventory ledger.
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+)
+
+// Service is the interface that provides inventory methods. Methods act on behalf of the admin authenticated in the context,
+// ListMovements requires transaction.PermissionViewStock and the others require transaction.PermissionManageInventory.
+// Admins whose role lacks it are rejected with auth.ErrForbidden, so are API keys since stock movements are recorded as made by an admin.
+type Service interface {
+	// CreateProduct creates new product, the initial quantity is recorded as restock
+	CreateProduct(ctx context.Context, name string, price decimal.Decimal, quantity int64) (*transaction.Product, error)
+	// EditProduct changes name, description and price of the product
+	EditProduct(ctx context.Context, productID, name, description string, price decimal.Decimal) (*transaction.Product, error)
+	// ArchiveProduct withdraws the product from sale
+	ArchiveProduct(ctx context.Context, productID string) error
+	// AddVariant adds variant without stock to the product, price overrides the price of the product when it's not nil
+	AddVariant(ctx context.Context, productID, sku, name string, price *decimal.Decimal) (*transaction.Product, error)
+	// EditVariant changes name and price override of the variant
+	EditVariant(ctx context.Context, productID, sku, name string, price *decimal.Decimal) (*transaction.Product, error)
+	// AdjustStock changes the stock of the variant, or of the product itself when sku is empty, by quantity.
+	// Negative quantity takes stock out.
+	AdjustStock(ctx context.Context, productID, sku string, quantity int64, reason transaction.MovementReason, note string) (*transaction.InventoryMovement, error)
+	// ListMovements lists the inventory ledger of the product from the oldest movement
+	ListMovements(ctx context.Context, productID string) ([]transaction.InventoryMovement, error)
+	// SetReorderThreshold changes the stock at or below which the product needs reordering
+	SetReorderThreshold(ctx context.Context, productID string, threshold int64) (*transaction.Product, error)
+	// SetBackorder changes whether the product can be ordered beyond its stock, availableAt is required by pre-order
+	SetBackorder(ctx context.Context, productID string, policy transaction.BackorderPolicy, availableAt *time.Time) (*transaction.Product, error)
+	// SetComponents turns the product into a bundle of the components or replaces them, components are validated
+	// against their products. The returned bundle has the quantity its components make up.
+	SetComponents(ctx context.Context, productID string, components []transaction.Component) (*transaction.Product, error)
+	// CreateCategory creates new category under parent, empty parentID creates a root category
+	CreateCategory(ctx context.Context, name, parentID string) (*transaction.Category, error)
+	// EditCategory renames the category and moves it under parent
+	EditCategory(ctx context.Context, categoryID, name, parentID string) (*transaction.Category, error)
+	// DeleteCategory deletes the category, only categories without subcategories and products can be deleted
+	DeleteCategory(ctx context.Context, categoryID string) error
+	// CategorizeProduct puts the product into the category and replaces its tags, empty categoryID uncategorizes the product
+	CategorizeProduct(ctx context.Context, productID, categoryID string, tags []string) (*transaction.Product, error)
+}
+
+type service struct {
+	products   transaction.ProductRepository
+	categories transaction.CategoryRepository
+	inventory  transaction.InventoryRepository
+	notifier   transaction.StockNotifier
+	uow        transaction.UnitOfWork
+}
+
+// NewService creates an inventory service with necessary dependencies
+func NewService(
+	products transaction.ProductRepository,
+	categories transaction.CategoryRepository,
+	inventory transaction.InventoryRepository,
+	notifier transaction.StockNotifier,
+	uow transaction.UnitOfWork,
+) Service {
+	return &service{
+		products:   products,
+		categories: categories,
+		inventory:  inventory,
+		notifier:   notifier,
+		uow:        uow,
+	}
+}
+
+func (s *service) CreateProduct(ctx context.Context, name string, price decimal.Decimal, quantity int64) (*transaction.Product, error) {
+	a, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory)
+	if err != nil {
+		return nil, err
+	}
+
+	if quantity < 0 {
+		return nil, transaction.ErrInvalidMovement
+	}
+
+	p, err := transaction.NewProduct(name, price)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Quantity = quantity
+
+	err = s.uow.Do(ctx, func(ctx context.Context, r transaction.Repositories) error {
+		if err := r.Products().Store(ctx, p); err != nil {
+			return err
+		}
+		if quantity == 0 {
+			return nil
+		}
+
+		m := transaction.NewInventoryMovement(p.ID, "", quantity, transaction.MovementReasonRestock, transaction.AdminActor(a.ID))
+		return r.Inventory().Store(ctx, m)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *service) EditProduct(ctx context.Context, productID, name, description string, price decimal.Decimal) (*transaction.Product, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return nil, err
+	}
+
+	p, err := s.products.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Edit(name, price); err != nil {
+		return nil, err
+	}
+	if err := p.Describe(description); err != nil {
+		return nil, err
+	}
+
+	if err := s.products.Update(ctx, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *service) ArchiveProduct(ctx context.Context, productID string) error {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return err
+	}
+
+	p, err := s.products.FindByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.Archive(); err != nil {
+		return err
+	}
+
+	return s.products.Update(ctx, p)
+}
+
+func (s *service) AddVariant(ctx context.Context, productID, sku, name string, price *decimal.Decimal) (*transaction.Product, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return nil, err
+	}
+
+	p, err := s.products.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.AddVariant(sku, name, price); err != nil {
+		return nil, err
+	}
+
+	if err := s.products.Update(ctx, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *service) EditVariant(ctx context.Context, productID, sku, name string, price *decimal.Decimal) (*transaction.Product, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return nil, err
+	}
+
+	p, err := s.products.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.EditVariant(sku, name, price); err != nil {
+		return nil, err
+	}
+
+	if err := s.products.Update(ctx, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *service) AdjustStock(ctx context.Context, productID, sku string, quantity int64, reason transaction.MovementReason, note string) (*transaction.InventoryMovement, error) {
+	a, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reason.Manual() || !reason.Allows(quantity) {
+		return nil, transaction.ErrInvalidMovement
+	}
+
+	m := transaction.NewInventoryMovement(productID, sku, quantity, reason, transaction.AdminActor(a.ID))
+	m.Note = note
+
+	var alert transaction.StockAlert
+	var alerted bool
+	err = s.uow.Do(ctx, func(ctx context.Context, r transaction.Repositories) error {
+		p, err := r.Products().FindByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+		previous := p.Quantity
+		if err := p.AdjustQuantity(sku, quantity); err != nil {
+			return err
+		}
+		if err := r.Inventory().Store(ctx, m); err != nil {
+			return err
+		}
+		// replenished stock goes to the orders waiting for it
+		if quantity > 0 {
+			if err := transaction.AllocateBackorders(ctx, r, p); err != nil {
+				return err
+			}
+		}
+		if err := r.Products().Update(ctx, p); err != nil {
+			return err
+		}
+		alert, alerted = p.StockAlert(previous)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if alerted {
+		s.notifier.NotifyStockAlert(ctx, alert)
+	}
+	return m, nil
+}
+
+func (s *service) ListMovements(ctx context.Context, productID string) ([]transaction.InventoryMovement, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionViewStock); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.products.FindByID(ctx, productID); err != nil {
+		return nil, err
+	}
+	return s.inventory.FindByProductID(ctx, productID)
+}
+
+func (s *service) SetReorderThreshold(ctx context.Context, productID string, threshold int64) (*transaction.Product, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return nil, err
+	}
+
+	p, err := s.products.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.SetReorderThreshold(threshold); err != nil {
+		return nil, err
+	}
+
+	if err := s.products.Update(ctx, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *service) SetBackorder(ctx context.Context, productID string, policy transaction.BackorderPolicy, availableAt *time.Time) (*transaction.Product, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return nil, err
+	}
+
+	p, err := s.products.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.SetBackorder(policy, availableAt); err != nil {
+		return nil, err
+	}
+
+	if err := s.products.Update(ctx, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (s *service) SetComponents(ctx context.Context, productID string, components []transaction.Component) (*transaction.Product, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return nil, err
+	}
+
+	p, err := s.products.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	validated := make([]transaction.Component, 0, len(components))
+	for _, c := range components {
+		cp, err := s.products.FindByID(ctx, c.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		component, err := transaction.NewComponent(cp, c.SKU, c.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		validated = append(validated, component)
+	}
+
+	if err := p.SetComponents(validated); err != nil {
+		return nil, err
+	}
+
+	if err := s.products.Update(ctx, p); err != nil {
+		return nil, err
+	}
+
+	if err := transaction.ResolveBundle(ctx, s.products, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *service) CreateCategory(ctx context.Context, name, parentID string) (*transaction.Category, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categories.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := transaction.NewCategory(name, parentID, categories)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.categories.Store(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *service) EditCategory(ctx context.Context, categoryID, name, parentID string) (*transaction.Category, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return nil, err
+	}
+
+	c, err := s.categories.FindByID(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categories.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Edit(name, parentID, categories); err != nil {
+		return nil, err
+	}
+
+	if err := s.categories.Update(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *service) DeleteCategory(ctx context.Context, categoryID string) error {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return err
+	}
+
+	categories, err := s.categories.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	if !categories.Has(categoryID) {
+		return transaction.ErrCategoryNotFound
+	}
+	if len(categories.Children(categoryID)) != 0 {
+		return transaction.ErrCategoryInUse
+	}
+
+	products, err := s.products.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range products {
+		if p.CategoryID == categoryID {
+			return transaction.ErrCategoryInUse
+		}
+	}
+
+	return s.categories.Delete(ctx, categoryID)
+}
+
+func (s *service) CategorizeProduct(ctx context.Context, productID, categoryID string, tags []string) (*transaction.Product, error) {
+	if _, err := auth.AuthorizeAdmin(ctx, transaction.PermissionManageInventory); err != nil {
+		return nil, err
+	}
+
+	if categoryID != "" {
+		if _, err := s.categories.FindByID(ctx, categoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := s.products.FindByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Categorize(categoryID, tags); err != nil {
+		return nil, err
+	}
+
+	if err := s.products.Update(ctx, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,583 @@
+package inventory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/inventory"
+	"github.com/muktihari/order-transaction-ddd/ordering"
+	"github.com/muktihari/order-transaction-ddd/persistent/inmem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+)
+
+// stockNotifier records the stock alerts it's notified of
+type stockNotifier struct {
+	alerts []transaction.StockAlert
+}
+
+func (n *stockNotifier) NotifyStockAlert(ctx context.Context, alert transaction.StockAlert) {
+	n.alerts = append(n.alerts, alert)
+}
+
+// adminContext returns context authenticated as admin ADMIN1 having the role
+func adminContext(role transaction.Role) context.Context {
+	return auth.WithAdmin(context.Background(), &transaction.Admin{ID: "ADMIN1", Role: role})
+}
+
+// checkLedger checks the stock of the product can be reconstructed from its movements
+func checkLedger(t *testing.T, s inventory.Service, products transaction.ProductRepository, productID string) []transaction.InventoryMovement {
+	t.Helper()
+	ctx := adminContext(transaction.RoleViewer)
+	p, err := products.FindByID(ctx, productID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	movements, err := s.ListMovements(ctx, productID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if stock := transaction.Stock(movements); stock != p.Quantity {
+		t.Errorf("got ledger stock %d, expected %d", stock, p.Quantity)
+	}
+	return movements
+}
+
+func TestCreateProduct(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		coupons  = inmem.NewCouponRepository()
+		orders   = inmem.NewOrderRepository()
+		ledger   = inmem.NewInventoryRepository()
+		uow      = inmem.NewUnitOfWork(orders, products, coupons, ledger)
+		s        = inventory.NewService(products, inmem.NewCategoryRepository(), ledger, &stockNotifier{}, uow)
+		ctx      = adminContext(transaction.RoleFulfillment)
+		price    = decimal.NewFromInt(15)
+		negative = decimal.NewFromInt(-1)
+	)
+
+	tt := []struct {
+		Name      string
+		PName     string
+		Price     decimal.Decimal
+		Quantity  int64
+		Movements int
+		Err       error
+	}{
+		{Name: "With Stock", PName: "Indomie Goreng", Price: price, Quantity: 40, Movements: 1},
+		{Name: "Without Stock", PName: "Indomie Soto", Price: price, Quantity: 0, Movements: 0},
+		{Name: "No Name", PName: "", Price: price, Quantity: 40, Err: transaction.ErrInvalidProduct},
+		{Name: "Negative Price", PName: "Indomie Goreng", Price: negative, Quantity: 40, Err: transaction.ErrInvalidProduct},
+		{Name: "Negative Quantity", PName: "Indomie Goreng", Price: price, Quantity: -1, Err: transaction.ErrInvalidMovement},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			p, err := s.CreateProduct(ctx, tc.PName, tc.Price, tc.Quantity)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if err != nil {
+				if p != nil {
+					t.Errorf("got product %+v, expected nil", p)
+				}
+				return
+			}
+			if p.ID == "" || p.Name != tc.PName || p.Quantity != tc.Quantity {
+				t.Errorf("got product %+v", p)
+			}
+
+			movements := checkLedger(t, s, products, p.ID)
+			if len(movements) != tc.Movements {
+				t.Fatalf("got %d movements, expected %d", len(movements), tc.Movements)
+			}
+			for _, m := range movements {
+				if m.Reason != transaction.MovementReasonRestock || m.Actor != transaction.AdminActor("ADMIN1") {
+					t.Errorf("got movement %+v, expected restock by ADMIN1", m)
+				}
+			}
+		})
+	}
+}
+
+func TestAdjustStock(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		coupons  = inmem.NewCouponRepository()
+		orders   = inmem.NewOrderRepository()
+		ledger   = inmem.NewInventoryRepository()
+		uow      = inmem.NewUnitOfWork(orders, products, coupons, ledger)
+		s        = inventory.NewService(products, inmem.NewCategoryRepository(), ledger, &stockNotifier{}, uow)
+		ctx      = adminContext(transaction.RoleFulfillment)
+	)
+
+	tt := []struct {
+		Name      string
+		ProductID string
+		Quantity  int64
+		Reason    transaction.MovementReason
+		Expected  int64
+		Err       error
+	}{
+		{Name: "Restock", ProductID: "PRODUCT1", Quantity: 50, Reason: transaction.MovementReasonRestock, Expected: 250},
+		{Name: "Damage", ProductID: "PRODUCT1", Quantity: -10, Reason: transaction.MovementReasonDamage, Expected: 240},
+		{Name: "Correction", ProductID: "PRODUCT1", Quantity: -5, Reason: transaction.MovementReasonCorrection, Expected: 235},
+		{Name: "Restock Taking Out", ProductID: "PRODUCT1", Quantity: -5, Reason: transaction.MovementReasonRestock, Expected: 235, Err: transaction.ErrInvalidMovement},
+		{Name: "Damage Adding", ProductID: "PRODUCT1", Quantity: 5, Reason: transaction.MovementReasonDamage, Expected: 235, Err: transaction.ErrInvalidMovement},
+		{Name: "Zero Correction", ProductID: "PRODUCT1", Quantity: 0, Reason: transaction.MovementReasonCorrection, Expected: 235, Err: transaction.ErrInvalidMovement},
+		{Name: "System Reason", ProductID: "PRODUCT1", Quantity: -5, Reason: transaction.MovementReasonOrderReservation, Expected: 235, Err: transaction.ErrInvalidMovement},
+		{Name: "Exceed Stock", ProductID: "PRODUCT1", Quantity: -236, Reason: transaction.MovementReasonDamage, Expected: 235, Err: transaction.ErrQuantityExceedProductStock},
+		{Name: "Unknown Product", ProductID: "UNKNOWN", Quantity: 5, Reason: transaction.MovementReasonRestock, Err: transaction.ErrProductNotFound},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			m, err := s.AdjustStock(ctx, tc.ProductID, "", tc.Quantity, tc.Reason, "stock take")
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if err == nil && (m.ID == "" || m.Actor != transaction.AdminActor("ADMIN1") || m.Note != "stock take") {
+				t.Errorf("got movement %+v", m)
+			}
+			if tc.ProductID == "UNKNOWN" {
+				return
+			}
+
+			p, err := products.FindByID(ctx, tc.ProductID)
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if p.Quantity != tc.Expected {
+				t.Errorf("got quantity %d, expected %d", p.Quantity, tc.Expected)
+			}
+			checkLedger(t, s, products, tc.ProductID)
+		})
+	}
+}
+
+func TestEditAndArchiveProduct(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		coupons  = inmem.NewCouponRepository()
+		orders   = inmem.NewOrderRepository()
+		ledger   = inmem.NewInventoryRepository()
+		uow      = inmem.NewUnitOfWork(orders, products, coupons, ledger)
+		s        = inventory.NewService(products, inmem.NewCategoryRepository(), ledger, &stockNotifier{}, uow)
+		ctx      = adminContext(transaction.RoleFulfillment)
+	)
+
+	p, err := s.EditProduct(ctx, "PRODUCT1", "Sony Xperia 10 II", "Second generation", decimal.NewFromInt(550))
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if p.Name != "Sony Xperia 10 II" || p.Description != "Second generation" || !p.Price.Equal(decimal.NewFromInt(550)) || p.Quantity != 200 {
+		t.Errorf("got product %+v", p)
+	}
+	if _, err := s.EditProduct(ctx, "PRODUCT1", "", "", decimal.NewFromInt(550)); !errors.Is(err, transaction.ErrInvalidProduct) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrInvalidProduct)
+	}
+
+	if err := s.ArchiveProduct(ctx, "PRODUCT1"); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	found, err := products.FindByID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if !found.Archived || found.Quantity != 200 {
+		t.Errorf("got product %+v, expected archived with its stock", found)
+	}
+	if err := found.TryReserveQuantity("", 1); !errors.Is(err, transaction.ErrProductArchived) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrProductArchived)
+	}
+
+	if err := s.ArchiveProduct(ctx, "PRODUCT1"); !errors.Is(err, transaction.ErrProductArchived) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrProductArchived)
+	}
+	if _, err := s.EditProduct(ctx, "PRODUCT1", "Sony Xperia 10 III", "", decimal.NewFromInt(600)); !errors.Is(err, transaction.ErrProductArchived) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrProductArchived)
+	}
+	if err := s.ArchiveProduct(ctx, "UNKNOWN"); !errors.Is(err, transaction.ErrProductNotFound) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrProductNotFound)
+	}
+	checkLedger(t, s, products, "PRODUCT1")
+}
+
+func TestVariantStock(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		coupons  = inmem.NewCouponRepository()
+		orders   = inmem.NewOrderRepository()
+		ledger   = inmem.NewInventoryRepository()
+		uow      = inmem.NewUnitOfWork(orders, products, coupons, ledger)
+		s        = inventory.NewService(products, inmem.NewCategoryRepository(), ledger, &stockNotifier{}, uow)
+		ctx      = adminContext(transaction.RoleFulfillment)
+		xl       = decimal.NewFromFloat(17.5)
+	)
+
+	p, err := s.CreateProduct(ctx, "Uniqlo Airism T-Shirt", decimal.NewFromInt(15), 0)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := s.AddVariant(ctx, p.ID, "AIRISM-M", "M", nil); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := s.AddVariant(ctx, p.ID, "AIRISM-XL", "XL", &xl); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := s.AddVariant(ctx, p.ID, "AIRISM-XL", "XXL", nil); !errors.Is(err, transaction.ErrInvalidVariant) {
+		t.Errorf("duplicate sku: got %v, expected %v", err, transaction.ErrInvalidVariant)
+	}
+	if _, err := s.AddVariant(ctx, "PRODUCT1", "S20-BLACK", "Black", nil); !errors.Is(err, transaction.ErrInvalidVariant) {
+		t.Errorf("product having stock: got %v, expected %v", err, transaction.ErrInvalidVariant)
+	}
+
+	tt := []struct {
+		Name     string
+		SKU      string
+		Quantity int64
+		Reason   transaction.MovementReason
+		Err      error
+	}{
+		{Name: "Restock", SKU: "AIRISM-M", Quantity: 20, Reason: transaction.MovementReasonRestock},
+		{Name: "Restock Other Variant", SKU: "AIRISM-XL", Quantity: 10, Reason: transaction.MovementReasonRestock},
+		{Name: "Damage", SKU: "AIRISM-XL", Quantity: -3, Reason: transaction.MovementReasonDamage},
+		{Name: "Exceed Variant Stock", SKU: "AIRISM-XL", Quantity: -8, Reason: transaction.MovementReasonDamage, Err: transaction.ErrQuantityExceedProductStock},
+		{Name: "Without Variant", SKU: "", Quantity: 5, Reason: transaction.MovementReasonRestock, Err: transaction.ErrVariantRequired},
+		{Name: "Unknown Variant", SKU: "AIRISM-S", Quantity: 5, Reason: transaction.MovementReasonRestock, Err: transaction.ErrVariantNotFound},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			m, err := s.AdjustStock(ctx, p.ID, tc.SKU, tc.Quantity, tc.Reason, "")
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if err == nil && m.SKU != tc.SKU {
+				t.Errorf("got movement of %q, expected %q", m.SKU, tc.SKU)
+			}
+		})
+	}
+
+	found, err := products.FindByID(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	movements := checkLedger(t, s, products, p.ID)
+	for sku, expected := range map[string]int64{"AIRISM-M": 20, "AIRISM-XL": 7} {
+		v, err := found.Variant(sku)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if v.Quantity != expected || transaction.StockOf(movements, sku) != expected {
+			t.Errorf("%s: got quantity %d and ledger stock %d, expected %d", sku, v.Quantity, transaction.StockOf(movements, sku), expected)
+		}
+	}
+	if price, _ := found.PriceOf("AIRISM-XL"); !price.Equal(xl) {
+		t.Errorf("got price %s, expected %s", price, xl)
+	}
+}
+
+func TestManageCategories(t *testing.T) {
+	var (
+		products   = inmem.NewProductRepository()
+		categories = inmem.NewCategoryRepository()
+		coupons    = inmem.NewCouponRepository()
+		orders     = inmem.NewOrderRepository()
+		ledger     = inmem.NewInventoryRepository()
+		uow        = inmem.NewUnitOfWork(orders, products, coupons, ledger)
+		s          = inventory.NewService(products, categories, ledger, &stockNotifier{}, uow)
+		ctx        = adminContext(transaction.RoleFulfillment)
+	)
+
+	c, err := s.CreateCategory(ctx, "Tablets", "CATEGORY1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := s.CreateCategory(ctx, "Tablets", "UNKNOWN"); !errors.Is(err, transaction.ErrInvalidCategory) {
+		t.Errorf("unknown parent: got %v, expected %v", err, transaction.ErrInvalidCategory)
+	}
+	if _, err := s.EditCategory(ctx, "CATEGORY1", "Electronics", c.ID); !errors.Is(err, transaction.ErrInvalidCategory) {
+		t.Errorf("move into own subtree: got %v, expected %v", err, transaction.ErrInvalidCategory)
+	}
+	if _, err := s.EditCategory(ctx, c.ID, "Tablets & eReaders", ""); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	p, err := s.CategorizeProduct(ctx, "PRODUCT1", c.ID, []string{"Android", " android", "tablet"})
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if diff := cmp.Diff([]string{"android", "tablet"}, p.Tags); diff != "" {
+		t.Errorf("(-expected +got): %s", diff)
+	}
+	if _, err := s.CategorizeProduct(ctx, "PRODUCT1", "UNKNOWN", nil); !errors.Is(err, transaction.ErrCategoryNotFound) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrCategoryNotFound)
+	}
+
+	tt := []struct {
+		Name       string
+		CategoryID string
+		Err        error
+	}{
+		{Name: "Having Subcategories", CategoryID: "CATEGORY1", Err: transaction.ErrCategoryInUse},
+		{Name: "Having Products", CategoryID: c.ID, Err: transaction.ErrCategoryInUse},
+		{Name: "Unused", CategoryID: "CATEGORY3"},
+		{Name: "Unknown", CategoryID: "UNKNOWN", Err: transaction.ErrCategoryNotFound},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := s.DeleteCategory(ctx, tc.CategoryID); !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+		})
+	}
+
+	if _, err := categories.FindByID(ctx, "CATEGORY3"); !errors.Is(err, transaction.ErrCategoryNotFound) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrCategoryNotFound)
+	}
+}
+
+func TestReorderThreshold(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		coupons  = inmem.NewCouponRepository()
+		orders   = inmem.NewOrderRepository()
+		ledger   = inmem.NewInventoryRepository()
+		uow      = inmem.NewUnitOfWork(orders, products, coupons, ledger)
+		notifier = &stockNotifier{}
+		s        = inventory.NewService(products, inmem.NewCategoryRepository(), ledger, notifier, uow)
+		ctx      = adminContext(transaction.RoleFulfillment)
+	)
+
+	if _, err := s.SetReorderThreshold(ctx, "PRODUCT1", -1); !errors.Is(err, transaction.ErrInvalidProduct) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrInvalidProduct)
+	}
+	p, err := s.SetReorderThreshold(ctx, "PRODUCT1", 50)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if p.ReorderThreshold != 50 || p.LowStock() {
+		t.Errorf("got product %+v, expected threshold of 50 above stock", p)
+	}
+
+	if _, err := s.AdjustStock(ctx, "PRODUCT1", "", -160, transaction.MovementReasonDamage, ""); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := s.AdjustStock(ctx, "PRODUCT1", "", 5, transaction.MovementReasonRestock, ""); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := s.AdjustStock(ctx, "PRODUCT1", "", -45, transaction.MovementReasonCorrection, ""); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	expected := []transaction.StockAlert{
+		{ProductID: "PRODUCT1", Name: "Sony Xperia 10", Kind: transaction.StockAlertLow, Quantity: 40, ReorderThreshold: 50},
+		{ProductID: "PRODUCT1", Name: "Sony Xperia 10", Kind: transaction.StockAlertOut, Quantity: 0, ReorderThreshold: 50},
+	}
+	if diff := cmp.Diff(expected, notifier.alerts, cmpopts.IgnoreFields(transaction.StockAlert{}, "CreatedAt")); diff != "" {
+		t.Errorf("(-expected +got): %s", diff)
+	}
+}
+
+func TestBackorder(t *testing.T) {
+	var (
+		products  = inmem.NewProductRepository()
+		customers = inmem.NewCustomerRepository()
+		coupons   = inmem.NewCouponRepository()
+		orders    = inmem.NewOrderRepository()
+		ledger    = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, ledger)
+		s         = inventory.NewService(products, inmem.NewCategoryRepository(), ledger, &stockNotifier{}, uow)
+		o         = ordering.NewService(orders, customers, products, coupons, nil, &stockNotifier{}, uow)
+		ctx       = adminContext(transaction.RoleFulfillment)
+	)
+
+	if _, err := s.SetBackorder(ctx, "PRODUCT1", "sometimes", nil); !errors.Is(err, transaction.ErrInvalidProduct) {
+		t.Errorf("unknown policy: got %v, expected %v", err, transaction.ErrInvalidProduct)
+	}
+	if _, err := s.SetBackorder(ctx, "PRODUCT1", transaction.BackorderPreOrder, nil); !errors.Is(err, transaction.ErrInvalidProduct) {
+		t.Errorf("pre-order without date: got %v, expected %v", err, transaction.ErrInvalidProduct)
+	}
+	if _, err := s.SetBackorder(ctx, "PRODUCT1", transaction.BackorderAllowed, nil); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := s.AdjustStock(ctx, "PRODUCT1", "", -195, transaction.MovementReasonDamage, ""); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	submit := func(quantity int64) string {
+		t.Helper()
+		ctx := auth.WithCustomer(context.Background(), &transaction.Customer{ID: "CUSTOMER1"})
+		order, err := o.MakeOrder(ctx, "CUSTOMER1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := o.AddProduct(ctx, order.ID, "PRODUCT1", "", quantity); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := o.SubmitOrder(ctx, order.ID); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		return order.ID
+	}
+	backordered := func(orderID string) int64 {
+		t.Helper()
+		order, err := orders.FindByID(ctx, orderID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		return order.Cart[0].Backordered
+	}
+
+	first := submit(8)
+	// orders placed within the same millisecond have no defined order
+	time.Sleep(2 * time.Millisecond)
+	second := submit(4)
+	if got := []int64{backordered(first), backordered(second)}; !cmp.Equal(got, []int64{3, 4}) {
+		t.Errorf("got backordered %v, expected [3 4]", got)
+	}
+	checkLedger(t, s, products, "PRODUCT1")
+
+	// the first order placed is allocated first
+	if _, err := s.AdjustStock(ctx, "PRODUCT1", "", 5, transaction.MovementReasonRestock, ""); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if got := []int64{backordered(first), backordered(second)}; !cmp.Equal(got, []int64{0, 2}) {
+		t.Errorf("got backordered %v, expected [0 2]", got)
+	}
+	p, err := products.FindByID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if p.Quantity != 0 {
+		t.Errorf("got quantity %d, expected 0", p.Quantity)
+	}
+	checkLedger(t, s, products, "PRODUCT1")
+
+	order, err := orders.FindByID(ctx, first)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if !order.Allocated() {
+		t.Errorf("got order %s not allocated, expected allocated", first)
+	}
+	order, err = orders.FindByID(ctx, second)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := order.ChangeStatusTo(transaction.OrderStatusShipped); !errors.Is(err, transaction.ErrOrderNotAllocated) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrOrderNotAllocated)
+	}
+}
+
+func TestSetComponents(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		coupons  = inmem.NewCouponRepository()
+		orders   = inmem.NewOrderRepository()
+		ledger   = inmem.NewInventoryRepository()
+		uow      = inmem.NewUnitOfWork(orders, products, coupons, ledger)
+		s        = inventory.NewService(products, inmem.NewCategoryRepository(), ledger, &stockNotifier{}, uow)
+		ctx      = adminContext(transaction.RoleFulfillment)
+	)
+
+	bundle, err := s.CreateProduct(ctx, "Sony Xperia 10 Starter Kit", decimal.NewFromInt(450), 0)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	stocked, err := s.CreateProduct(ctx, "Sony Xperia 10 Case", decimal.NewFromInt(20), 5)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	tt := []struct {
+		Name       string
+		ProductID  string
+		Components []transaction.Component
+		Err        error
+	}{
+		{Name: "No Components", ProductID: bundle.ID, Err: transaction.ErrInvalidBundle},
+		{Name: "Unknown Component", ProductID: bundle.ID, Components: []transaction.Component{{ProductID: "UNKNOWN", Quantity: 1}}, Err: transaction.ErrProductNotFound},
+		{Name: "Zero Quantity", ProductID: bundle.ID, Components: []transaction.Component{{ProductID: "PRODUCT1", Quantity: 0}}, Err: transaction.ErrInvalidBundle},
+		{Name: "Itself", ProductID: bundle.ID, Components: []transaction.Component{{ProductID: bundle.ID, Quantity: 1}}, Err: transaction.ErrInvalidBundle},
+		{Name: "Duplicate", ProductID: bundle.ID, Components: []transaction.Component{{ProductID: "PRODUCT1", Quantity: 1}, {ProductID: "PRODUCT1", Quantity: 2}}, Err: transaction.ErrInvalidBundle},
+		{Name: "Bundle Having Stock", ProductID: stocked.ID, Components: []transaction.Component{{ProductID: "PRODUCT1", Quantity: 1}}, Err: transaction.ErrInvalidBundle},
+		{Name: "Valid", ProductID: bundle.ID, Components: []transaction.Component{{ProductID: "PRODUCT1", Quantity: 1}, {ProductID: "PRODUCT2", Quantity: 20}}},
+		{Name: "Bundle As Component", ProductID: stocked.ID, Components: []transaction.Component{{ProductID: bundle.ID, Quantity: 1}}, Err: transaction.ErrInvalidBundle},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			p, err := s.SetComponents(ctx, tc.ProductID, tc.Components)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("got %v, expected %v", err, tc.Err)
+			}
+			if err != nil {
+				return
+			}
+			// PRODUCT2 makes up 100 bundles, less than PRODUCT1
+			if !p.IsBundle() || p.Quantity != 100 {
+				t.Errorf("got product %+v, expected bundle of quantity 100", p)
+			}
+		})
+	}
+
+	if _, err := s.AdjustStock(ctx, bundle.ID, "", 5, transaction.MovementReasonRestock, ""); !errors.Is(err, transaction.ErrInvalidBundle) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrInvalidBundle)
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	var (
+		products = inmem.NewProductRepository()
+		coupons  = inmem.NewCouponRepository()
+		orders   = inmem.NewOrderRepository()
+		ledger   = inmem.NewInventoryRepository()
+		uow      = inmem.NewUnitOfWork(orders, products, coupons, ledger)
+		s        = inventory.NewService(products, inmem.NewCategoryRepository(), ledger, &stockNotifier{}, uow)
+		apiKey   = auth.WithAPIKey(context.Background(), &transaction.APIKey{ID: "KEY1", Scopes: []transaction.Permission{transaction.PermissionManageInventory, transaction.PermissionViewStock}})
+	)
+
+	tt := []struct {
+		Name      string
+		Ctx       context.Context
+		AdjustErr error
+		ListErr   error
+	}{
+		{Name: "Fulfillment", Ctx: adminContext(transaction.RoleFulfillment)},
+		{Name: "Viewer", Ctx: adminContext(transaction.RoleViewer), AdjustErr: auth.ErrForbidden},
+		{Name: "Finance", Ctx: adminContext(transaction.RoleFinance), AdjustErr: auth.ErrForbidden},
+		{Name: "API Key", Ctx: apiKey, AdjustErr: auth.ErrForbidden, ListErr: auth.ErrForbidden},
+		{Name: "Customer", Ctx: auth.WithCustomer(context.Background(), &transaction.Customer{ID: "CUSTOMER1"}), AdjustErr: auth.ErrUnauthenticated, ListErr: auth.ErrUnauthenticated},
+		{Name: "Unauthenticated", Ctx: context.Background(), AdjustErr: auth.ErrUnauthenticated, ListErr: auth.ErrUnauthenticated},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			before, err := ledger.FindByProductID(context.Background(), "PRODUCT1")
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if _, err := s.AdjustStock(tc.Ctx, "PRODUCT1", "", 5, transaction.MovementReasonRestock, ""); !errors.Is(err, tc.AdjustErr) {
+				t.Fatalf("AdjustStock: got %v, expected %v", err, tc.AdjustErr)
+			}
+			after, err := ledger.FindByProductID(context.Background(), "PRODUCT1")
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if tc.AdjustErr != nil && len(after) != len(before) {
+				t.Errorf("got %d new movements, expected none", len(after)-len(before))
+			}
+			if _, err := s.ListMovements(tc.Ctx, "PRODUCT1"); !errors.Is(err, tc.ListErr) {
+				t.Errorf("ListMovements: got %v, expected %v", err, tc.ListErr)
+			}
+		})
+	}
+}
@@ -2,21 +2,27 @@ package ordering
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/problem"
 	"github.com/muktihari/order-transaction-ddd/transaction"
 )
 
 var (
 	// ErrInvalidArgument occurs when payload argument is invalid
-	ErrInvalidArgument = errors.New("invalid argument")
+	ErrInvalidArgument = transaction.NewError(transaction.KindInvalid, "invalid_argument", "invalid argument")
 )
 
-// MakeHandler create RestAPI handler
-func MakeHandler(s Service) http.Handler {
+// MakeHandler create RestAPI handler, every request is authenticated by authenticate,
+// then POST and PUT requests carrying an idempotency key are made idempotent by idempotent
+func MakeHandler(s Service, authenticate, idempotent auth.Middleware) http.Handler {
 	r := chi.NewRouter()
+	r.Use(authenticate)
+	r.Use(idempotent)
 
 	r.Post("/order/make", func(w http.ResponseWriter, r *http.Request) {
 		payload := struct {
@@ -24,19 +30,19 @@ func MakeHandler(s Service) http.Handler {
 		}{}
 
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-			encodeError(err, w)
+			problem.Write(w, ErrInvalidArgument)
 			return
 		}
 
 		o, err := s.MakeOrder(r.Context(), payload.CustomerID)
 		if err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		if err := json.NewEncoder(w).Encode(o); err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 	})
@@ -45,16 +51,17 @@ func MakeHandler(s Service) http.Handler {
 		orderID := chi.URLParam(r, "order_id")
 		payload := struct {
 			ProductID string `json:"product_id"`
+			SKU       string `json:"sku"`
 			Quantity  int64  `json:"quantity"`
 		}{}
 
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-			encodeError(err, w)
+			problem.Write(w, ErrInvalidArgument)
 			return
 		}
 
-		if err := s.AddProduct(r.Context(), orderID, payload.ProductID, payload.Quantity); err != nil {
-			encodeError(err, w)
+		if err := s.AddProduct(r.Context(), orderID, payload.ProductID, payload.SKU, payload.Quantity); err != nil {
+			problem.Write(w, err)
 			return
 		}
 	})
@@ -66,12 +73,12 @@ func MakeHandler(s Service) http.Handler {
 		}{}
 
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-			encodeError(err, w)
+			problem.Write(w, ErrInvalidArgument)
 			return
 		}
 
 		if err := s.ApplyCoupon(r.Context(), orderID, payload.CouponCode); err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 	})
@@ -80,7 +87,7 @@ func MakeHandler(s Service) http.Handler {
 		orderID := chi.URLParam(r, "order_id")
 
 		if err := s.SubmitOrder(r.Context(), orderID); err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 	})
@@ -89,12 +96,70 @@ func MakeHandler(s Service) http.Handler {
 		orderID := chi.URLParam(r, "order_id")
 		var ps transaction.PaymentSpecification
 		if err := json.NewDecoder(r.Body).Decode(&ps); err != nil {
-			encodeError(err, w)
+			problem.Write(w, ErrInvalidArgument)
 			return
 		}
 
 		if err := s.MakePayment(r.Context(), orderID, ps); err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Post("/order/{order_id}/reorder", func(w http.ResponseWriter, r *http.Request) {
+		orderID := chi.URLParam(r, "order_id")
+
+		o, unavailable, err := s.Reorder(r.Context(), orderID)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+		var response = map[string]interface{}{
+			"order":       o,
+			"unavailable": unavailable,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			problem.Write(w, err)
+			return
+		}
+	})
+
+	r.Get("/customer/{customer_id}/orders", func(w http.ResponseWriter, r *http.Request) {
+		customerID := chi.URLParam(r, "customer_id")
+
+		q := r.URL.Query()
+		var statuses []transaction.OrderStatus
+		for _, val := range q["status"] {
+			for _, s := range strings.Split(val, ",") {
+				status, err := strconv.Atoi(s)
+				if err != nil {
+					problem.Write(w, ErrInvalidArgument)
+					return
+				}
+				statuses = append(statuses, transaction.OrderStatus(status))
+			}
+		}
+		page := transaction.Page{Cursor: q.Get("cursor")}
+		if val := q.Get("limit"); val != "" {
+			limit, err := strconv.Atoi(val)
+			if err != nil {
+				problem.Write(w, ErrInvalidArgument)
+				return
+			}
+			page.Limit = limit
+		}
+
+		result, err := s.ListCustomerOrders(r.Context(), customerID, statuses, page)
+		if err != nil {
+			problem.Write(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			problem.Write(w, err)
 			return
 		}
 	})
@@ -104,7 +169,7 @@ func MakeHandler(s Service) http.Handler {
 
 		status, err := s.CheckOrderStatus(r.Context(), orderID)
 		if err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 		var response = map[string]interface{}{
@@ -113,7 +178,7 @@ func MakeHandler(s Service) http.Handler {
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 	})
@@ -123,7 +188,7 @@ func MakeHandler(s Service) http.Handler {
 
 		status, err := s.CheckShipmentStatus(r.Context(), shippingID)
 		if err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 		var response = map[string]interface{}{
@@ -132,36 +197,10 @@ func MakeHandler(s Service) http.Handler {
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
-			encodeError(err, w)
+			problem.Write(w, err)
 			return
 		}
 	})
 
 	return r
 }
-
-func encodeError(err error, w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-
-	switch err {
-	case transaction.ErrCustomerNotFound:
-		fallthrough
-	case transaction.ErrOrderNotFound:
-		fallthrough
-	case transaction.ErrCouponNotFound:
-		fallthrough
-	case transaction.ErrProductNotFound:
-		w.WriteHeader(http.StatusNotFound)
-	case transaction.ErrInvalidCoupon:
-		w.WriteHeader(http.StatusConflict)
-	case transaction.ErrQuantityExceedProductStock:
-		w.WriteHeader(http.StatusConflict)
-	default:
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": err.Error(),
-	})
-}
@@ -33,12 +33,12 @@ func (s *instrumentingService) MakeOrder(ctx context.Context, customerID string)
 	return s.Service.MakeOrder(ctx, customerID)
 }
 
-func (s *instrumentingService) AddProduct(ctx context.Context, orderID, productID string, quantity int64) (err error) {
+func (s *instrumentingService) AddProduct(ctx context.Context, orderID, productID, sku string, quantity int64) (err error) {
 	defer func(begin time.Time) {
 		s.request.WithLabelValues("add_product", fmt.Sprintf("%t", err != nil)).Inc()
 		s.latency.WithLabelValues("add_product", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return s.Service.AddProduct(ctx, orderID, productID, quantity)
+	return s.Service.AddProduct(ctx, orderID, productID, sku, quantity)
 }
 
 func (s *instrumentingService) ApplyCoupon(ctx context.Context, orderID, couponCode string) (err error) {
@@ -80,3 +80,19 @@ func (s *instrumentingService) CheckShipmentStatus(ctx context.Context, shipping
 	}(time.Now())
 	return s.Service.CheckShipmentStatus(ctx, shippingID)
 }
+
+func (s *instrumentingService) ListCustomerOrders(ctx context.Context, customerID string, statuses []transaction.OrderStatus, page transaction.Page) (result *transaction.OrderPage, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("list_customer_orders", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("list_customer_orders", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.ListCustomerOrders(ctx, customerID, statuses, page)
+}
+
+func (s *instrumentingService) Reorder(ctx context.Context, orderID string) (order *transaction.Order, unavailable []UnavailableLine, err error) {
+	defer func(begin time.Time) {
+		s.request.WithLabelValues("reorder", fmt.Sprintf("%t", err != nil)).Inc()
+		s.latency.WithLabelValues("reorder", fmt.Sprintf("%t", err != nil)).Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.Service.Reorder(ctx, orderID)
+}
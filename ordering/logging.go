@@ -35,18 +35,19 @@ func (s *loggingService) MakeOrder(ctx context.Context, customerID string) (orde
 	return s.Service.MakeOrder(ctx, customerID)
 }
 
-func (s *loggingService) AddProduct(ctx context.Context, orderID, productID string, quantity int64) (err error) {
+func (s *loggingService) AddProduct(ctx context.Context, orderID, productID, sku string, quantity int64) (err error) {
 	defer func(begin time.Time) {
 		s.log.WithFields(log.Fields{
 			"method":     "add_product",
 			"order_id":   orderID,
 			"product_id": productID,
+			"sku":        sku,
 			"quantity":   quantity,
 			"took":       time.Since(begin),
 			"err":        err,
 		}).Println()
 	}(time.Now())
-	return s.Service.AddProduct(ctx, orderID, productID, quantity)
+	return s.Service.AddProduct(ctx, orderID, productID, sku, quantity)
 }
 
 func (s *loggingService) ApplyCoupon(ctx context.Context, orderID, couponCode string) (err error) {
@@ -112,3 +113,40 @@ func (s *loggingService) CheckShipmentStatus(ctx context.Context, shippingID tra
 	}(time.Now())
 	return s.Service.CheckShipmentStatus(ctx, shippingID)
 }
+
+func (s *loggingService) ListCustomerOrders(ctx context.Context, customerID string, statuses []transaction.OrderStatus, page transaction.Page) (result *transaction.OrderPage, err error) {
+	defer func(begin time.Time) {
+		var count int
+		if result != nil {
+			count = len(result.Orders)
+		}
+		s.log.WithFields(log.Fields{
+			"method":      "list_customer_orders",
+			"customer_id": customerID,
+			"statuses":    statuses,
+			"limit":       page.Size(),
+			"count":       count,
+			"took":        time.Since(begin),
+			"err":         err,
+		}).Println()
+	}(time.Now())
+	return s.Service.ListCustomerOrders(ctx, customerID, statuses, page)
+}
+
+func (s *loggingService) Reorder(ctx context.Context, orderID string) (order *transaction.Order, unavailable []UnavailableLine, err error) {
+	defer func(begin time.Time) {
+		var newOrderID string
+		if order != nil {
+			newOrderID = order.ID
+		}
+		s.log.WithFields(log.Fields{
+			"method":       "reorder",
+			"order_id":     orderID,
+			"new_order_id": newOrderID,
+			"unavailable":  len(unavailable),
+			"took":         time.Since(begin),
+			"err":          err,
+		}).Println()
+	}(time.Now())
+	return s.Service.Reorder(ctx, orderID)
+}
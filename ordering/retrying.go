@@ -0,0 +1,53 @@
+package ordering
+
+import (
+	"context"
+	"errors"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type retryingService struct {
+	attempts int
+	Service
+}
+
+// NewRetryingService creates new retrying service. Commands failed with transaction.ErrConcurrentModification
+// are re-run up to attempts times, each run loads fresh aggregates from the repositories.
+func NewRetryingService(attempts int, s Service) Service {
+	return &retryingService{attempts, s}
+}
+
+func (s *retryingService) retry(fn func() error) (err error) {
+	for i := 0; i < s.attempts; i++ {
+		err = fn()
+		if !errors.Is(err, transaction.ErrConcurrentModification) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *retryingService) AddProduct(ctx context.Context, orderID, productID, sku string, quantity int64) error {
+	return s.retry(func() error {
+		return s.Service.AddProduct(ctx, orderID, productID, sku, quantity)
+	})
+}
+
+func (s *retryingService) ApplyCoupon(ctx context.Context, orderID, couponCode string) error {
+	return s.retry(func() error {
+		return s.Service.ApplyCoupon(ctx, orderID, couponCode)
+	})
+}
+
+func (s *retryingService) SubmitOrder(ctx context.Context, orderID string) error {
+	return s.retry(func() error {
+		return s.Service.SubmitOrder(ctx, orderID)
+	})
+}
+
+func (s *retryingService) MakePayment(ctx context.Context, orderID string, ps transaction.PaymentSpecification) error {
+	return s.retry(func() error {
+		return s.Service.MakePayment(ctx, orderID, ps)
+	})
+}
@@ -2,26 +2,45 @@ package ordering
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
+	"github.com/muktihari/order-transaction-ddd/auth"
 	"github.com/muktihari/order-transaction-ddd/transaction"
 )
 
-// Service is the interface that provides ordering methods.
+// Service is the interface that provides ordering methods. Methods act on behalf of the customer authenticated
+// in the context, orders and customers other than the authenticated one are rejected with auth.ErrForbidden.
 type Service interface {
 	// MakeOrder creates new open order for the customer
 	MakeOrder(ctx context.Context, customerID string) (*transaction.Order, error)
-	// AddProduct adds product with set quantity to the order
-	AddProduct(ctx context.Context, orderID, productID string, quantity int64) error
+	// AddProduct adds variant of the product with set quantity to the order, sku is empty for product without variants
+	AddProduct(ctx context.Context, orderID, productID, sku string, quantity int64) error
 	// ApplyCoupon applies coupon to the order
 	ApplyCoupon(ctx context.Context, orderID, couponCode string) error
-	// SubmitOrder reserves added products and its quantity and finalize order
+	// SubmitOrder reserves added products and its quantity and finalize order,
+	// quantity beyond the stock of backorderable products is backordered, bundles reserve their components
 	SubmitOrder(ctx context.Context, orderID string) error
 	// MakePayment makes payment for submitted order
 	MakePayment(ctx context.Context, orderID string, ps transaction.PaymentSpecification) error
 	// CheckOrderStatus checks status order
 	CheckOrderStatus(ctx context.Context, orderID string) (transaction.OrderStatus, error)
-	// CheckShipmentStatus checks shipment status
+	// CheckShipmentStatus checks shipment status of the order shipped with the shipping ID
 	CheckShipmentStatus(ctx context.Context, shippingID transaction.ShippingID) (transaction.ShipmentStatus, error)
+	// ListCustomerOrders lists orders of the customer from the newest, optionally only orders in statuses
+	ListCustomerOrders(ctx context.Context, customerID string, statuses []transaction.OrderStatus, page transaction.Page) (*transaction.OrderPage, error)
+	// Reorder creates new open order from the cart of a past order at current prices,
+	// lines which can not be added anymore are returned instead of failing the whole order.
+	Reorder(ctx context.Context, orderID string) (*transaction.Order, []UnavailableLine, error)
+}
+
+// UnavailableLine is a cart line of a past order which can not be added to a new order
+type UnavailableLine struct {
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku"`
+	Quantity  int64  `json:"quantity"`
+	Available int64  `json:"available"`
+	Reason    string `json:"reason"`
 }
 
 type service struct {
@@ -30,6 +49,8 @@ type service struct {
 	products  transaction.ProductRepository
 	coupons   transaction.CouponRepository
 	logistics transaction.LogisticsPartner
+	notifier  transaction.StockNotifier
+	uow       transaction.UnitOfWork
 }
 
 // NewService creates a ordering service with necessary dependencies
@@ -39,6 +60,8 @@ func NewService(
 	products transaction.ProductRepository,
 	coupons transaction.CouponRepository,
 	logistics transaction.LogisticsPartner,
+	notifier transaction.StockNotifier,
+	uow transaction.UnitOfWork,
 ) Service {
 	return &service{
 		orders:    orders,
@@ -46,10 +69,16 @@ func NewService(
 		products:  products,
 		coupons:   coupons,
 		logistics: logistics,
+		notifier:  notifier,
+		uow:       uow,
 	}
 }
 
 func (s *service) MakeOrder(ctx context.Context, customerID string) (*transaction.Order, error) {
+	if err := auth.AuthorizeCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
 	c, err := s.customers.FindByID(ctx, customerID)
 	if err != nil {
 		return nil, err
@@ -64,22 +93,28 @@ func (s *service) MakeOrder(ctx context.Context, customerID string) (*transactio
 	return o, nil
 }
 
-func (s *service) AddProduct(ctx context.Context, orderID string, productID string, quantity int64) error {
+func (s *service) AddProduct(ctx context.Context, orderID string, productID, sku string, quantity int64) error {
 	o, err := s.orders.FindByID(ctx, orderID)
 	if err != nil {
 		return err
 	}
+	if err := auth.AuthorizeCustomer(ctx, o.Customer.ID); err != nil {
+		return err
+	}
 
 	p, err := s.products.FindByID(ctx, productID)
 	if err != nil {
 		return err
 	}
+	if err := transaction.ResolveBundle(ctx, s.products, p); err != nil {
+		return err
+	}
 
-	if err := p.TryReserveQuantity(quantity); err != nil {
+	if err := p.TryReserveQuantity(sku, quantity); err != nil {
 		return err
 	}
 
-	if err := o.AddProduct(p, quantity); err != nil {
+	if err := o.AddProduct(p, sku, quantity); err != nil {
 		return err
 	}
 
@@ -95,6 +130,9 @@ func (s *service) ApplyCoupon(ctx context.Context, orderID, couponCode string) e
 	if err != nil {
 		return err
 	}
+	if err := auth.AuthorizeCustomer(ctx, o.Customer.ID); err != nil {
+		return err
+	}
 
 	c, err := s.coupons.FindByCode(ctx, couponCode)
 	if err != nil {
@@ -113,19 +151,83 @@ func (s *service) ApplyCoupon(ctx context.Context, orderID, couponCode string) e
 }
 
 func (s *service) SubmitOrder(ctx context.Context, orderID string) error {
-	o, err := s.orders.FindByID(ctx, orderID)
-	if err != nil {
-		return err
-	}
+	var alerts []transaction.StockAlert
+	err := s.uow.Do(ctx, func(ctx context.Context, r transaction.Repositories) error {
+		alerts = nil
 
-	if err := o.ChangeStatusTo(transaction.OrderStatusSubmitted); err != nil {
-		return err
-	}
+		o, err := r.Orders().FindByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if err := auth.AuthorizeCustomer(ctx, o.Customer.ID); err != nil {
+			return err
+		}
+
+		if err := o.ChangeStatusTo(transaction.OrderStatusSubmitted); err != nil {
+			return err
+		}
+
+		if o.HasCoupon() {
+			c, err := r.Coupons().FindByCode(ctx, o.Coupon.Code)
+			if err != nil {
+				return err
+			}
+			if err := c.Use(); err != nil {
+				return err
+			}
+			if err := r.Coupons().Update(ctx, c); err != nil {
+				return err
+			}
+		}
 
-	if err := s.orders.FinalizeAndReserveProducts(ctx, o); err != nil {
+		for i := range o.Cart {
+			cartItem := &o.Cart[i]
+			p, err := r.Products().FindByID(ctx, cartItem.Product.ID)
+			if err != nil {
+				return err
+			}
+			if p.IsBundle() {
+				bundleAlerts, err := transaction.ReserveBundle(ctx, r, o, cartItem, p)
+				if err != nil {
+					return err
+				}
+				alerts = append(alerts, bundleAlerts...)
+				continue
+			}
+			if err := p.TryReserveQuantity(cartItem.SKU, cartItem.Quantity); err != nil {
+				return err
+			}
+			// quantity missing from the stock of a backorderable product is allocated once it's restocked
+			previous := p.Quantity
+			cartItem.Backordered = p.ReserveAvailable(cartItem.SKU, cartItem.Quantity)
+			if err := r.Products().Update(ctx, p); err != nil {
+				return err
+			}
+			if alert, ok := p.StockAlert(previous); ok {
+				alerts = append(alerts, alert)
+			}
+
+			reserved := cartItem.Quantity - cartItem.Backordered
+			if reserved == 0 {
+				continue
+			}
+			m := transaction.NewInventoryMovement(p.ID, cartItem.SKU, -reserved, transaction.MovementReasonOrderReservation, transaction.CustomerActor(o.Customer.ID))
+			m.OrderID = o.ID
+			if err := r.Inventory().Store(ctx, m); err != nil {
+				return err
+			}
+		}
+
+		return r.Orders().Update(ctx, o)
+	})
+	if err != nil {
 		return err
 	}
 
+	// alerts are sent once the reservation is committed, a rolled back attempt crossed nothing
+	for _, alert := range alerts {
+		s.notifier.NotifyStockAlert(ctx, alert)
+	}
 	return nil
 }
 
@@ -138,6 +240,9 @@ func (s *service) MakePayment(ctx context.Context, orderID string, ps transactio
 	if err != nil {
 		return err
 	}
+	if err := auth.AuthorizeCustomer(ctx, o.Customer.ID); err != nil {
+		return err
+	}
 
 	o.SpecifyNewPayment(ps)
 	if err := o.ChangeStatusTo(transaction.OrderStatusPaid); err != nil {
@@ -156,9 +261,95 @@ func (s *service) CheckOrderStatus(ctx context.Context, orderID string) (transac
 	if err != nil {
 		return 0, err
 	}
+	if err := auth.AuthorizeCustomer(ctx, o.Customer.ID); err != nil {
+		return 0, err
+	}
 	return o.Status, nil
 }
 
 func (s *service) CheckShipmentStatus(ctx context.Context, shippingID transaction.ShippingID) (transaction.ShipmentStatus, error) {
+	// orders not shipped yet have no shipping ID, an empty one would match them all
+	if shippingID == "" {
+		return 0, transaction.ErrOrderNotFound
+	}
+	result, err := s.orders.Find(ctx, transaction.OrderFilter{ShippingID: shippingID}, transaction.Page{Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+	if len(result.Orders) == 0 {
+		return 0, fmt.Errorf("shipment %s: %w", shippingID, transaction.ErrOrderNotFound)
+	}
+	if err := auth.AuthorizeCustomer(ctx, result.Orders[0].Customer.ID); err != nil {
+		return 0, err
+	}
 	return s.logistics.CheckShipmentStatus(ctx, shippingID)
 }
+
+func (s *service) ListCustomerOrders(ctx context.Context, customerID string, statuses []transaction.OrderStatus, page transaction.Page) (*transaction.OrderPage, error) {
+	if err := auth.AuthorizeCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+	if _, err := s.customers.FindByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+	filter := transaction.OrderFilter{
+		CustomerID: customerID,
+		Statuses:   statuses,
+		Sort:       transaction.OrderSortNewest,
+	}
+	return s.orders.Find(ctx, filter, page)
+}
+
+func (s *service) Reorder(ctx context.Context, orderID string) (*transaction.Order, []UnavailableLine, error) {
+	past, err := s.orders.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := auth.AuthorizeCustomer(ctx, past.Customer.ID); err != nil {
+		return nil, nil, err
+	}
+
+	c, err := s.customers.FindByID(ctx, past.Customer.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	o := transaction.NewOrder(c)
+	unavailable := make([]UnavailableLine, 0)
+	for _, cartItem := range past.Cart {
+		line := UnavailableLine{ProductID: cartItem.Product.ID, SKU: cartItem.SKU, Quantity: cartItem.Quantity}
+
+		p, err := s.products.FindByID(ctx, cartItem.Product.ID)
+		if err != nil {
+			if !errors.Is(err, transaction.ErrProductNotFound) {
+				return nil, nil, err
+			}
+			line.Reason = err.Error()
+			unavailable = append(unavailable, line)
+			continue
+		}
+		if err := transaction.ResolveBundle(ctx, s.products, p); err != nil {
+			return nil, nil, err
+		}
+
+		if err := p.TryReserveQuantity(cartItem.SKU, cartItem.Quantity); err != nil {
+			line.Available = p.Quantity
+			if v, err := p.Variant(cartItem.SKU); err == nil {
+				line.Available = v.Quantity
+			}
+			line.Reason = err.Error()
+			unavailable = append(unavailable, line)
+			continue
+		}
+
+		if err := o.AddProduct(p, cartItem.SKU, cartItem.Quantity); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := s.orders.Store(ctx, o); err != nil {
+		return nil, nil, err
+	}
+
+	return o, unavailable, nil
+}
@@ -2,25 +2,44 @@ package ordering_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/handling"
 	"github.com/muktihari/order-transaction-ddd/ordering"
 	"github.com/muktihari/order-transaction-ddd/persistent/inmem"
 	"github.com/muktihari/order-transaction-ddd/transaction"
 	"github.com/shopspring/decimal"
 )
 
+// stockNotifier records the stock alerts it's notified of
+type stockNotifier struct {
+	alerts []transaction.StockAlert
+}
+
+func (n *stockNotifier) NotifyStockAlert(ctx context.Context, alert transaction.StockAlert) {
+	n.alerts = append(n.alerts, alert)
+}
+
+// customerContext returns context authenticated as the customer
+func customerContext(customerID string) context.Context {
+	return auth.WithCustomer(context.Background(), &transaction.Customer{ID: customerID})
+}
+
 func TestMakeOrder(t *testing.T) {
 	var (
 		customers = inmem.NewCustomerRepository()
 		products  = inmem.NewProductRepository()
 		coupons   = inmem.NewCouponRepository()
 		logistics = inmem.NewLogisticsParner()
-		orders    = inmem.NewOrderRepository(coupons, products)
-		s         = ordering.NewService(orders, customers, products, coupons, logistics)
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
 	)
 
 	tt := []struct {
@@ -39,7 +58,7 @@ func TestMakeOrder(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.Name, func(t *testing.T) {
-			o, err := s.MakeOrder(context.Background(), tc.CustomerID)
+			o, err := s.MakeOrder(customerContext("CUSTOMER1"), tc.CustomerID)
 			if err != nil {
 				t.Fatalf("got err, expected nil")
 			}
@@ -47,6 +66,10 @@ func TestMakeOrder(t *testing.T) {
 				t.Errorf("got empty string, expected not empty")
 			}
 			o.ID = ""
+			if o.CreatedAt.IsZero() {
+				t.Errorf("got zero created at, expected creation time")
+			}
+			o.CreatedAt = time.Time{}
 			if diff := cmp.Diff(o, tc.Expected); diff != "" {
 				fmt.Println(diff)
 				t.Fatal("different")
@@ -61,8 +84,10 @@ func TestAddProduct(t *testing.T) {
 		products  = inmem.NewProductRepository()
 		coupons   = inmem.NewCouponRepository()
 		logistics = inmem.NewLogisticsParner()
-		orders    = inmem.NewOrderRepository(coupons, products)
-		s         = ordering.NewService(orders, customers, products, coupons, logistics)
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
 	)
 
 	tt := []struct {
@@ -82,15 +107,16 @@ func TestAddProduct(t *testing.T) {
 					Quantity: 5,
 				},
 			},
-			Price:  decimal.NewFromInt(500 * 5),
-			Status: transaction.OrderStatusOpen,
+			Price:   decimal.NewFromInt(500 * 5),
+			Status:  transaction.OrderStatusOpen,
+			Version: 1,
 		}},
 	}
 
-	ctx := context.Background()
+	ctx := customerContext("CUSTOMER1")
 	for _, tc := range tt {
 		t.Run(tc.Name, func(t *testing.T) {
-			if err := s.AddProduct(ctx, tc.OrderID, tc.ProductID, 5); err != nil {
+			if err := s.AddProduct(ctx, tc.OrderID, tc.ProductID, "", 5); err != nil {
 				t.Fatalf("got %v, expected nil", err)
 			}
 
@@ -98,6 +124,7 @@ func TestAddProduct(t *testing.T) {
 			if err != nil {
 				t.Fatalf("got %v, expected nil", err)
 			}
+			o.CreatedAt = time.Time{}
 
 			if diff := cmp.Diff(o, tc.Expected); diff != "" {
 				fmt.Println(diff)
@@ -113,8 +140,10 @@ func TestApplyCoupon(t *testing.T) {
 		products  = inmem.NewProductRepository()
 		coupons   = inmem.NewCouponRepository()
 		logistics = inmem.NewLogisticsParner()
-		orders    = inmem.NewOrderRepository(coupons, products)
-		s         = ordering.NewService(orders, customers, products, coupons, logistics)
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
 	)
 
 	tt := []struct {
@@ -143,10 +172,11 @@ func TestApplyCoupon(t *testing.T) {
 			Price:               decimal.NewFromInt(500 * 5),
 			PriceAfterReduction: decimal.NewFromInt(500 * 5).Sub(decimal.NewFromInt(500 * 5).Mul(decimal.NewFromFloat(0.2))),
 			Status:              transaction.OrderStatusOpen,
+			Version:             1,
 		}},
 	}
 
-	ctx := context.Background()
+	ctx := customerContext("CUSTOMER1")
 	for _, tc := range tt {
 		t.Run(tc.Name, func(t *testing.T) {
 			if err := s.ApplyCoupon(ctx, tc.OrderID, tc.CouponCode); err != nil {
@@ -159,6 +189,7 @@ func TestApplyCoupon(t *testing.T) {
 			}
 			o.Coupon.Begin = time.Time{}
 			o.Coupon.End = time.Time{}
+			o.CreatedAt = time.Time{}
 
 			if diff := cmp.Diff(o, tc.Expected); diff != "" {
 				fmt.Println(diff)
@@ -168,14 +199,81 @@ func TestApplyCoupon(t *testing.T) {
 	}
 }
 
+func TestOrderPrice(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
+	)
+
+	ctx := customerContext("CUSTOMER1")
+	o, err := s.MakeOrder(ctx, "CUSTOMER1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	// every change recalculates the price of the whole cart instead of adding to the previous price
+	if err := s.AddProduct(ctx, o.ID, "PRODUCT1", "", 2); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.AddProduct(ctx, o.ID, "PRODUCT2", "", 3); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.ApplyCoupon(ctx, o.ID, "DISCOUNT_20%"); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	o, err = orders.FindByID(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if expected := decimal.NewFromInt(500*2 + 5*3); !o.Price.Equal(expected) {
+		t.Errorf("got price %s, expected %s", o.Price, expected)
+	}
+	if expected := decimal.NewFromInt(812); !o.PriceAfterReduction.Equal(expected) {
+		t.Errorf("got price after reduction %s, expected %s", o.PriceAfterReduction, expected)
+	}
+
+	// adding the product already in the cart changes its quantity, the price follows
+	if err := s.AddProduct(ctx, o.ID, "PRODUCT1", "", 3); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	for _, quantity := range []int64{0, -1} {
+		if err := s.AddProduct(ctx, o.ID, "PRODUCT1", "", quantity); !errors.Is(err, transaction.ErrInvalidQuantity) {
+			t.Errorf("quantity %d: got %v, expected %v", quantity, err, transaction.ErrInvalidQuantity)
+		}
+	}
+
+	o, err = orders.FindByID(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if len(o.Cart) != 2 || o.Cart[0].Quantity != 3 {
+		t.Fatalf("got cart %+v, expected 3 of PRODUCT1 and 3 of PRODUCT2", o.Cart)
+	}
+	if expected := decimal.NewFromInt(500*3 + 5*3); !o.Price.Equal(expected) {
+		t.Errorf("got price %s, expected %s", o.Price, expected)
+	}
+	if expected := decimal.NewFromInt(1212); !o.PriceAfterReduction.Equal(expected) {
+		t.Errorf("got price after reduction %s, expected %s", o.PriceAfterReduction, expected)
+	}
+}
+
 func TestSubmitOrder(t *testing.T) {
 	var (
 		customers = inmem.NewCustomerRepository()
 		products  = inmem.NewProductRepository()
 		coupons   = inmem.NewCouponRepository()
 		logistics = inmem.NewLogisticsParner()
-		orders    = inmem.NewOrderRepository(coupons, products)
-		s         = ordering.NewService(orders, customers, products, coupons, logistics)
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
 	)
 
 	tt := []struct {
@@ -204,10 +302,11 @@ func TestSubmitOrder(t *testing.T) {
 			Price:               decimal.NewFromInt(500 * 5),
 			PriceAfterReduction: decimal.NewFromInt(500 * 5).Sub(decimal.NewFromInt(500 * 5).Mul(decimal.NewFromFloat(0.2))),
 			Status:              transaction.OrderStatusSubmitted,
+			Version:             1,
 		}},
 	}
 
-	ctx := context.Background()
+	ctx := customerContext("CUSTOMER1")
 	for _, tc := range tt {
 		t.Run(tc.Name, func(t *testing.T) {
 			if err := s.SubmitOrder(ctx, tc.OrderID); err != nil {
@@ -221,6 +320,7 @@ func TestSubmitOrder(t *testing.T) {
 
 			o.Coupon.Begin = time.Time{}
 			o.Coupon.End = time.Time{}
+			o.CreatedAt = time.Time{}
 
 			if diff := cmp.Diff(o, tc.Expected); diff != "" {
 				fmt.Println(diff)
@@ -229,3 +329,650 @@ func TestSubmitOrder(t *testing.T) {
 		})
 	}
 }
+
+type conflictingService struct {
+	ordering.Service
+	conflicts int
+	calls     int
+}
+
+func (s *conflictingService) SubmitOrder(ctx context.Context, orderID string) error {
+	s.calls++
+	if s.calls <= s.conflicts {
+		return transaction.ErrConcurrentModification
+	}
+	return nil
+}
+
+func TestRetryingService(t *testing.T) {
+	tt := []struct {
+		Name          string
+		Attempts      int
+		Conflicts     int
+		ExpectedErr   error
+		ExpectedCalls int
+	}{
+		{Name: "No Conflict", Attempts: 3, Conflicts: 0, ExpectedErr: nil, ExpectedCalls: 1},
+		{Name: "Conflict Resolved By Retry", Attempts: 3, Conflicts: 2, ExpectedErr: nil, ExpectedCalls: 3},
+		{Name: "Conflict Exceed Attempts", Attempts: 3, Conflicts: 5, ExpectedErr: transaction.ErrConcurrentModification, ExpectedCalls: 3},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			cs := &conflictingService{conflicts: tc.Conflicts}
+			s := ordering.NewRetryingService(tc.Attempts, cs)
+			if err := s.SubmitOrder(customerContext("CUSTOMER1"), "ORDER_OPEN"); !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("got %v, expected %v", err, tc.ExpectedErr)
+			}
+			if cs.calls != tc.ExpectedCalls {
+				t.Fatalf("got %d calls, expected %d", cs.calls, tc.ExpectedCalls)
+			}
+		})
+	}
+}
+
+// racingOrders lets another request change the order right after it's loaded, once
+type racingOrders struct {
+	transaction.OrderRepository
+	race func()
+}
+
+func (r *racingOrders) FindByID(ctx context.Context, id string) (*transaction.Order, error) {
+	o, err := r.OrderRepository.FindByID(ctx, id)
+	if race := r.race; race != nil {
+		r.race = nil
+		race()
+	}
+	return o, err
+}
+
+func TestConcurrentModification(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		racing    = &racingOrders{OrderRepository: orders}
+		other     = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
+		s         = ordering.NewService(racing, customers, products, coupons, logistics, &stockNotifier{}, uow)
+	)
+	ctx := customerContext("CUSTOMER1")
+
+	racing.race = func() {
+		if err := other.AddProduct(ctx, "ORDER_OPEN", "PRODUCT2", "", 1); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+	}
+	if err := s.AddProduct(ctx, "ORDER_OPEN", "PRODUCT1", "", 1); !errors.Is(err, transaction.ErrConcurrentModification) {
+		t.Fatalf("got %v, expected %v", err, transaction.ErrConcurrentModification)
+	}
+	o, err := orders.FindByID(ctx, "ORDER_OPEN")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if len(o.Cart) != 1 || o.Cart[0].Product.ID != "PRODUCT2" {
+		t.Fatalf("got cart %+v, expected only the product added by the other request", o.Cart)
+	}
+
+	racing.race = func() {
+		if err := other.AddProduct(ctx, "ORDER_OPEN", "PRODUCT2", "", 2); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+	}
+	if err := ordering.NewRetryingService(3, s).AddProduct(ctx, "ORDER_OPEN", "PRODUCT1", "", 1); err != nil {
+		t.Fatalf("got %v retrying, expected nil", err)
+	}
+	o, err = orders.FindByID(ctx, "ORDER_OPEN")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	quantities := make(map[string]int64)
+	for _, item := range o.Cart {
+		quantities[item.Product.ID] += item.Quantity
+	}
+	if quantities["PRODUCT1"] != 1 || quantities["PRODUCT2"] != 2 {
+		t.Errorf("got cart quantities %v, expected changes of both requests kept", quantities)
+	}
+}
+
+func TestSubmitOrderReservation(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
+	)
+
+	ctx := customerContext("CUSTOMER1")
+	if err := s.SubmitOrder(ctx, "ORDER_WITH_PRODUCT_AND_COUPON"); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	p, err := products.FindByID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if p.Quantity != 195 {
+		t.Errorf("got product quantity %d, expected %d", p.Quantity, 195)
+	}
+
+	movements, err := inventory.FindByProductID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if stock := transaction.Stock(movements); stock != p.Quantity {
+		t.Errorf("got ledger stock %d, expected %d", stock, p.Quantity)
+	}
+	reservation := movements[len(movements)-1]
+	if reservation.Reason != transaction.MovementReasonOrderReservation || reservation.OrderID != "ORDER_WITH_PRODUCT_AND_COUPON" ||
+		reservation.Actor != transaction.CustomerActor("CUSTOMER1") {
+		t.Errorf("got movement %+v, expected reservation of the order by its customer", reservation)
+	}
+
+	c, err := coupons.FindByCode(ctx, "DISCOUNT_20%")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if c.Quantity != 99 {
+		t.Errorf("got coupon quantity %d, expected %d", c.Quantity, 99)
+	}
+
+	// the second order exceeds the remaining stock, nothing should be changed
+	o, err := s.MakeOrder(ctx, "CUSTOMER1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.AddProduct(ctx, o.ID, "PRODUCT2", "", 10); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.AddProduct(ctx, o.ID, "PRODUCT1", "", 195); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	p.Quantity = 190
+	if err := products.Update(ctx, p); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	if err := s.SubmitOrder(ctx, o.ID); !errors.Is(err, transaction.ErrQuantityExceedProductStock) {
+		t.Fatalf("got %v, expected %v", err, transaction.ErrQuantityExceedProductStock)
+	}
+
+	status, err := s.CheckOrderStatus(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if status != transaction.OrderStatusOpen {
+		t.Errorf("got status %v, expected %v", status, transaction.OrderStatusOpen)
+	}
+
+	p2, err := products.FindByID(ctx, "PRODUCT2")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if p2.Quantity != 2000 {
+		t.Errorf("got product quantity %d, expected %d", p2.Quantity, 2000)
+	}
+}
+
+func TestSubmitOrderVariants(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
+	)
+
+	ctx := customerContext("CUSTOMER1")
+	xl := decimal.NewFromFloat(17.5)
+	p, _ := transaction.NewProduct("Uniqlo Airism T-Shirt", decimal.NewFromInt(15))
+	_ = p.AddVariant("AIRISM-M", "M", nil)
+	_ = p.AddVariant("AIRISM-XL", "XL", &xl)
+	_ = p.AdjustQuantity("AIRISM-M", 5)
+	_ = p.AdjustQuantity("AIRISM-XL", 7)
+	if err := products.Store(ctx, p); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	o, err := s.MakeOrder(ctx, "CUSTOMER1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.AddProduct(ctx, o.ID, p.ID, "", 1); !errors.Is(err, transaction.ErrVariantRequired) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrVariantRequired)
+	}
+	if err := s.AddProduct(ctx, o.ID, p.ID, "AIRISM-S", 1); !errors.Is(err, transaction.ErrVariantNotFound) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrVariantNotFound)
+	}
+	if err := s.AddProduct(ctx, o.ID, p.ID, "AIRISM-XL", 8); !errors.Is(err, transaction.ErrQuantityExceedProductStock) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrQuantityExceedProductStock)
+	}
+	if err := s.AddProduct(ctx, o.ID, p.ID, "AIRISM-XL", 3); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.AddProduct(ctx, o.ID, p.ID, "AIRISM-M", 1); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.SubmitOrder(ctx, o.ID); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	submitted, err := orders.FindByID(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if expected := decimal.NewFromFloat(67.5); !submitted.Price.Equal(expected) {
+		t.Errorf("got order price %s, expected %s", submitted.Price, expected)
+	}
+
+	p, err = products.FindByID(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	movements, err := inventory.FindByProductID(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	for sku, reserved := range map[string]int64{"AIRISM-M": 1, "AIRISM-XL": 3} {
+		v, err := p.Variant(sku)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if v.Quantity != 4 {
+			t.Errorf("%s: got quantity %d, expected 4", sku, v.Quantity)
+		}
+		// the product is stored without ledger, so the ledger only has the reservations
+		if stock := transaction.StockOf(movements, sku); stock != -reserved {
+			t.Errorf("%s: got ledger stock %d, expected %d", sku, stock, -reserved)
+		}
+	}
+	if p.Quantity != 8 {
+		t.Errorf("got product quantity %d, expected 8", p.Quantity)
+	}
+}
+
+func TestListCustomerOrders(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
+	)
+
+	ctx := customerContext("CUSTOMER1")
+	if err := s.SubmitOrder(ctx, "ORDER_WITH_PRODUCT_AND_COUPON"); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	tt := []struct {
+		Name       string
+		CustomerID string
+		Statuses   []transaction.OrderStatus
+		Count      int
+		Err        error
+	}{
+		{Name: "All Orders", CustomerID: "CUSTOMER1", Count: 3},
+		{Name: "Submitted Orders", CustomerID: "CUSTOMER1", Statuses: []transaction.OrderStatus{transaction.OrderStatusSubmitted}, Count: 1},
+		{Name: "Unknown Customer", CustomerID: "UNKNOWN", Err: transaction.ErrCustomerNotFound},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				count int
+				page  = transaction.Page{Limit: 2}
+			)
+			for {
+				result, err := s.ListCustomerOrders(customerContext(tc.CustomerID), tc.CustomerID, tc.Statuses, page)
+				if !errors.Is(err, tc.Err) {
+					t.Fatalf("got %v, expected %v", err, tc.Err)
+				}
+				if err != nil {
+					return
+				}
+				count += len(result.Orders)
+				if result.NextCursor == "" {
+					break
+				}
+				page.Cursor = result.NextCursor
+			}
+			if count != tc.Count {
+				t.Errorf("got %d orders, expected %d", count, tc.Count)
+			}
+		})
+	}
+}
+
+func TestReorder(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
+	)
+
+	ctx := customerContext("CUSTOMER1")
+	past, err := s.MakeOrder(ctx, "CUSTOMER1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.AddProduct(ctx, past.ID, "PRODUCT1", "", 5); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.AddProduct(ctx, past.ID, "PRODUCT2", "", 10); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.SubmitOrder(ctx, past.ID); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	// price of PRODUCT1 has changed and PRODUCT2 is running out of stock since the past order
+	p1, err := products.FindByID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	p1.Price = decimal.NewFromInt(600)
+	if err := products.Update(ctx, p1); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	p2, err := products.FindByID(ctx, "PRODUCT2")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	p2.Quantity = 3
+	if err := products.Update(ctx, p2); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	o, unavailable, err := s.Reorder(ctx, past.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if o.ID == "" || o.ID == past.ID {
+		t.Errorf("got order id %q, expected new order", o.ID)
+	}
+	if o.Status != transaction.OrderStatusOpen {
+		t.Errorf("got status %v, expected %v", o.Status, transaction.OrderStatusOpen)
+	}
+	if len(o.Cart) != 1 || o.Cart[0].Product.ID != "PRODUCT1" || o.Cart[0].Quantity != 5 {
+		t.Fatalf("got cart %+v, expected 5 of PRODUCT1", o.Cart)
+	}
+	if !o.Price.Equal(decimal.NewFromInt(3000)) {
+		t.Errorf("got price %s, expected %s", o.Price, decimal.NewFromInt(3000))
+	}
+
+	expected := []ordering.UnavailableLine{
+		{ProductID: "PRODUCT2", Quantity: 10, Available: 3, Reason: transaction.ErrQuantityExceedProductStock.Error()},
+	}
+	if diff := cmp.Diff(unavailable, expected); diff != "" {
+		fmt.Println(diff)
+		t.Fatal("different")
+	}
+
+	stored, err := orders.FindByID(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if len(stored.Cart) != 1 {
+		t.Errorf("got %d stored cart items, expected 1", len(stored.Cart))
+	}
+
+	if _, _, err := s.Reorder(ctx, "UNKNOWN"); !errors.Is(err, transaction.ErrOrderNotFound) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrOrderNotFound)
+	}
+}
+
+func TestSubmitOrderStockAlert(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		notifier  = &stockNotifier{}
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, notifier, uow)
+		ctx       = customerContext("CUSTOMER1")
+	)
+
+	p, err := products.FindByID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := p.SetReorderThreshold(150); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := products.Update(ctx, p); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	tt := []struct {
+		Name     string
+		Quantity int64
+		Expected []transaction.StockAlertKind
+	}{
+		{Name: "Above Threshold", Quantity: 40, Expected: nil},
+		{Name: "Crossing Threshold", Quantity: 20, Expected: []transaction.StockAlertKind{transaction.StockAlertLow}},
+		{Name: "Below Threshold", Quantity: 100, Expected: nil},
+		{Name: "Out Of Stock", Quantity: 40, Expected: []transaction.StockAlertKind{transaction.StockAlertOut}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			notifier.alerts = nil
+			o, err := s.MakeOrder(ctx, "CUSTOMER1")
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if err := s.AddProduct(ctx, o.ID, "PRODUCT1", "", tc.Quantity); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if err := s.SubmitOrder(ctx, o.ID); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+
+			var kinds []transaction.StockAlertKind
+			for _, alert := range notifier.alerts {
+				if alert.ProductID != "PRODUCT1" || alert.ReorderThreshold != 150 {
+					t.Errorf("got alert %+v, expected alert of PRODUCT1", alert)
+				}
+				kinds = append(kinds, alert.Kind)
+			}
+			if diff := cmp.Diff(tc.Expected, kinds); diff != "" {
+				t.Errorf("(-expected +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestSubmitOrderBundle(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
+		h         = handling.NewService(orders, products, logistics, uow)
+		ctx       = customerContext("CUSTOMER1")
+	)
+
+	bundle, err := transaction.NewProduct("Sony Xperia 10 Starter Kit", decimal.NewFromInt(450))
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	components := []transaction.Component{{ProductID: "PRODUCT1", Quantity: 1}, {ProductID: "PRODUCT2", Quantity: 2}}
+	if err := bundle.SetComponents(components); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := products.Store(ctx, bundle); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	o, err := s.MakeOrder(ctx, "CUSTOMER1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	// PRODUCT1 makes up at most 200 bundles
+	if err := s.AddProduct(ctx, o.ID, bundle.ID, "", 201); !errors.Is(err, transaction.ErrQuantityExceedProductStock) {
+		t.Fatalf("got %v, expected %v", err, transaction.ErrQuantityExceedProductStock)
+	}
+	if err := s.AddProduct(ctx, o.ID, bundle.ID, "", 3); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := s.SubmitOrder(ctx, o.ID); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	quantities := func() []int64 {
+		t.Helper()
+		var quantities []int64
+		for _, id := range []string{"PRODUCT1", "PRODUCT2"} {
+			p, err := products.FindByID(ctx, id)
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			movements, err := inventory.FindByProductID(ctx, id)
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if stock := transaction.Stock(movements); stock != p.Quantity {
+				t.Errorf("got ledger stock %d of %s, expected %d", stock, id, p.Quantity)
+			}
+			quantities = append(quantities, p.Quantity)
+		}
+		return quantities
+	}
+	if diff := cmp.Diff([]int64{197, 1994}, quantities()); diff != "" {
+		t.Errorf("(-expected +got): %s", diff)
+	}
+
+	submitted, err := orders.FindByID(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if diff := cmp.Diff(components, submitted.Cart[0].Components); diff != "" {
+		t.Errorf("(-expected +got): %s", diff)
+	}
+
+	// the bundle changing after submit does not change what is released
+	bundle, err = products.FindByID(ctx, bundle.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := bundle.SetComponents([]transaction.Component{{ProductID: "PRODUCT2", Quantity: 1}}); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := products.Update(ctx, bundle); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	admin := &transaction.Admin{ID: "ADMIN1", Role: transaction.RoleSuperadmin}
+	if err := h.CancelOrder(auth.WithAdmin(ctx, admin), o.ID); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if diff := cmp.Diff([]int64{200, 2000}, quantities()); diff != "" {
+		t.Errorf("(-expected +got): %s", diff)
+	}
+}
+
+func TestOrderOwnership(t *testing.T) {
+	var (
+		customers = inmem.NewCustomerRepository()
+		products  = inmem.NewProductRepository()
+		coupons   = inmem.NewCouponRepository()
+		logistics = inmem.NewLogisticsParner()
+		orders    = inmem.NewOrderRepository()
+		inventory = inmem.NewInventoryRepository()
+		uow       = inmem.NewUnitOfWork(orders, products, coupons, inventory)
+		s         = ordering.NewService(orders, customers, products, coupons, logistics, &stockNotifier{}, uow)
+	)
+
+	o, err := s.MakeOrder(customerContext("CUSTOMER1"), "CUSTOMER1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	shippingID, err := logistics.RegisterShipment(context.Background(), o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	o.SpecifyShippingID(shippingID, transaction.AdminActor("ADMIN1"))
+	if err := orders.Update(context.Background(), o); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	tt := []struct {
+		Name string
+		Ctx  context.Context
+		Err  error
+	}{
+		{Name: "Another Customer", Ctx: customerContext("CUSTOMER2"), Err: auth.ErrForbidden},
+		{Name: "Unauthenticated", Ctx: context.Background(), Err: auth.ErrUnauthenticated},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			if _, err := s.MakeOrder(tc.Ctx, "CUSTOMER1"); !errors.Is(err, tc.Err) {
+				t.Errorf("MakeOrder: got %v, expected %v", err, tc.Err)
+			}
+			if err := s.AddProduct(tc.Ctx, o.ID, "PRODUCT1", "", 1); !errors.Is(err, tc.Err) {
+				t.Errorf("AddProduct: got %v, expected %v", err, tc.Err)
+			}
+			if err := s.ApplyCoupon(tc.Ctx, o.ID, "DISCOUNT_$5"); !errors.Is(err, tc.Err) {
+				t.Errorf("ApplyCoupon: got %v, expected %v", err, tc.Err)
+			}
+			if err := s.SubmitOrder(tc.Ctx, o.ID); !errors.Is(err, tc.Err) {
+				t.Errorf("SubmitOrder: got %v, expected %v", err, tc.Err)
+			}
+			if _, err := s.CheckOrderStatus(tc.Ctx, o.ID); !errors.Is(err, tc.Err) {
+				t.Errorf("CheckOrderStatus: got %v, expected %v", err, tc.Err)
+			}
+			if _, _, err := s.Reorder(tc.Ctx, o.ID); !errors.Is(err, tc.Err) {
+				t.Errorf("Reorder: got %v, expected %v", err, tc.Err)
+			}
+			if _, err := s.ListCustomerOrders(tc.Ctx, "CUSTOMER1", nil, transaction.Page{}); !errors.Is(err, tc.Err) {
+				t.Errorf("ListCustomerOrders: got %v, expected %v", err, tc.Err)
+			}
+			if _, err := s.CheckShipmentStatus(tc.Ctx, shippingID); !errors.Is(err, tc.Err) {
+				t.Errorf("CheckShipmentStatus: got %v, expected %v", err, tc.Err)
+			}
+		})
+	}
+
+	status, err := s.CheckShipmentStatus(customerContext("CUSTOMER1"), shippingID)
+	if err != nil || status != transaction.ShipmentStatusShipped {
+		t.Errorf("got status %v (err: %v), expected %v", status, err, transaction.ShipmentStatusShipped)
+	}
+	if _, err := s.CheckShipmentStatus(customerContext("CUSTOMER1"), "UNKNOWN"); !errors.Is(err, transaction.ErrOrderNotFound) {
+		t.Errorf("got %v, expected %v", err, transaction.ErrOrderNotFound)
+	}
+
+	found, err := orders.FindByID(context.Background(), o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if len(found.Cart) != 0 || found.Status != transaction.OrderStatusOpen {
+		t.Errorf("got order %+v, expected untouched", found)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"sync"
 
+	"github.com/google/uuid"
 	"github.com/muktihari/order-transaction-ddd/transaction"
-	"github.com/sirupsen/logrus"
 )
 
 type customerRepository struct {
@@ -25,32 +25,124 @@ func NewCustomerRepository() transaction.CustomerRepository {
 func (r *customerRepository) FindByID(ctx context.Context, id string) (*transaction.Customer, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	logrus.Warnf("id: %s", id)
 	if val, ok := r.customers[id]; ok {
-		return val, nil
+		c := *val
+		return &c, nil
 	}
 	return nil, transaction.ErrCustomerNotFound
 }
 
+func (r *customerRepository) FindByEmail(ctx context.Context, email string) (*transaction.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, val := range r.customers {
+		if val.Email == email {
+			c := *val
+			return &c, nil
+		}
+	}
+	return nil, transaction.ErrCustomerNotFound
+}
+
+func (r *customerRepository) Store(ctx context.Context, customer *transaction.Customer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkUnique(customer); err != nil {
+		return err
+	}
+	customer.ID = uuid.NewString()
+	c := *customer
+	r.customers[c.ID] = &c
+	return nil
+}
+
+func (r *customerRepository) Update(ctx context.Context, customer *transaction.Customer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	val, ok := r.customers[customer.ID]
+	if !ok {
+		return transaction.ErrCustomerNotFound
+	}
+	if val.Version != customer.Version {
+		return transaction.ErrConcurrentModification
+	}
+	if err := r.checkUnique(customer); err != nil {
+		return err
+	}
+	customer.Version++
+	c := *customer
+	r.customers[c.ID] = &c
+	return nil
+}
+
+func (r *customerRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.customers[id]; !ok {
+		return transaction.ErrCustomerNotFound
+	}
+	delete(r.customers, id)
+	return nil
+}
+
+// checkUnique checks no other customer has the same email or phone number
+func (r *customerRepository) checkUnique(customer *transaction.Customer) error {
+	for id, c := range r.customers {
+		if id == customer.ID {
+			continue
+		}
+		if c.Email == customer.Email {
+			return transaction.ErrEmailTaken
+		}
+		if c.PhoneNumber == customer.PhoneNumber {
+			return transaction.ErrPhoneNumberTaken
+		}
+	}
+	return nil
+}
+
 type adminRepository struct {
 	mu     sync.RWMutex
 	admins map[string]*transaction.Admin
 }
 
-// NewAdminRepository creates new admin repository in memory
-func NewAdminRepository() transaction.CustomerRepository {
-	return &customerRepository{
-		customers: map[string]*transaction.Customer{
-			"ADMIN1": {ID: "ADMIN1", Name: "Mukti", PhoneNumber: "+62-67890", Email: "example@email.com", Address: "No, Street, City, Indonesia"},
-		},
-	}
+// NewAdminRepository creates new admin repository in memory, it starts without any admin
+func NewAdminRepository() transaction.AdminRepository {
+	return &adminRepository{admins: make(map[string]*transaction.Admin)}
 }
 
 func (r *adminRepository) FindByID(ctx context.Context, id string) (*transaction.Admin, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	if val, ok := r.admins[id]; ok {
-		return val, nil
+		a := *val
+		return &a, nil
 	}
-	return nil, transaction.ErrCustomerNotFound
+	return nil, transaction.ErrAdminNotFound
+}
+
+func (r *adminRepository) FindByEmail(ctx context.Context, email string) (*transaction.Admin, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, val := range r.admins {
+		if val.Email == email {
+			a := *val
+			return &a, nil
+		}
+	}
+	return nil, transaction.ErrAdminNotFound
+}
+
+func (r *adminRepository) Store(ctx context.Context, admin *transaction.Admin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.admins {
+		if a.Email == admin.Email {
+			return transaction.ErrAdminEmailTaken
+		}
+	}
+	admin.ID = uuid.NewString()
+	a := *admin
+	r.admins[a.ID] = &a
+	return nil
 }
@@ -0,0 +1,89 @@
+package inmem
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type apiKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string]*transaction.APIKey
+}
+
+// NewAPIKeyRepository creates new API key repository in memory
+func NewAPIKeyRepository() transaction.APIKeyRepository {
+	return &apiKeyRepository{
+		keys: make(map[string]*transaction.APIKey),
+	}
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, id string) (*transaction.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if val, ok := r.keys[id]; ok {
+		return copyAPIKey(val), nil
+	}
+	return nil, transaction.ErrAPIKeyNotFound
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, hash []byte) (*transaction.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, val := range r.keys {
+		if bytes.Equal(val.Hash, hash) {
+			return copyAPIKey(val), nil
+		}
+	}
+	return nil, transaction.ErrAPIKeyNotFound
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context) ([]transaction.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]transaction.APIKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		keys = append(keys, *copyAPIKey(k))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Store(ctx context.Context, key *transaction.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key.ID = uuid.NewString()
+	r.keys[key.ID] = copyAPIKey(key)
+	return nil
+}
+
+func (r *apiKeyRepository) Update(ctx context.Context, key *transaction.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	val, ok := r.keys[key.ID]
+	if !ok {
+		return transaction.ErrAPIKeyNotFound
+	}
+	if val.Version != key.Version {
+		return transaction.ErrConcurrentModification
+	}
+	key.Version++
+	r.keys[key.ID] = copyAPIKey(key)
+	return nil
+}
+
+// copyAPIKey copies the key so the stored key does not share its scopes with the caller
+func copyAPIKey(k *transaction.APIKey) *transaction.APIKey {
+	c := *k
+	c.Scopes = append([]transaction.Permission(nil), k.Scopes...)
+	return &c
+}
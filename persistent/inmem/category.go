@@ -0,0 +1,82 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type categoryRepository struct {
+	mu         sync.RWMutex
+	categories map[string]*transaction.Category
+}
+
+// NewCategoryRepository creates new category repository in memory
+func NewCategoryRepository() transaction.CategoryRepository {
+	return &categoryRepository{
+		categories: map[string]*transaction.Category{
+			"CATEGORY1": {ID: "CATEGORY1", Name: "Electronics"},
+			"CATEGORY2": {ID: "CATEGORY2", Name: "Smartphones", ParentID: "CATEGORY1"},
+			"CATEGORY3": {ID: "CATEGORY3", Name: "Groceries"},
+		},
+	}
+}
+
+func (r *categoryRepository) FindByID(ctx context.Context, id string) (*transaction.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if val, ok := r.categories[id]; ok {
+		c := *val
+		return &c, nil
+	}
+	return nil, transaction.ErrCategoryNotFound
+}
+
+func (r *categoryRepository) FindAll(ctx context.Context) (transaction.Categories, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	categories := make(transaction.Categories, 0, len(r.categories))
+	for _, c := range r.categories {
+		categories = append(categories, *c)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories, nil
+}
+
+func (r *categoryRepository) Store(ctx context.Context, category *transaction.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	category.ID = uuid.NewString()
+	c := *category
+	r.categories[c.ID] = &c
+	return nil
+}
+
+func (r *categoryRepository) Update(ctx context.Context, category *transaction.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	val, ok := r.categories[category.ID]
+	if !ok {
+		return transaction.ErrCategoryNotFound
+	}
+	if val.Version != category.Version {
+		return transaction.ErrConcurrentModification
+	}
+	category.Version++
+	c := *category
+	r.categories[c.ID] = &c
+	return nil
+}
+
+func (r *categoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.categories[id]; !ok {
+		return transaction.ErrCategoryNotFound
+	}
+	delete(r.categories, id)
+	return nil
+}
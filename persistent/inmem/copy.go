@@ -0,0 +1,55 @@
+package inmem
+
+import "github.com/muktihari/order-transaction-ddd/transaction"
+
+func copyOrder(o *transaction.Order) *transaction.Order {
+	c := *o
+	c.Cart = make([]transaction.CartItem, len(o.Cart))
+	for i, cartItem := range o.Cart {
+		c.Cart[i] = cartItem
+		if cartItem.Components != nil {
+			c.Cart[i].Components = append([]transaction.Component(nil), cartItem.Components...)
+		}
+		if cartItem.Product != nil {
+			c.Cart[i].Product = copyProduct(cartItem.Product)
+		}
+	}
+	return &c
+}
+
+func copyProduct(p *transaction.Product) *transaction.Product {
+	c := *p
+	if p.AvailableAt != nil {
+		availableAt := *p.AvailableAt
+		c.AvailableAt = &availableAt
+	}
+	if p.Tags != nil {
+		c.Tags = append([]string(nil), p.Tags...)
+	}
+	if p.Components != nil {
+		c.Components = append([]transaction.Component(nil), p.Components...)
+	}
+	if p.Variants != nil {
+		c.Variants = make([]transaction.Variant, len(p.Variants))
+		for i, v := range p.Variants {
+			c.Variants[i] = v
+			if v.Price != nil {
+				price := *v.Price
+				c.Variants[i].Price = &price
+			}
+		}
+	}
+	return &c
+}
+
+func copyCoupon(coupon *transaction.Coupon) *transaction.Coupon {
+	c := *coupon
+	return &c
+}
+
+func copyIdempotencyRecord(r *transaction.IdempotencyRecord) *transaction.IdempotencyRecord {
+	c := *r
+	c.Fingerprint = append([]byte(nil), r.Fingerprint...)
+	c.Body = append([]byte(nil), r.Body...)
+	return &c
+}
@@ -42,14 +42,22 @@ func (r *couponRepository) FindByCode(ctx context.Context, code string) (*transa
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	if val, ok := r.coupons[code]; ok {
-		return val, nil
+		return copyCoupon(val), nil
 	}
 	return nil, transaction.ErrCouponNotFound
 }
 
 func (r *couponRepository) Update(ctx context.Context, coupon *transaction.Coupon) error {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	r.coupons[coupon.Code] = coupon
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	val, ok := r.coupons[coupon.Code]
+	if !ok {
+		return transaction.ErrCouponNotFound
+	}
+	if val.Version != coupon.Version {
+		return transaction.ErrConcurrentModification
+	}
+	coupon.Version++
+	r.coupons[coupon.Code] = copyCoupon(coupon)
 	return nil
 }
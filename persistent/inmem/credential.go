@@ -0,0 +1,40 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type credentialRepository struct {
+	mu          sync.RWMutex
+	credentials map[string]*transaction.Credential
+}
+
+// NewCredentialRepository creates new credential repository in memory, the predefined customer's password is "password"
+func NewCredentialRepository() transaction.CredentialRepository {
+	return &credentialRepository{
+		credentials: map[string]*transaction.Credential{
+			"CUSTOMER1": {CustomerID: "CUSTOMER1", PasswordHash: []byte("$2a$10$3InHY0ExvLl3N7jui8UmWei4K3thIemvgLFCjMKsWjLmXmTv0Cr3K")},
+		},
+	}
+}
+
+func (r *credentialRepository) FindByCustomerID(ctx context.Context, customerID string) (*transaction.Credential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if val, ok := r.credentials[customerID]; ok {
+		c := *val
+		return &c, nil
+	}
+	return nil, transaction.ErrCredentialNotFound
+}
+
+func (r *credentialRepository) Store(ctx context.Context, credential *transaction.Credential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := *credential
+	r.credentials[c.CustomerID] = &c
+	return nil
+}
@@ -0,0 +1,75 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type idempotencyKey struct {
+	client, key string
+}
+
+type idempotencyRepository struct {
+	mu      sync.Mutex
+	records map[idempotencyKey]*transaction.IdempotencyRecord
+	pruned  time.Time
+	now     func() time.Time
+}
+
+// NewIdempotencyRepository creates new idempotency repository in memory, expired records are pruned as new ones are reserved
+func NewIdempotencyRepository() transaction.IdempotencyRepository {
+	return &idempotencyRepository{
+		records: make(map[idempotencyKey]*transaction.IdempotencyRecord),
+		now:     time.Now,
+	}
+}
+
+func (r *idempotencyRepository) Find(ctx context.Context, client, key string) (*transaction.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if val, ok := r.records[idempotencyKey{client, key}]; ok && !val.Expired(r.now()) {
+		return copyIdempotencyRecord(val), nil
+	}
+	return nil, transaction.ErrIdempotencyKeyNotFound
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, record *transaction.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if now.Sub(r.pruned) > time.Minute {
+		for k, val := range r.records {
+			if val.Expired(now) {
+				delete(r.records, k)
+			}
+		}
+		r.pruned = now
+	}
+	k := idempotencyKey{record.Client, record.Key}
+	if val, ok := r.records[k]; ok && !val.Expired(now) {
+		return transaction.ErrIdempotencyKeyExists
+	}
+	r.records[k] = copyIdempotencyRecord(record)
+	return nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, record *transaction.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := idempotencyKey{record.Client, record.Key}
+	if val, ok := r.records[k]; !ok || val.Expired(r.now()) {
+		return transaction.ErrIdempotencyKeyNotFound
+	}
+	r.records[k] = copyIdempotencyRecord(record)
+	return nil
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, client, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, idempotencyKey{client, key})
+	return nil
+}
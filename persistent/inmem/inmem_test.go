@@ -0,0 +1,52 @@
+package inmem
+
+import (
+	"testing"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/muktihari/order-transaction-ddd/transaction/repotest"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, seed repotest.Seed) repotest.Repositories {
+		var (
+			customers   = &customerRepository{customers: make(map[string]*transaction.Customer)}
+			credentials = &credentialRepository{credentials: make(map[string]*transaction.Credential)}
+			admins      = &adminRepository{admins: make(map[string]*transaction.Admin)}
+			products    = &productRepository{products: make(map[string]*transaction.Product)}
+			categories  = &categoryRepository{categories: make(map[string]*transaction.Category)}
+			coupons     = &couponRepository{coupons: make(map[string]*transaction.Coupon)}
+			orders      = &orderRepository{orders: make(map[string]*transaction.Order)}
+			inventory   = &inventoryRepository{movements: seed.Movements}
+		)
+		for i := range seed.Customers {
+			customers.customers[seed.Customers[i].ID] = &seed.Customers[i]
+		}
+		for i := range seed.Admins {
+			admins.admins[seed.Admins[i].ID] = &seed.Admins[i]
+		}
+		for i := range seed.Products {
+			products.products[seed.Products[i].ID] = &seed.Products[i]
+		}
+		for i := range seed.Categories {
+			categories.categories[seed.Categories[i].ID] = &seed.Categories[i]
+		}
+		for i := range seed.Coupons {
+			coupons.coupons[seed.Coupons[i].Code] = &seed.Coupons[i]
+		}
+
+		return repotest.Repositories{
+			Orders:      orders,
+			Products:    products,
+			Categories:  categories,
+			Coupons:     coupons,
+			Customers:   customers,
+			Credentials: credentials,
+			Admins:      admins,
+			APIKeys:     NewAPIKeyRepository(),
+			Inventory:   inventory,
+			UnitOfWork:  NewUnitOfWork(orders, products, coupons, inventory),
+			Idempotency: NewIdempotencyRepository(),
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type inventoryRepository struct {
+	mu        sync.RWMutex
+	movements []transaction.InventoryMovement
+}
+
+// NewInventoryRepository creates new inventory repository in memory holding the opening balances
+// of the products of NewProductRepository
+func NewInventoryRepository() transaction.InventoryRepository {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return &inventoryRepository{
+		movements: []transaction.InventoryMovement{
+			{ID: "MOVEMENT1", ProductID: "PRODUCT1", Quantity: 200, Reason: transaction.MovementReasonOpeningBalance, Actor: transaction.ActorSystem, CreatedAt: now},
+			{ID: "MOVEMENT2", ProductID: "PRODUCT2", Quantity: 2000, Reason: transaction.MovementReasonOpeningBalance, Actor: transaction.ActorSystem, CreatedAt: now},
+		},
+	}
+}
+
+func (r *inventoryRepository) Store(ctx context.Context, movement *transaction.InventoryMovement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	movement.ID = uuid.NewString()
+	r.movements = append(r.movements, *movement)
+	return nil
+}
+
+func (r *inventoryRepository) FindByProductID(ctx context.Context, productID string) ([]transaction.InventoryMovement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return findMovements(r.movements, productID), nil
+}
+
+func findMovements(movements []transaction.InventoryMovement, productID string) []transaction.InventoryMovement {
+	found := make([]transaction.InventoryMovement, 0)
+	for _, m := range movements {
+		if m.ProductID == productID {
+			found = append(found, m)
+		}
+	}
+	return found
+}
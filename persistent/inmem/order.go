@@ -2,6 +2,7 @@ package inmem
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,14 +12,12 @@ import (
 )
 
 type orderRepository struct {
-	mu       sync.RWMutex
-	orders   map[string]*transaction.Order
-	coupons  transaction.CouponRepository
-	products transaction.ProductRepository
+	mu     sync.RWMutex
+	orders map[string]*transaction.Order
 }
 
 // NewOrderRepository creates new order repository in memory
-func NewOrderRepository(coupons transaction.CouponRepository, products transaction.ProductRepository) transaction.OrderRepository {
+func NewOrderRepository() transaction.OrderRepository {
 	return &orderRepository{
 		orders: map[string]*transaction.Order{
 			"ORDER_OPEN": {
@@ -26,8 +25,9 @@ func NewOrderRepository(coupons transaction.CouponRepository, products transacti
 				Customer: transaction.Customer{
 					ID: "CUSTOMER1", Name: "Hari", PhoneNumber: "+62-12345", Email: "example@email.com", Address: "No, Street, City, Indonesia",
 				},
-				Cart:   []transaction.CartItem{},
-				Status: transaction.OrderStatusOpen,
+				Cart:      []transaction.CartItem{},
+				Status:    transaction.OrderStatusOpen,
+				CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
 			},
 			"ORDER_WITH_PRODUCT": {
 				ID: "ORDER_WITH_PRODUCT",
@@ -40,7 +40,8 @@ func NewOrderRepository(coupons transaction.CouponRepository, products transacti
 						Quantity: 5,
 					},
 				},
-				Status: transaction.OrderStatusOpen,
+				Status:    transaction.OrderStatusOpen,
+				CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
 			},
 			"ORDER_WITH_PRODUCT_AND_COUPON": {
 				ID: "ORDER_WITH_PRODUCT_AND_COUPON",
@@ -64,10 +65,9 @@ func NewOrderRepository(coupons transaction.CouponRepository, products transacti
 				Price:               decimal.NewFromInt(500 * 5),
 				PriceAfterReduction: decimal.NewFromInt(500 * 5).Sub(decimal.NewFromInt(500 * 5).Mul(decimal.NewFromFloat(0.2))),
 				Status:              transaction.OrderStatusOpen,
+				CreatedAt:           time.Now().UTC().Truncate(time.Millisecond),
 			},
 		},
-		coupons:  coupons,
-		products: products,
 	}
 }
 
@@ -75,85 +75,65 @@ func (r *orderRepository) FindByID(ctx context.Context, id string) (*transaction
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	if val, ok := r.orders[id]; ok {
-		return val, nil
+		return copyOrder(val), nil
 	}
 	return nil, transaction.ErrOrderNotFound
 }
 
+func (r *orderRepository) Find(ctx context.Context, filter transaction.OrderFilter, page transaction.Page) (*transaction.OrderPage, error) {
+	cursor, err := transaction.DecodeOrderCursor(page.Cursor, filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	orders := make([]transaction.Order, 0)
+	for _, o := range r.orders {
+		if filter.Match(o) && (cursor == nil || cursor.After(o)) {
+			orders = append(orders, *copyOrder(o))
+		}
+	}
+	r.mu.RUnlock()
+
+	return newOrderPage(orders, filter, page), nil
+}
+
 func (r *orderRepository) Store(ctx context.Context, order *transaction.Order) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	order.ID = uuid.NewString()
-	r.orders[order.ID] = order
+	r.orders[order.ID] = copyOrder(order)
 	return nil
 }
 
 func (r *orderRepository) Update(ctx context.Context, order *transaction.Order) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.orders[order.ID] = order
-	return nil
-}
-
-func (r *orderRepository) FinalizeAndReserveProducts(ctx context.Context, order *transaction.Order) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	// assume it's transactional
-	coupon, err := r.coupons.FindByCode(ctx, order.Coupon.Code)
-	if err != nil {
-		return err
-	}
-	coupon.Quantity--
-	if err := r.coupons.Update(ctx, coupon); err != nil {
+	if err := r.checkVersion(order); err != nil {
 		return err
 	}
-
-	for _, cartItem := range order.Cart {
-		p, err := r.products.FindByID(ctx, cartItem.Product.ID)
-		if err != nil {
-			return err
-		}
-		if err := p.TryReserveQuantity(cartItem.Quantity); err != nil {
-			return err
-		}
-		p.ReserveQuantity(cartItem.Quantity)
-		if err := r.products.Update(ctx, p); err != nil {
-			return err
-		}
-
-	}
-
-	r.orders[order.ID] = order
-
+	order.Version++
+	r.orders[order.ID] = copyOrder(order)
 	return nil
 }
 
-func (r *orderRepository) CancelAndReleaseProducts(ctx context.Context, order *transaction.Order) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	// assume it's transactional
-	coupon, err := r.coupons.FindByCode(ctx, order.Coupon.Code)
-	if err != nil {
-		return err
+// checkVersion checks that the stored order has not been changed since the given order was loaded.
+func (r *orderRepository) checkVersion(order *transaction.Order) error {
+	val, ok := r.orders[order.ID]
+	if !ok {
+		return transaction.ErrOrderNotFound
 	}
-	coupon.Quantity++
-	if err := r.coupons.Update(ctx, coupon); err != nil {
-		return err
+	if val.Version != order.Version {
+		return transaction.ErrConcurrentModification
 	}
+	return nil
+}
 
-	for _, cartItem := range order.Cart {
-		p, err := r.products.FindByID(ctx, cartItem.Product.ID)
-		if err != nil {
-			return err
-		}
-		p.RollbackQuantity(cartItem.Quantity)
-		if err := r.products.Update(ctx, p); err != nil {
-			return err
-		}
+// newOrderPage sorts matching orders and cuts them to the page
+func newOrderPage(orders []transaction.Order, filter transaction.OrderFilter, page transaction.Page) *transaction.OrderPage {
+	sort.Slice(orders, func(i, j int) bool { return filter.Less(&orders[i], &orders[j]) })
+	if len(orders) > page.Size()+1 {
+		orders = orders[:page.Size()+1]
 	}
-	r.orders[order.ID] = order
-
-	return nil
+	return transaction.NewOrderPage(orders, filter, page)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/google/uuid"
 	"github.com/muktihari/order-transaction-ddd/transaction"
 	"github.com/shopspring/decimal"
 )
@@ -27,7 +28,7 @@ func (r *productRepository) FindByID(ctx context.Context, id string) (*transacti
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	if val, ok := r.products[id]; ok {
-		return val, nil
+		return copyProduct(val), nil
 	}
 	return nil, transaction.ErrProductNotFound
 }
@@ -37,14 +38,30 @@ func (r *productRepository) FindAll(ctx context.Context) ([]transaction.Product,
 	defer r.mu.RUnlock()
 	products := make([]transaction.Product, 0)
 	for _, p := range r.products {
-		products = append(products, *p)
+		products = append(products, *copyProduct(p))
 	}
 	return products, nil
 }
 
+func (r *productRepository) Store(ctx context.Context, product *transaction.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product.ID = uuid.NewString()
+	r.products[product.ID] = copyProduct(product)
+	return nil
+}
+
 func (r *productRepository) Update(ctx context.Context, product *transaction.Product) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.products[product.ID] = product
+	val, ok := r.products[product.ID]
+	if !ok {
+		return transaction.ErrProductNotFound
+	}
+	if val.Version != product.Version {
+		return transaction.ErrConcurrentModification
+	}
+	product.Version++
+	r.products[product.ID] = copyProduct(product)
 	return nil
 }
@@ -0,0 +1,240 @@
+package inmem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// snapshot is the content of a snapshot file
+type snapshot struct {
+	Customers   []transaction.Customer          `json:"customers"`
+	Credentials []transaction.Credential        `json:"credentials"`
+	Admins      []snapshotAdmin                 `json:"admins"`
+	APIKeys     []snapshotAPIKey                `json:"api_keys"`
+	Products    []transaction.Product           `json:"products"`
+	Categories  []transaction.Category          `json:"categories"`
+	Coupons     []transaction.Coupon            `json:"coupons"`
+	Orders      []transaction.Order             `json:"orders"`
+	Movements   []transaction.InventoryMovement `json:"movements"`
+}
+
+// snapshotAdmin is the admin with the hash of the password, which is not encoded to JSON otherwise
+type snapshotAdmin struct {
+	transaction.Admin
+	PasswordHash []byte `json:"password_hash"`
+}
+
+// snapshotAPIKey is the API key with its hash, which is not encoded to JSON otherwise
+type snapshotAPIKey struct {
+	transaction.APIKey
+	Hash []byte `json:"hash"`
+}
+
+// Snapshotter saves the state of inmem repositories to a JSON file and restores it
+type Snapshotter struct {
+	path        string
+	customers   *customerRepository
+	credentials *credentialRepository
+	admins      *adminRepository
+	apiKeys     *apiKeyRepository
+	products    *productRepository
+	categories  *categoryRepository
+	coupons     *couponRepository
+	orders      *orderRepository
+	inventory   *inventoryRepository
+}
+
+// NewSnapshotter creates new snapshotter of the given repositories writing to path,
+// the repositories must be created by this package.
+func NewSnapshotter(
+	path string,
+	customers transaction.CustomerRepository,
+	credentials transaction.CredentialRepository,
+	admins transaction.AdminRepository,
+	apiKeys transaction.APIKeyRepository,
+	products transaction.ProductRepository,
+	categories transaction.CategoryRepository,
+	coupons transaction.CouponRepository,
+	orders transaction.OrderRepository,
+	inventory transaction.InventoryRepository,
+) *Snapshotter {
+	return &Snapshotter{
+		path:        path,
+		customers:   customers.(*customerRepository),
+		credentials: credentials.(*credentialRepository),
+		admins:      admins.(*adminRepository),
+		apiKeys:     apiKeys.(*apiKeyRepository),
+		products:    products.(*productRepository),
+		categories:  categories.(*categoryRepository),
+		coupons:     coupons.(*couponRepository),
+		orders:      orders.(*orderRepository),
+		inventory:   inventory.(*inventoryRepository),
+	}
+}
+
+// Restore replaces the state of the repositories with the snapshot file,
+// the repositories are left untouched when the file does not exist yet.
+func (s *Snapshotter) Restore() error {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return err
+	}
+
+	s.lock()
+	defer s.unlock()
+
+	s.customers.customers = make(map[string]*transaction.Customer, len(snap.Customers))
+	for i := range snap.Customers {
+		s.customers.customers[snap.Customers[i].ID] = &snap.Customers[i]
+	}
+	s.credentials.credentials = make(map[string]*transaction.Credential, len(snap.Credentials))
+	for i := range snap.Credentials {
+		s.credentials.credentials[snap.Credentials[i].CustomerID] = &snap.Credentials[i]
+	}
+	s.admins.admins = make(map[string]*transaction.Admin, len(snap.Admins))
+	for i := range snap.Admins {
+		a := snap.Admins[i].Admin
+		a.PasswordHash = snap.Admins[i].PasswordHash
+		s.admins.admins[a.ID] = &a
+	}
+	s.apiKeys.keys = make(map[string]*transaction.APIKey, len(snap.APIKeys))
+	for i := range snap.APIKeys {
+		k := snap.APIKeys[i].APIKey
+		k.Hash = snap.APIKeys[i].Hash
+		s.apiKeys.keys[k.ID] = &k
+	}
+	s.products.products = make(map[string]*transaction.Product, len(snap.Products))
+	for i := range snap.Products {
+		s.products.products[snap.Products[i].ID] = &snap.Products[i]
+	}
+	s.categories.categories = make(map[string]*transaction.Category, len(snap.Categories))
+	for i := range snap.Categories {
+		s.categories.categories[snap.Categories[i].ID] = &snap.Categories[i]
+	}
+	s.coupons.coupons = make(map[string]*transaction.Coupon, len(snap.Coupons))
+	for i := range snap.Coupons {
+		s.coupons.coupons[snap.Coupons[i].Code] = &snap.Coupons[i]
+	}
+	s.orders.orders = make(map[string]*transaction.Order, len(snap.Orders))
+	for i := range snap.Orders {
+		s.orders.orders[snap.Orders[i].ID] = &snap.Orders[i]
+	}
+	s.inventory.movements = snap.Movements
+	return nil
+}
+
+// Save writes the state of the repositories to the snapshot file, the file is replaced atomically
+// so a crash while saving leaves the previous snapshot intact.
+func (s *Snapshotter) Save() error {
+	var snap snapshot
+
+	s.lock()
+	for _, c := range s.customers.customers {
+		snap.Customers = append(snap.Customers, *c)
+	}
+	for _, c := range s.credentials.credentials {
+		snap.Credentials = append(snap.Credentials, *c)
+	}
+	for _, a := range s.admins.admins {
+		snap.Admins = append(snap.Admins, snapshotAdmin{Admin: *a, PasswordHash: a.PasswordHash})
+	}
+	for _, k := range s.apiKeys.keys {
+		snap.APIKeys = append(snap.APIKeys, snapshotAPIKey{APIKey: *k, Hash: k.Hash})
+	}
+	for _, p := range s.products.products {
+		snap.Products = append(snap.Products, *p)
+	}
+	for _, c := range s.categories.categories {
+		snap.Categories = append(snap.Categories, *c)
+	}
+	for _, c := range s.coupons.coupons {
+		snap.Coupons = append(snap.Coupons, *c)
+	}
+	for _, o := range s.orders.orders {
+		snap.Orders = append(snap.Orders, *copyOrder(o))
+	}
+	snap.Movements = append(snap.Movements, s.inventory.movements...)
+	s.unlock()
+
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+// Run saves a snapshot every interval until ctx is done, then it saves the final snapshot.
+// Failures of periodic snapshots are passed to onError, the failure of the final snapshot is returned.
+func (s *Snapshotter) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return s.Save()
+		case <-ticker.C:
+			if err := s.Save(); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// lock locks every repository in the same order as unit of work commit does
+func (s *Snapshotter) lock() {
+	s.orders.mu.Lock()
+	s.products.mu.Lock()
+	s.coupons.mu.Lock()
+	s.inventory.mu.Lock()
+	s.customers.mu.Lock()
+	s.credentials.mu.Lock()
+	s.admins.mu.Lock()
+	s.apiKeys.mu.Lock()
+	s.categories.mu.Lock()
+}
+
+func (s *Snapshotter) unlock() {
+	s.categories.mu.Unlock()
+	s.apiKeys.mu.Unlock()
+	s.admins.mu.Unlock()
+	s.credentials.mu.Unlock()
+	s.customers.mu.Unlock()
+	s.inventory.mu.Unlock()
+	s.coupons.mu.Unlock()
+	s.products.mu.Unlock()
+	s.orders.mu.Unlock()
+}
@@ -0,0 +1,146 @@
+package inmem
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+func TestSnapshotter(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	var (
+		customers   = NewCustomerRepository()
+		credentials = NewCredentialRepository()
+		admins      = NewAdminRepository()
+		apiKeys     = NewAPIKeyRepository()
+		products    = NewProductRepository()
+		categories  = NewCategoryRepository()
+		coupons     = NewCouponRepository()
+		orders      = NewOrderRepository()
+		inventory   = NewInventoryRepository()
+	)
+	s := NewSnapshotter(path, customers, credentials, admins, apiKeys, products, categories, coupons, orders, inventory)
+
+	// restoring a missing snapshot keeps the predefined data
+	if err := s.Restore(); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	p, err := products.FindByID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	c, err := customers.FindByID(ctx, "CUSTOMER1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	o := transaction.NewOrder(c)
+	if err := orders.Store(ctx, o); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := o.AddProduct(p, "", 5); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := orders.Update(ctx, o); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	p.ReserveQuantity("", 5)
+	if err := p.Categorize("CATEGORY2", []string{"Android"}); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := products.Update(ctx, p); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	m := transaction.NewInventoryMovement(p.ID, "", -5, transaction.MovementReasonOrderReservation, transaction.CustomerActor(c.ID))
+	if err := inventory.Store(ctx, m); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	credential := &transaction.Credential{CustomerID: c.ID, PasswordHash: []byte("hash")}
+	if err := credentials.Store(ctx, credential); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	admin := &transaction.Admin{Name: "Kiki", Email: "kiki@email.com", Role: transaction.RoleFinance, PasswordHash: []byte("admin hash")}
+	if err := admins.Store(ctx, admin); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	key := &transaction.APIKey{Name: "Warehouse", Scopes: []transaction.Permission{transaction.PermissionShipOrders}, Hash: []byte("key hash")}
+	if err := apiKeys.Store(ctx, key); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	if err := s.Save(); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	var (
+		restoredCredentials = &credentialRepository{credentials: make(map[string]*transaction.Credential)}
+		restoredAdmins      = &adminRepository{admins: make(map[string]*transaction.Admin)}
+		restoredAPIKeys     = NewAPIKeyRepository()
+		restoredProducts    = NewProductRepository()
+		restoredCategories  = NewCategoryRepository()
+		restoredOrders      = NewOrderRepository()
+		restoredInventory   = NewInventoryRepository()
+	)
+	restored := NewSnapshotter(path, NewCustomerRepository(), restoredCredentials, restoredAdmins, restoredAPIKeys, restoredProducts, restoredCategories, NewCouponRepository(), restoredOrders, restoredInventory)
+	if err := restored.Restore(); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	rc, err := restoredCredentials.FindByCustomerID(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if string(rc.PasswordHash) != "hash" {
+		t.Errorf("got password hash %q, expected %q", rc.PasswordHash, "hash")
+	}
+
+	ra, err := restoredAdmins.FindByEmail(ctx, "kiki@email.com")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if ra.ID != admin.ID || ra.Role != transaction.RoleFinance || string(ra.PasswordHash) != "admin hash" {
+		t.Errorf("got admin %+v, expected %+v", ra, admin)
+	}
+
+	rk, err := restoredAPIKeys.FindByHash(ctx, []byte("key hash"))
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if rk.ID != key.ID || !rk.Can(transaction.PermissionShipOrders) {
+		t.Errorf("got api key %+v, expected %+v", rk, key)
+	}
+
+	rp, err := restoredProducts.FindByID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if rp.Quantity != p.Quantity || rp.Version != p.Version || !rp.Price.Equal(p.Price) ||
+		rp.CategoryID != "CATEGORY2" || !rp.HasTag("android") {
+		t.Errorf("got product %+v, expected %+v", rp, p)
+	}
+	movements, err := restoredInventory.FindByProductID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if stock := transaction.Stock(movements); stock != rp.Quantity {
+		t.Errorf("got ledger stock %d, expected %d", stock, rp.Quantity)
+	}
+
+	ro, err := restoredOrders.FindByID(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if ro.Version != o.Version || ro.Customer != o.Customer || !ro.Price.Equal(o.Price) {
+		t.Errorf("got order %+v, expected %+v", ro, o)
+	}
+	if len(ro.Cart) != 1 || ro.Cart[0].Product.ID != "PRODUCT1" || ro.Cart[0].Quantity != 5 {
+		t.Errorf("got cart %+v, expected 5 of PRODUCT1", ro.Cart)
+	}
+}
@@ -0,0 +1,263 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type unitOfWork struct {
+	mu        sync.Mutex
+	orders    *orderRepository
+	products  *productRepository
+	coupons   *couponRepository
+	inventory *inventoryRepository
+}
+
+// NewUnitOfWork creates new unit of work in memory spanning the given repositories,
+// the repositories must be created by this package.
+func NewUnitOfWork(
+	orders transaction.OrderRepository,
+	products transaction.ProductRepository,
+	coupons transaction.CouponRepository,
+	inventory transaction.InventoryRepository,
+) transaction.UnitOfWork {
+	return &unitOfWork{
+		orders:    orders.(*orderRepository),
+		products:  products.(*productRepository),
+		coupons:   coupons.(*couponRepository),
+		inventory: inventory.(*inventoryRepository),
+	}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, r transaction.Repositories) error) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	t := &tx{
+		u:               u,
+		orders:          make(map[string]*transaction.Order),
+		orderVersions:   make(map[string]int64),
+		products:        make(map[string]*transaction.Product),
+		productVersions: make(map[string]int64),
+		coupons:         make(map[string]*transaction.Coupon),
+		couponVersions:  make(map[string]int64),
+	}
+	if err := fn(ctx, t); err != nil {
+		return err
+	}
+	return t.commit()
+}
+
+// tx buffers changes made within a unit of work, they are applied to the repositories on commit.
+// The versions maps hold the version of aggregates when they were first changed within the unit of work.
+// Movements are appended to the ledger on commit.
+type tx struct {
+	u               *unitOfWork
+	orders          map[string]*transaction.Order
+	orderVersions   map[string]int64
+	products        map[string]*transaction.Product
+	productVersions map[string]int64
+	coupons         map[string]*transaction.Coupon
+	couponVersions  map[string]int64
+	movements       []transaction.InventoryMovement
+}
+
+func (t *tx) Orders() transaction.OrderRepository        { return &txOrderRepository{t} }
+func (t *tx) Products() transaction.ProductRepository    { return &txProductRepository{t} }
+func (t *tx) Coupons() transaction.CouponRepository      { return &txCouponRepository{t} }
+func (t *tx) Inventory() transaction.InventoryRepository { return &txInventoryRepository{t} }
+
+func (t *tx) commit() error {
+	t.u.orders.mu.Lock()
+	defer t.u.orders.mu.Unlock()
+	t.u.products.mu.Lock()
+	defer t.u.products.mu.Unlock()
+	t.u.coupons.mu.Lock()
+	defer t.u.coupons.mu.Unlock()
+	t.u.inventory.mu.Lock()
+	defer t.u.inventory.mu.Unlock()
+
+	for id, version := range t.orderVersions {
+		if val, ok := t.u.orders.orders[id]; !ok || val.Version != version {
+			return transaction.ErrConcurrentModification
+		}
+	}
+	for id, version := range t.productVersions {
+		if val, ok := t.u.products.products[id]; !ok || val.Version != version {
+			return transaction.ErrConcurrentModification
+		}
+	}
+	for code, version := range t.couponVersions {
+		if val, ok := t.u.coupons.coupons[code]; !ok || val.Version != version {
+			return transaction.ErrConcurrentModification
+		}
+	}
+
+	for id, val := range t.orders {
+		t.u.orders.orders[id] = val
+	}
+	for id, val := range t.products {
+		t.u.products.products[id] = val
+	}
+	for code, val := range t.coupons {
+		t.u.coupons.coupons[code] = val
+	}
+	t.u.inventory.movements = append(t.u.inventory.movements, t.movements...)
+
+	return nil
+}
+
+type txOrderRepository struct{ *tx }
+
+func (r *txOrderRepository) FindByID(ctx context.Context, id string) (*transaction.Order, error) {
+	if val, ok := r.orders[id]; ok {
+		return copyOrder(val), nil
+	}
+	return r.u.orders.FindByID(ctx, id)
+}
+
+// Find lists committed orders overlaid with orders changed within the unit of work
+func (r *txOrderRepository) Find(ctx context.Context, filter transaction.OrderFilter, page transaction.Page) (*transaction.OrderPage, error) {
+	cursor, err := transaction.DecodeOrderCursor(page.Cursor, filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	r.u.orders.mu.RLock()
+	orders := make([]transaction.Order, 0)
+	for id, o := range r.u.orders.orders {
+		if val, ok := r.orders[id]; ok {
+			o = val
+		}
+		if filter.Match(o) && (cursor == nil || cursor.After(o)) {
+			orders = append(orders, *copyOrder(o))
+		}
+	}
+	r.u.orders.mu.RUnlock()
+	for id, o := range r.orders {
+		if _, ok := r.orderVersions[id]; ok {
+			continue // changed rather than stored, already listed
+		}
+		if filter.Match(o) && (cursor == nil || cursor.After(o)) {
+			orders = append(orders, *copyOrder(o))
+		}
+	}
+
+	return newOrderPage(orders, filter, page), nil
+}
+
+func (r *txOrderRepository) Store(ctx context.Context, order *transaction.Order) error {
+	order.ID = uuid.NewString()
+	r.orders[order.ID] = copyOrder(order)
+	return nil
+}
+
+func (r *txOrderRepository) Update(ctx context.Context, order *transaction.Order) error {
+	val, err := r.FindByID(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	if val.Version != order.Version {
+		return transaction.ErrConcurrentModification
+	}
+	if _, ok := r.orders[order.ID]; !ok {
+		r.orderVersions[order.ID] = val.Version
+	}
+	order.Version++
+	r.orders[order.ID] = copyOrder(order)
+	return nil
+}
+
+type txProductRepository struct{ *tx }
+
+func (r *txProductRepository) FindByID(ctx context.Context, id string) (*transaction.Product, error) {
+	if val, ok := r.products[id]; ok {
+		return copyProduct(val), nil
+	}
+	return r.u.products.FindByID(ctx, id)
+}
+
+func (r *txProductRepository) FindAll(ctx context.Context) ([]transaction.Product, error) {
+	products, err := r.u.products.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		if val, ok := r.products[products[i].ID]; ok {
+			products[i] = *copyProduct(val)
+		}
+	}
+	for id, p := range r.products {
+		if _, ok := r.productVersions[id]; ok {
+			continue // changed rather than stored, already listed
+		}
+		products = append(products, *copyProduct(p))
+	}
+	return products, nil
+}
+
+func (r *txProductRepository) Store(ctx context.Context, product *transaction.Product) error {
+	product.ID = uuid.NewString()
+	r.products[product.ID] = copyProduct(product)
+	return nil
+}
+
+func (r *txProductRepository) Update(ctx context.Context, product *transaction.Product) error {
+	val, err := r.FindByID(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	if val.Version != product.Version {
+		return transaction.ErrConcurrentModification
+	}
+	if _, ok := r.products[product.ID]; !ok {
+		r.productVersions[product.ID] = val.Version
+	}
+	product.Version++
+	r.products[product.ID] = copyProduct(product)
+	return nil
+}
+
+type txCouponRepository struct{ *tx }
+
+func (r *txCouponRepository) FindByCode(ctx context.Context, code string) (*transaction.Coupon, error) {
+	if val, ok := r.coupons[code]; ok {
+		return copyCoupon(val), nil
+	}
+	return r.u.coupons.FindByCode(ctx, code)
+}
+
+func (r *txCouponRepository) Update(ctx context.Context, coupon *transaction.Coupon) error {
+	val, err := r.FindByCode(ctx, coupon.Code)
+	if err != nil {
+		return err
+	}
+	if val.Version != coupon.Version {
+		return transaction.ErrConcurrentModification
+	}
+	if _, ok := r.coupons[coupon.Code]; !ok {
+		r.couponVersions[coupon.Code] = val.Version
+	}
+	coupon.Version++
+	r.coupons[coupon.Code] = copyCoupon(coupon)
+	return nil
+}
+
+type txInventoryRepository struct{ *tx }
+
+func (r *txInventoryRepository) Store(ctx context.Context, movement *transaction.InventoryMovement) error {
+	movement.ID = uuid.NewString()
+	r.movements = append(r.movements, *movement)
+	return nil
+}
+
+func (r *txInventoryRepository) FindByProductID(ctx context.Context, productID string) ([]transaction.InventoryMovement, error) {
+	movements, err := r.u.inventory.FindByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return append(movements, findMovements(r.movements, productID)...), nil
+}
@@ -3,9 +3,12 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/muktihari/order-transaction-ddd/transaction"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -19,8 +22,38 @@ func NewCustomerRepository(db *mongo.Database) transaction.CustomerRepository {
 	return &customerRepository{db, db.Collection("customers")}
 }
 
+var customerSchema = Schema{
+	Collection: "customers",
+	Indexes: []Index{
+		{Name: "email_unique", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+		{Name: "phone_number_unique", Keys: bson.D{{Key: "phone_number", Value: 1}}, Unique: true},
+	},
+	Validator: bson.M{
+		"bsonType": "object",
+		"required": bson.A{"_id", "name"},
+		"properties": bson.M{
+			"_id":            bson.M{"bsonType": "string"},
+			"name":           bson.M{"bsonType": "string"},
+			"phone_number":   bson.M{"bsonType": "string"},
+			"email":          bson.M{"bsonType": "string"},
+			"address":        bson.M{"bsonType": "string"},
+			"email_verified": bson.M{"bsonType": "bool"},
+			"phone_verified": bson.M{"bsonType": "bool"},
+			"version":        bson.M{"bsonType": bson.A{"int", "long"}},
+		},
+	},
+}
+
 func (r *customerRepository) FindByID(ctx context.Context, id string) (*transaction.Customer, error) {
-	sr := r.collection.FindOne(ctx, bson.M{"_id": id})
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *customerRepository) FindByEmail(ctx context.Context, email string) (*transaction.Customer, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *customerRepository) findOne(ctx context.Context, filter bson.M) (*transaction.Customer, error) {
+	sr := r.collection.FindOne(ctx, filter)
 	if err := sr.Err(); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, transaction.ErrCustomerNotFound
@@ -36,6 +69,55 @@ func (r *customerRepository) FindByID(ctx context.Context, id string) (*transact
 	return &customer, nil
 }
 
+func (r *customerRepository) Store(ctx context.Context, customer *transaction.Customer) error {
+	customer.ID = primitive.NewObjectID().Hex()
+	if _, err := r.collection.InsertOne(ctx, customer); err != nil {
+		customer.ID = ""
+		return customerConflict(err)
+	}
+	return nil
+}
+
+func (r *customerRepository) Update(ctx context.Context, customer *transaction.Customer) error {
+	version := customer.Version
+	customer.Version++
+	if err := replaceVersioned(ctx, r.collection, bson.M{"_id": customer.ID}, version, customer, transaction.ErrCustomerNotFound); err != nil {
+		customer.Version = version
+		return customerConflict(err)
+	}
+
+	return nil
+}
+
+func (r *customerRepository) Delete(ctx context.Context, id string) error {
+	dr, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if dr.DeletedCount == 0 {
+		return fmt.Errorf("customer %s: %w", id, transaction.ErrCustomerNotFound)
+	}
+	return nil
+}
+
+// customerConflict tells which unique contact the error of writing a customer violates, other errors are returned as is
+func customerConflict(err error) error {
+	var we mongo.WriteException
+	if !errors.As(err, &we) {
+		return err
+	}
+	for _, e := range we.WriteErrors {
+		if e.Code != 11000 {
+			continue
+		}
+		if strings.Contains(e.Message, "phone_number_unique") {
+			return transaction.ErrPhoneNumberTaken
+		}
+		return transaction.ErrEmailTaken
+	}
+	return err
+}
+
 type adminRepository struct {
 	db         *mongo.Database
 	collection *mongo.Collection
@@ -43,11 +125,38 @@ type adminRepository struct {
 
 // NewAdminRepository creates new admin repository
 func NewAdminRepository(db *mongo.Database) transaction.AdminRepository {
-	return &adminRepository{db, db.Collection("customers")}
+	return &adminRepository{db, db.Collection("admins")}
+}
+
+var adminSchema = Schema{
+	Collection: "admins",
+	Indexes: []Index{
+		// admins existing before accounts have no email, they're left out of the index
+		{Name: "email_unique", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true, Sparse: true},
+	},
+	Validator: bson.M{
+		"bsonType": "object",
+		"required": bson.A{"_id", "name"},
+		"properties": bson.M{
+			"_id":           bson.M{"bsonType": "string"},
+			"name":          bson.M{"bsonType": "string"},
+			"email":         bson.M{"bsonType": "string"},
+			"role":          bson.M{"bsonType": "string"},
+			"password_hash": bson.M{"bsonType": "binData"},
+		},
+	},
 }
 
 func (r *adminRepository) FindByID(ctx context.Context, id string) (*transaction.Admin, error) {
-	sr := r.collection.FindOne(ctx, bson.M{"_id": id})
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *adminRepository) FindByEmail(ctx context.Context, email string) (*transaction.Admin, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *adminRepository) findOne(ctx context.Context, filter bson.M) (*transaction.Admin, error) {
+	sr := r.collection.FindOne(ctx, filter)
 	if err := sr.Err(); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, transaction.ErrAdminNotFound
@@ -62,3 +171,26 @@ func (r *adminRepository) FindByID(ctx context.Context, id string) (*transaction
 
 	return &admin, nil
 }
+
+func (r *adminRepository) Store(ctx context.Context, admin *transaction.Admin) error {
+	admin.ID = primitive.NewObjectID().Hex()
+	if _, err := r.collection.InsertOne(ctx, admin); err != nil {
+		admin.ID = ""
+		return adminConflict(err)
+	}
+	return nil
+}
+
+// adminConflict tells whether the error of writing an admin violates the unique email, other errors are returned as is
+func adminConflict(err error) error {
+	var we mongo.WriteException
+	if !errors.As(err, &we) {
+		return err
+	}
+	for _, e := range we.WriteErrors {
+		if e.Code == 11000 && strings.Contains(e.Message, "email_unique") {
+			return transaction.ErrAdminEmailTaken
+		}
+	}
+	return err
+}
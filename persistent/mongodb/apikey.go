@@ -0,0 +1,105 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type apiKeyRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewAPIKeyRepository creates new API key repository
+func NewAPIKeyRepository(db *mongo.Database) transaction.APIKeyRepository {
+	return &apiKeyRepository{db, db.Collection("api_keys")}
+}
+
+var apiKeySchema = Schema{
+	Collection: "api_keys",
+	Indexes: []Index{
+		{Name: "hash_unique", Keys: bson.D{{Key: "hash", Value: 1}}, Unique: true},
+	},
+	Validator: bson.M{
+		"bsonType": "object",
+		"required": bson.A{"_id", "name", "scopes", "hash", "created_by", "created_at", "expires_at"},
+		"properties": bson.M{
+			"_id":        bson.M{"bsonType": "string"},
+			"name":       bson.M{"bsonType": "string"},
+			"scopes":     bson.M{"bsonType": "array", "items": bson.M{"bsonType": "string"}},
+			"hash":       bson.M{"bsonType": "binData"},
+			"created_by": bson.M{"bsonType": "string"},
+			"created_at": bson.M{"bsonType": "date"},
+			"rotated_at": bson.M{"bsonType": bson.A{"date", "null"}},
+			"expires_at": bson.M{"bsonType": "date"},
+			"revoked_at": bson.M{"bsonType": bson.A{"date", "null"}},
+			"version":    bson.M{"bsonType": bson.A{"int", "long"}},
+		},
+	},
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, id string) (*transaction.APIKey, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, hash []byte) (*transaction.APIKey, error) {
+	return r.findOne(ctx, bson.M{"hash": hash})
+}
+
+func (r *apiKeyRepository) findOne(ctx context.Context, filter bson.M) (*transaction.APIKey, error) {
+	sr := r.collection.FindOne(ctx, filter)
+	if err := sr.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, transaction.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	var key transaction.APIKey
+	if err := sr.Decode(&key); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context) ([]transaction.APIKey, error) {
+	cur, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	keys := make([]transaction.APIKey, 0)
+	if err := cur.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Store(ctx context.Context, key *transaction.APIKey) error {
+	key.ID = primitive.NewObjectID().Hex()
+	if _, err := r.collection.InsertOne(ctx, key); err != nil {
+		key.ID = ""
+		return err
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) Update(ctx context.Context, key *transaction.APIKey) error {
+	version := key.Version
+	key.Version++
+	if err := replaceVersioned(ctx, r.collection, bson.M{"_id": key.ID}, version, key, transaction.ErrAPIKeyNotFound); err != nil {
+		key.Version = version
+		return err
+	}
+
+	return nil
+}
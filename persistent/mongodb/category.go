@@ -0,0 +1,104 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type categoryRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewCategoryRepository creates new category repository
+func NewCategoryRepository(db *mongo.Database) transaction.CategoryRepository {
+	return &categoryRepository{db, db.Collection("categories")}
+}
+
+var categorySchema = Schema{
+	Collection: "categories",
+	Indexes: []Index{
+		{Name: "parent_id", Keys: bson.D{{Key: "parent_id", Value: 1}}},
+	},
+	Validator: bson.M{
+		"bsonType": "object",
+		"required": bson.A{"_id", "name", "parent_id", "version"},
+		"properties": bson.M{
+			"_id":       bson.M{"bsonType": "string"},
+			"name":      bson.M{"bsonType": "string"},
+			"parent_id": bson.M{"bsonType": "string"},
+			"version":   bson.M{"bsonType": bson.A{"int", "long"}},
+		},
+	},
+}
+
+func (r *categoryRepository) FindByID(ctx context.Context, id string) (*transaction.Category, error) {
+	sr := r.collection.FindOne(ctx, bson.M{"_id": id})
+	if err := sr.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("category %s: %w", id, transaction.ErrCategoryNotFound)
+		}
+		return nil, err
+	}
+
+	var category transaction.Category
+	if err := sr.Decode(&category); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) FindAll(ctx context.Context) (transaction.Categories, error) {
+	cur, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	categories := make(transaction.Categories, 0)
+	if err := cur.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r *categoryRepository) Store(ctx context.Context, category *transaction.Category) error {
+	category.ID = primitive.NewObjectID().Hex()
+	ir, err := r.collection.InsertOne(ctx, category)
+	if err != nil {
+		return err
+	}
+	category.ID = fmt.Sprintf("%v", ir.InsertedID)
+
+	return nil
+}
+
+func (r *categoryRepository) Update(ctx context.Context, category *transaction.Category) error {
+	version := category.Version
+	category.Version++
+	if err := replaceVersioned(ctx, r.collection, bson.M{"_id": category.ID}, version, category, transaction.ErrCategoryNotFound); err != nil {
+		category.Version = version
+		return err
+	}
+
+	return nil
+}
+
+func (r *categoryRepository) Delete(ctx context.Context, id string) error {
+	dr, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if dr.DeletedCount == 0 {
+		return fmt.Errorf("category %s: %w", id, transaction.ErrCategoryNotFound)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/muktihari/order-transaction-ddd/transaction"
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,11 +20,31 @@ func NewCouponRepository(db *mongo.Database) transaction.CouponRepository {
 	return &couponRepository{db, db.Collection("coupons")}
 }
 
+var couponSchema = Schema{
+	Collection: "coupons",
+	Indexes: []Index{
+		{Name: "code_unique", Keys: bson.D{{Key: "code", Value: 1}}, Unique: true},
+	},
+	Validator: bson.M{
+		"bsonType": "object",
+		"required": bson.A{"code", "quantity", "amount", "begin", "end", "type", "version"},
+		"properties": bson.M{
+			"code":     bson.M{"bsonType": "string"},
+			"quantity": bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+			"amount":   bson.M{"bsonType": "decimal"},
+			"begin":    bson.M{"bsonType": "date"},
+			"end":      bson.M{"bsonType": "date"},
+			"type":     bson.M{"bsonType": bson.A{"int", "long"}},
+			"version":  bson.M{"bsonType": bson.A{"int", "long"}},
+		},
+	},
+}
+
 func (r *couponRepository) FindByCode(ctx context.Context, code string) (*transaction.Coupon, error) {
 	sr := r.collection.FindOne(ctx, bson.M{"code": code})
 	if err := sr.Err(); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, transaction.ErrCouponNotFound
+			return nil, fmt.Errorf("coupon %s: %w", code, transaction.ErrCouponNotFound)
 		}
 		return nil, err
 	}
@@ -37,11 +58,10 @@ func (r *couponRepository) FindByCode(ctx context.Context, code string) (*transa
 }
 
 func (r *couponRepository) Update(ctx context.Context, coupon *transaction.Coupon) error {
-	sr := r.collection.FindOneAndReplace(ctx, bson.M{"code": coupon.Code}, coupon)
-	if err := sr.Err(); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return transaction.ErrCouponNotFound
-		}
+	version := coupon.Version
+	coupon.Version++
+	if err := replaceVersioned(ctx, r.collection, bson.M{"code": coupon.Code}, version, coupon, transaction.ErrCouponNotFound); err != nil {
+		coupon.Version = version
 		return err
 	}
 
@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type credentialRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewCredentialRepository creates new credential repository
+func NewCredentialRepository(db *mongo.Database) transaction.CredentialRepository {
+	return &credentialRepository{db, db.Collection("credentials")}
+}
+
+var credentialSchema = Schema{
+	Collection: "credentials",
+	Validator: bson.M{
+		"bsonType": "object",
+		"required": bson.A{"_id", "password_hash"},
+		"properties": bson.M{
+			"_id":           bson.M{"bsonType": "string"},
+			"password_hash": bson.M{"bsonType": "binData"},
+		},
+	},
+}
+
+func (r *credentialRepository) FindByCustomerID(ctx context.Context, customerID string) (*transaction.Credential, error) {
+	sr := r.collection.FindOne(ctx, bson.M{"_id": customerID})
+	if err := sr.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fmt.Errorf("credential of customer %s: %w", customerID, transaction.ErrCredentialNotFound)
+		}
+		return nil, err
+	}
+
+	var credential transaction.Credential
+	if err := sr.Decode(&credential); err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+func (r *credentialRepository) Store(ctx context.Context, credential *transaction.Credential) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": credential.CustomerID}, credential, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,103 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type idempotencyRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+	now        func() time.Time
+}
+
+// NewIdempotencyRepository creates new idempotency repository, expired records are removed by a TTL index
+func NewIdempotencyRepository(db *mongo.Database) transaction.IdempotencyRepository {
+	return &idempotencyRepository{db, db.Collection("idempotency_keys"), time.Now}
+}
+
+var idempotencySchema = Schema{
+	Collection: "idempotency_keys",
+	Indexes: []Index{
+		{Name: "client_key_unique", Keys: bson.D{{Key: "client", Value: 1}, {Key: "key", Value: 1}}, Unique: true},
+		{Name: "created_at_ttl", Keys: bson.D{{Key: "created_at", Value: 1}}, ExpireAfterSeconds: int32(transaction.IdempotencyTTL / time.Second)},
+	},
+	Validator: bson.M{
+		"bsonType": "object",
+		"required": bson.A{"client", "key", "fingerprint", "status_code", "created_at"},
+		"properties": bson.M{
+			"client":       bson.M{"bsonType": "string"},
+			"key":          bson.M{"bsonType": "string"},
+			"fingerprint":  bson.M{"bsonType": "binData"},
+			"status_code":  bson.M{"bsonType": bson.A{"int", "long"}},
+			"content_type": bson.M{"bsonType": "string"},
+			"body":         bson.M{"bsonType": bson.A{"binData", "null"}},
+			"created_at":   bson.M{"bsonType": "date"},
+		},
+	},
+}
+
+// live filters the record of the client and key which hasn't expired yet,
+// the TTL monitor only removes expired documents once a minute
+func (r *idempotencyRepository) live(client, key string) bson.M {
+	return bson.M{"client": client, "key": key, "created_at": bson.M{"$gt": r.now().Add(-transaction.IdempotencyTTL)}}
+}
+
+func (r *idempotencyRepository) Find(ctx context.Context, client, key string) (*transaction.IdempotencyRecord, error) {
+	sr := r.collection.FindOne(ctx, r.live(client, key))
+	if err := sr.Err(); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, transaction.ErrIdempotencyKeyNotFound
+		}
+		return nil, err
+	}
+
+	var record transaction.IdempotencyRecord
+	if err := sr.Decode(&record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, record *transaction.IdempotencyRecord) error {
+	// an expired record not removed yet would otherwise hold the key
+	expired := bson.M{"client": record.Client, "key": record.Key, "created_at": bson.M{"$lte": r.now().Add(-transaction.IdempotencyTTL)}}
+	if _, err := r.collection.DeleteOne(ctx, expired); err != nil {
+		return err
+	}
+
+	if _, err := r.collection.InsertOne(ctx, record); err != nil {
+		var we mongo.WriteException
+		if errors.As(err, &we) {
+			for _, e := range we.WriteErrors {
+				if e.Code == 11000 {
+					return transaction.ErrIdempotencyKeyExists
+				}
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, record *transaction.IdempotencyRecord) error {
+	res, err := r.collection.ReplaceOne(ctx, r.live(record.Client, record.Key), record)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return transaction.ErrIdempotencyKeyNotFound
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, client, key string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"client": client, "key": key})
+	return err
+}
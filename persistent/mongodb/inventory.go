@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type inventoryRepository struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+// NewInventoryRepository creates new inventory repository
+func NewInventoryRepository(db *mongo.Database) transaction.InventoryRepository {
+	return &inventoryRepository{db, db.Collection("inventory_movements")}
+}
+
+var inventorySchema = Schema{
+	Collection: "inventory_movements",
+	Indexes: []Index{
+		// movements of a product are listed in the order they were stored, object ids grow within a millisecond
+		{Name: "product_created_at", Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+	},
+	Validator: bson.M{
+		"bsonType": "object",
+		"required": bson.A{"_id", "product_id", "quantity", "reason", "actor", "created_at"},
+		"properties": bson.M{
+			"_id":        bson.M{"bsonType": "string"},
+			"product_id": bson.M{"bsonType": "string"},
+			"quantity":   bson.M{"bsonType": bson.A{"int", "long"}},
+			"reason":     bson.M{"bsonType": "string"},
+			"actor":      bson.M{"bsonType": "string"},
+			"order_id":   bson.M{"bsonType": "string"},
+			"note":       bson.M{"bsonType": "string"},
+			"created_at": bson.M{"bsonType": "date"},
+		},
+	},
+}
+
+func (r *inventoryRepository) Store(ctx context.Context, movement *transaction.InventoryMovement) error {
+	movement.ID = primitive.NewObjectID().Hex()
+	ir, err := r.collection.InsertOne(ctx, movement)
+	if err != nil {
+		return err
+	}
+	movement.ID = fmt.Sprintf("%v", ir.InsertedID)
+
+	return nil
+}
+
+func (r *inventoryRepository) FindByProductID(ctx context.Context, productID string) ([]transaction.InventoryMovement, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.collection.Find(ctx, bson.M{"product_id": productID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	movements := make([]transaction.InventoryMovement, 0)
+	if err := cur.All(ctx, &movements); err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
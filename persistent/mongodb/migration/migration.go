@@ -1,48 +1,295 @@
+// Package migration applies versioned migrations to mongodb.
+//
+// Schema migrations (collections, indexes, validators) and seed migrations (optional predefined data)
+// are kept apart, every applied migration is recorded in schema_migrations collection so it runs exactly once.
+// Migrations are applied while holding a lock stored in the same collection, so several instances
+// starting at once do not apply the same migration twice.
 package migration
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
-	"github.com/muktihari/order-transaction-ddd/transaction"
-	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// MigratePredefinedData drop transaction-order database and create new one with predefined data such as actor, product, coupon
-func MigratePredefinedData(ctx context.Context, client *mongo.Client) (err error) {
-	if err := client.Database("transaction-order").Drop(ctx); err != nil {
-		return err
+var (
+	// ErrSchemaTooNew tells that the database has migrations applied which are unknown to the application
+	ErrSchemaTooNew = errors.New("database schema is newer than the application")
+)
+
+const (
+	lockID            = "lock"
+	lockTTL           = 10 * time.Minute
+	lockRetryInterval = 500 * time.Millisecond
+)
+
+// Kind of migration
+type Kind string
+
+// List of migration kinds
+const (
+	KindSchema Kind = "schema"
+	KindSeed   Kind = "seed"
+)
+
+// Migration is a versioned change of the database, Up should be safe to re-run
+// since a failure between applying and recording it leaves the migration pending.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+}
+
+// Status tells whether a migration has been applied
+type Status struct {
+	Kind Kind
+	Migration
+	AppliedAt *time.Time
+}
+
+type record struct {
+	ID        string    `bson:"_id"`
+	Kind      Kind      `bson:"kind"`
+	Version   int       `bson:"version"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Migrator applies migrations to a database
+type Migrator struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+	schema     []Migration
+	seed       []Migration
+}
+
+// New creates new migrator applying Schema and Seed migrations
+func New(db *mongo.Database) *Migrator {
+	return NewWithMigrations(db, Schema, Seed)
+}
+
+// NewWithMigrations creates new migrator applying given migrations, both ordered by their version
+func NewWithMigrations(db *mongo.Database, schema, seed []Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		collection: db.Collection("schema_migrations"),
+		schema:     schema,
+		seed:       seed,
+	}
+}
+
+// Latest returns the latest schema version known by the migrator
+func (m *Migrator) Latest() int {
+	if len(m.schema) == 0 {
+		return 0
 	}
+	return m.schema[len(m.schema)-1].Version
+}
 
-	db := client.Database("transaction-order")
-	_, err = db.Collection("admins").InsertMany(ctx, []interface{}{
-		transaction.Admin{ID: primitive.NewObjectID().Hex(), Name: "Mukti"},
-	})
+// Version returns the latest schema version applied to the database
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx, KindSchema)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	var version int
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
 
-	_, err = db.Collection("customers").InsertMany(ctx, []interface{}{
-		transaction.Customer{ID: primitive.NewObjectID().Hex(), Name: "Hari", PhoneNumber: "+62-12345", Email: "example@email.com", Address: "No, Street, City, Indonesia"},
-	})
+// Check checks that the database schema is not newer than the migrator,
+// it returns the number of pending schema migrations.
+func (m *Migrator) Check(ctx context.Context) (pending int, err error) {
+	version, err := m.Version(ctx)
 	if err != nil {
+		return 0, err
+	}
+	if version > m.Latest() {
+		return 0, fmt.Errorf("%w: database version %d, latest known version %d", ErrSchemaTooNew, version, m.Latest())
+	}
+	applied, err := m.applied(ctx, KindSchema)
+	if err != nil {
+		return 0, err
+	}
+	for _, migration := range m.schema {
+		if _, ok := applied[migration.Version]; !ok {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending schema migrations
+func (m *Migrator) Up(ctx context.Context) error {
+	if _, err := m.Check(ctx); err != nil {
 		return err
 	}
+	return m.apply(ctx, KindSchema, m.schema)
+}
 
-	_, err = db.Collection("products").InsertMany(ctx, []interface{}{
-		transaction.Product{ID: primitive.NewObjectID().Hex(), Name: "Sony Xperia 10", Price: decimal.NewFromInt(500), Quantity: 100},
-		transaction.Product{ID: primitive.NewObjectID().Hex(), Name: "Ultramilk 1 Liter", Price: decimal.NewFromInt(5), Quantity: 1000},
-	})
+// Seed applies all pending seed migrations, existing data is never dropped
+func (m *Migrator) Seed(ctx context.Context) error {
+	return m.apply(ctx, KindSeed, m.seed)
+}
+
+// Status returns every known migration and when it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	for _, kind := range []Kind{KindSchema, KindSeed} {
+		migrations := m.schema
+		if kind == KindSeed {
+			migrations = m.seed
+		}
+		applied, err := m.applied(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+		for _, migration := range migrations {
+			status := Status{Kind: kind, Migration: migration}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// apply applies pending migrations of a kind under the migration lock
+func (m *Migrator) apply(ctx context.Context, kind Kind, migrations []Migration) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return fmt.Errorf("could not acquire migration lock: %w", err)
+	}
+	defer unlock()
+
+	// read after locking, another instance might have applied them while waiting
+	applied, err := m.applied(ctx, kind)
 	if err != nil {
 		return err
 	}
 
-	_, err = db.Collection("coupons").InsertMany(ctx, []interface{}{
-		transaction.Coupon{Code: "DISCOUNT_5$", Type: transaction.CouponTypeNominal, Amount: decimal.NewFromInt(5), Quantity: 100, Begin: time.Now(), End: time.Now().Add(10 * 24 * time.Hour)},
-		transaction.Coupon{Code: "DISCOUNT_20%", Type: transaction.CouponTypeNominal, Amount: decimal.NewFromFloat(0.2), Quantity: 100, Begin: time.Now(), End: time.Now().Add(10 * 24 * time.Hour)},
-	})
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := migration.Up(ctx, m.db); err != nil {
+			return fmt.Errorf("%s migration %d_%s: %w", kind, migration.Version, migration.Name, err)
+		}
+		_, err := m.collection.InsertOne(ctx, record{
+			ID:        fmt.Sprintf("%s_%04d", kind, migration.Version),
+			Kind:      kind,
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("%s migration %d_%s: could not record: %w", kind, migration.Version, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// applied returns applied versions of a kind and when they have been applied
+func (m *Migrator) applied(ctx context.Context, kind Kind) (map[int]time.Time, error) {
+	cur, err := m.collection.Find(ctx, bson.M{"kind": kind})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	applied := make(map[int]time.Time)
+	for cur.Next(ctx) {
+		var r record
+		if err := cur.Decode(&r); err != nil {
+			return nil, err
+		}
+		applied[r.Version] = r.AppliedAt
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// lock waits until it holds the migration lock, a lock older than lockTTL is considered
+// left behind by a crashed migrator and is taken over.
+func (m *Migrator) lock(ctx context.Context) (unlock func(), err error) {
+	owner := primitive.NewObjectID().Hex()
+	for {
+		now := time.Now().UTC()
+		_, err := m.collection.InsertOne(ctx, bson.M{"_id": lockID, "owner": owner, "locked_at": now})
+		if err == nil {
+			break
+		}
+		if !isDuplicateKey(err) {
+			return nil, err
+		}
+
+		ur, err := m.collection.UpdateOne(ctx,
+			bson.M{"_id": lockID, "locked_at": bson.M{"$lt": now.Add(-lockTTL)}},
+			bson.M{"$set": bson.M{"owner": owner, "locked_at": now}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if ur.MatchedCount == 1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, _ = m.collection.DeleteOne(ctx, bson.M{"_id": lockID, "owner": owner})
+	}, nil
+}
+
+func isDuplicateKey(err error) bool {
+	var we mongo.WriteException
+	if errors.As(err, &we) {
+		for _, e := range we.WriteErrors {
+			if e.Code == 11000 {
+				return true
+			}
+		}
+	}
+	return false
+}
 
+// createCollections creates the given collections unless they already exist
+func createCollections(ctx context.Context, db *mongo.Database, names ...string) error {
+	existing, err := db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		exists[name] = true
+	}
+	for _, name := range names {
+		if exists[name] {
+			continue
+		}
+		if err := db.CreateCollection(ctx, name, options.CreateCollection()); err != nil {
+			return err
+		}
+	}
 	return nil
 }
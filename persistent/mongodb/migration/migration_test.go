@@ -0,0 +1,88 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/persistent/mongodb/migration"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// openDB creates a fresh database in the mongodb given by MONGO_URI, the test is skipped when MONGO_URI is not set.
+func openDB(t *testing.T) *mongo.Database {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("could not connect to mongodb: %v", err)
+	}
+	db := client.Database("migration_" + uuid.NewString()[:8])
+	t.Cleanup(func() {
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+	return db
+}
+
+func TestMigrator(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+
+	var calls int
+	schema := []migration.Migration{
+		{Version: 1, Name: "create_things", Up: func(ctx context.Context, db *mongo.Database) error {
+			calls++
+			return db.CreateCollection(ctx, "things")
+		}},
+	}
+	seed := []migration.Migration{
+		{Version: 1, Name: "insert_things", Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("things").InsertOne(ctx, bson.M{"name": "thing"})
+			return err
+		}},
+	}
+	migrator := migration.NewWithMigrations(db, schema, seed)
+
+	if pending, err := migrator.Check(ctx); err != nil || pending != 1 {
+		t.Fatalf("got pending %d err %v, expected 1 and nil", pending, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := migrator.Up(ctx); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := migrator.Seed(ctx); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("got schema migration applied %d times, expected 1", calls)
+	}
+	if n, _ := db.Collection("things").CountDocuments(ctx, bson.M{}); n != 1 {
+		t.Fatalf("got %d seeded documents, expected 1", n)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("%s migration %d is pending, expected applied", status.Kind, status.Version)
+		}
+	}
+
+	older := migration.NewWithMigrations(db, nil, nil)
+	if _, err := older.Check(ctx); !errors.Is(err, migration.ErrSchemaTooNew) {
+		t.Fatalf("got %v, expected %v", err, migration.ErrSchemaTooNew)
+	}
+}
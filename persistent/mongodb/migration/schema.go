@@ -0,0 +1,87 @@
+package migration
+
+import (
+	"context"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Schema is the list of schema migrations ordered by version, a released migration must never be changed,
+// add a new version instead.
+var Schema = []Migration{
+	{
+		Version: 1,
+		Name:    "create_collections",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// collections can not be created implicitly inside a multi-document transaction
+			return createCollections(ctx, db, "admins", "customers", "products", "coupons", "orders")
+		},
+	},
+	{
+		Version: 2,
+		Name:    "inventory_ledger",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := createCollections(ctx, db, "inventory_movements"); err != nil {
+				return err
+			}
+			return insertOpeningBalances(ctx, db)
+		},
+	},
+	{
+		Version: 3,
+		Name:    "product_taxonomy",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createCollections(ctx, db, "categories")
+		},
+	},
+	{
+		Version: 4,
+		Name:    "disable_predefined_superadmin",
+		Up:      disablePredefinedSuperadmin,
+	},
+}
+
+// predefinedSuperadminHash is the hash of password "password" once given to the predefined superadmin by seed migration
+const predefinedSuperadminHash = "$2a$10$3InHY0ExvLl3N7jui8UmWei4K3thIemvgLFCjMKsWjLmXmTv0Cr3K"
+
+// disablePredefinedSuperadmin removes the well-known password of the superadmin seeded by older versions,
+// so it can't log in anymore. The first superadmin is created with create-admin command instead.
+func disablePredefinedSuperadmin(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("admins").UpdateMany(ctx,
+		bson.M{"password_hash": []byte(predefinedSuperadminHash)},
+		bson.M{"$unset": bson.M{"password_hash": ""}},
+	)
+	return err
+}
+
+// insertOpeningBalances records the stock of products having no inventory movement yet as their opening balance,
+// so the stock of every product can be reconstructed from the ledger.
+func insertOpeningBalances(ctx context.Context, db *mongo.Database) error {
+	cur, err := db.Collection("products").Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var products []transaction.Product
+	if err := cur.All(ctx, &products); err != nil {
+		return err
+	}
+
+	movements := db.Collection("inventory_movements")
+	for _, p := range products {
+		n, err := movements.CountDocuments(ctx, bson.M{"product_id": p.ID})
+		if err != nil {
+			return err
+		}
+		if n != 0 || p.Quantity == 0 {
+			continue
+		}
+		m := transaction.NewInventoryMovement(p.ID, "", p.Quantity, transaction.MovementReasonOpeningBalance, transaction.ActorSystem)
+		m.ID = "opening_" + p.ID
+		if _, err := movements.InsertOne(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package migration
+
+import (
+	"context"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Seed is the list of optional seed migrations ordered by version, such as predefined actor, product and coupon
+var Seed = []Migration{
+	{
+		Version: 1,
+		Name:    "predefined_data",
+		Up:      insertPredefinedData,
+	},
+	{
+		Version: 2,
+		Name:    "predefined_inventory",
+		Up:      insertOpeningBalances,
+	},
+}
+
+func insertPredefinedData(ctx context.Context, db *mongo.Database) (err error) {
+	_, err = db.Collection("admins").InsertMany(ctx, []interface{}{
+		transaction.Admin{ID: primitive.NewObjectID().Hex(), Name: "Mukti"},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("customers").InsertMany(ctx, []interface{}{
+		transaction.Customer{ID: primitive.NewObjectID().Hex(), Name: "Hari", PhoneNumber: "+62-12345", Email: "example@email.com", Address: "No, Street, City, Indonesia"},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("products").InsertMany(ctx, []interface{}{
+		transaction.Product{ID: primitive.NewObjectID().Hex(), Name: "Sony Xperia 10", Price: decimal.NewFromInt(500), Quantity: 100},
+		transaction.Product{ID: primitive.NewObjectID().Hex(), Name: "Ultramilk 1 Liter", Price: decimal.NewFromInt(5), Quantity: 1000},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("coupons").InsertMany(ctx, []interface{}{
+		transaction.Coupon{Code: "DISCOUNT_5$", Type: transaction.CouponTypeNominal, Amount: decimal.NewFromInt(5), Quantity: 100, Begin: time.Now(), End: time.Now().Add(10 * 24 * time.Hour)},
+		transaction.Coupon{Code: "DISCOUNT_20%", Type: transaction.CouponTypeNominal, Amount: decimal.NewFromFloat(0.2), Quantity: 100, Begin: time.Now(), End: time.Now().Add(10 * 24 * time.Hour)},
+	})
+	return err
+}
@@ -0,0 +1,142 @@
+package mongodb_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/decimalcodec"
+	"github.com/muktihari/order-transaction-ddd/persistent/mongodb"
+	"github.com/muktihari/order-transaction-ddd/persistent/mongodb/migration"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/muktihari/order-transaction-ddd/transaction/repotest"
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// connect connects to the mongodb given by MONGO_URI, the test is skipped when MONGO_URI is not set.
+// Unit of work checks need mongodb with replica(s) to enable transaction. e.g:
+//
+//	MONGO_URI="mongodb://localhost:27017" go test ./persistent/mongodb/...
+func connect(t *testing.T) *mongo.Client {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI is not set")
+	}
+
+	rb := bsoncodec.NewRegistryBuilder()
+	bsoncodec.DefaultValueDecoders{}.RegisterDefaultDecoders(rb)
+	bsoncodec.DefaultValueEncoders{}.RegisterDefaultEncoders(rb)
+	decimalcodec.RegisterEncodeDecoder(rb)
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri).SetRegistry(rb.Build()))
+	if err != nil {
+		t.Fatalf("could not connect to mongodb: %v", err)
+	}
+	return client
+}
+
+func TestRepositories(t *testing.T) {
+	client := connect(t)
+	defer client.Disconnect(context.Background())
+
+	repotest.Run(t, func(t *testing.T, seed repotest.Seed) repotest.Repositories {
+		ctx := context.Background()
+
+		// every setup gets its own database
+		db := client.Database("repotest_" + uuid.NewString()[:8])
+		t.Cleanup(func() { _ = db.Drop(ctx) })
+
+		if err := migration.New(db).Up(ctx); err != nil {
+			t.Fatalf("could not migrate: %v", err)
+		}
+		if err := mongodb.ApplySchema(ctx, db); err != nil {
+			t.Fatalf("could not apply schema: %v", err)
+		}
+		for _, c := range seed.Customers {
+			if _, err := db.Collection("customers").InsertOne(ctx, c); err != nil {
+				t.Fatalf("could not seed: %v", err)
+			}
+		}
+		for _, a := range seed.Admins {
+			if _, err := db.Collection("admins").InsertOne(ctx, a); err != nil {
+				t.Fatalf("could not seed: %v", err)
+			}
+		}
+		for _, c := range seed.Categories {
+			if _, err := db.Collection("categories").InsertOne(ctx, c); err != nil {
+				t.Fatalf("could not seed: %v", err)
+			}
+		}
+		for _, p := range seed.Products {
+			if _, err := db.Collection("products").InsertOne(ctx, p); err != nil {
+				t.Fatalf("could not seed: %v", err)
+			}
+		}
+		for _, c := range seed.Coupons {
+			if _, err := db.Collection("coupons").InsertOne(ctx, c); err != nil {
+				t.Fatalf("could not seed: %v", err)
+			}
+		}
+		for _, m := range seed.Movements {
+			if _, err := db.Collection("inventory_movements").InsertOne(ctx, m); err != nil {
+				t.Fatalf("could not seed: %v", err)
+			}
+		}
+
+		return repotest.Repositories{
+			Orders:      mongodb.NewOrderRepository(db),
+			Products:    mongodb.NewProductRepository(db),
+			Categories:  mongodb.NewCategoryRepository(db),
+			Coupons:     mongodb.NewCouponRepository(db),
+			Customers:   mongodb.NewCustomerRepository(db),
+			Credentials: mongodb.NewCredentialRepository(db),
+			Admins:      mongodb.NewAdminRepository(db),
+			APIKeys:     mongodb.NewAPIKeyRepository(db),
+			Inventory:   mongodb.NewInventoryRepository(db),
+			UnitOfWork:  mongodb.NewUnitOfWork(client, db),
+			Idempotency: mongodb.NewIdempotencyRepository(db),
+		}
+	})
+}
+
+func TestSchema(t *testing.T) {
+	client := connect(t)
+	defer client.Disconnect(context.Background())
+
+	ctx := context.Background()
+	db := client.Database("schema_" + uuid.NewString()[:8])
+	defer db.Drop(ctx)
+
+	if err := mongodb.VerifySchema(ctx, db); !errors.Is(err, mongodb.ErrSchemaMismatch) {
+		t.Fatalf("got %v, expected %v", err, mongodb.ErrSchemaMismatch)
+	}
+
+	// applying twice should be no-op
+	for i := 0; i < 2; i++ {
+		if err := mongodb.ApplySchema(ctx, db); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+	}
+	if err := mongodb.VerifySchema(ctx, db); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	coupon := transaction.Coupon{Code: "DUPLICATE", Type: transaction.CouponTypeNominal, Amount: decimal.NewFromInt(5), Quantity: 1, Begin: time.Now(), End: time.Now()}
+	if _, err := db.Collection("coupons").InsertOne(ctx, coupon); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := db.Collection("coupons").InsertOne(ctx, coupon); err == nil {
+		t.Fatalf("got nil, expected duplicate coupon code error")
+	}
+
+	product := transaction.Product{ID: "INVALID", Name: "Invalid", Price: decimal.NewFromInt(1), Quantity: -1}
+	if _, err := db.Collection("products").InsertOne(ctx, product); err == nil {
+		t.Fatalf("got nil, expected validation error on negative quantity")
+	}
+}
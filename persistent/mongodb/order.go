@@ -9,24 +9,59 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type orderRepository struct {
-	client     *mongo.Client
 	db         *mongo.Database
 	collection *mongo.Collection
 }
 
 // NewOrderRepository creates new order repository
-func NewOrderRepository(client *mongo.Client, db *mongo.Database) transaction.OrderRepository {
-	return &orderRepository{client, db, db.Collection("orders")}
+func NewOrderRepository(db *mongo.Database) transaction.OrderRepository {
+	return &orderRepository{db, db.Collection("orders")}
+}
+
+var orderSchema = Schema{
+	Collection: "orders",
+	Indexes: []Index{
+		{Name: "customer_status", Keys: bson.D{{Key: "customer._id", Value: 1}, {Key: "status", Value: 1}}},
+		{Name: "status", Keys: bson.D{{Key: "status", Value: 1}}},
+		// listing is sorted by created_at and _id, filters with equality on the prefix can use the sort
+		{Name: "created_at", Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Name: "customer_created_at", Keys: bson.D{{Key: "customer._id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Name: "status_created_at", Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Name: "coupon_code", Keys: bson.D{{Key: "coupon.code", Value: 1}}},
+		{Name: "cart_product", Keys: bson.D{{Key: "cart.product._id", Value: 1}}},
+		{Name: "shipping_id", Keys: bson.D{{Key: "shipping_id", Value: 1}}},
+	},
+	Validator: bson.M{
+		"bsonType": "object",
+		"required": bson.A{"_id", "status", "customer", "version"},
+		"properties": bson.M{
+			"_id":    bson.M{"bsonType": "string"},
+			"status": bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 1, "maximum": 6},
+			"customer": bson.M{
+				"bsonType": "object",
+				"required": bson.A{"_id"},
+				"properties": bson.M{
+					"_id": bson.M{"bsonType": "string"},
+				},
+			},
+			"cart":                  bson.M{"bsonType": bson.A{"array", "null"}},
+			"price":                 bson.M{"bsonType": "decimal"},
+			"price_after_reduction": bson.M{"bsonType": "decimal"},
+			"created_at":            bson.M{"bsonType": "date"},
+			"version":               bson.M{"bsonType": bson.A{"int", "long"}},
+		},
+	},
 }
 
 func (r *orderRepository) FindByID(ctx context.Context, id string) (*transaction.Order, error) {
 	sr := r.collection.FindOne(ctx, bson.M{"_id": id})
 	if err := sr.Err(); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, transaction.ErrOrderNotFound
+			return nil, fmt.Errorf("order %s: %w", id, transaction.ErrOrderNotFound)
 		}
 		return nil, err
 	}
@@ -39,92 +74,83 @@ func (r *orderRepository) FindByID(ctx context.Context, id string) (*transaction
 	return &order, nil
 }
 
-func (r *orderRepository) Store(ctx context.Context, order *transaction.Order) error {
-	order.ID = primitive.NewObjectID().Hex()
-	ir, err := r.collection.InsertOne(ctx, order)
+func (r *orderRepository) Find(ctx context.Context, filter transaction.OrderFilter, page transaction.Page) (*transaction.OrderPage, error) {
+	cursor, err := transaction.DecodeOrderCursor(page.Cursor, filter.Sort)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	order.ID = fmt.Sprintf("%v", ir.InsertedID)
-
-	return nil
-}
 
-func (r *orderRepository) Update(ctx context.Context, order *transaction.Order) error {
-	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": order.ID}, order)
-	if err != nil {
-		return err
+	direction, op := -1, "$lt"
+	if filter.Sort == transaction.OrderSortOldest {
+		direction, op = 1, "$gt"
 	}
 
-	return nil
-}
-
-func (r *orderRepository) FinalizeAndReserveProducts(ctx context.Context, order *transaction.Order) error {
-	sess, err := r.client.StartSession()
-	if err != nil {
-		return err
+	query := bson.M{}
+	if filter.CustomerID != "" {
+		query["customer._id"] = filter.CustomerID
 	}
-	defer sess.EndSession(nil)
-
-	_, err = sess.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
-		for _, cartItem := range order.Cart {
-			_, err := r.db.Collection("products").UpdateOne(sessCtx,
-				bson.M{"_id": cartItem.Product.ID},
-				bson.M{"$inc": bson.M{"quantity": cartItem.Quantity * -1}})
-			if err != nil {
-				return nil, err
-			}
-			_, err = r.db.Collection("coupons").UpdateOne(sessCtx,
-				bson.M{"code": order.Coupon.Code},
-				bson.M{"$inc": bson.M{"quantity": -1}})
-			if err != nil {
-				return nil, err
-			}
-		}
-		_, err = r.collection.ReplaceOne(sessCtx, bson.M{"_id": order.ID}, order)
-		if err != nil {
-			return nil, err
+	if len(filter.Statuses) != 0 {
+		query["status"] = bson.M{"$in": filter.Statuses}
+	}
+	created := bson.M{}
+	if !filter.CreatedFrom.IsZero() {
+		created["$gte"] = filter.CreatedFrom
+	}
+	if !filter.CreatedTo.IsZero() {
+		created["$lt"] = filter.CreatedTo
+	}
+	if len(created) != 0 {
+		query["created_at"] = created
+	}
+	if filter.CouponCode != "" {
+		query["coupon.code"] = filter.CouponCode
+	}
+	if filter.ProductID != "" {
+		query["cart.product._id"] = filter.ProductID
+	}
+	if filter.ShippingID != "" {
+		query["shipping_id"] = filter.ShippingID
+	}
+	if cursor != nil {
+		query["$or"] = bson.A{
+			bson.M{"created_at": bson.M{op: cursor.CreatedAt}},
+			bson.M{"created_at": cursor.CreatedAt, "_id": bson.M{op: cursor.ID}},
 		}
-		return nil, nil
-	})
+	}
+
+	cur, err := r.collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(page.Size()+1)),
+	)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer cur.Close(ctx)
 
-	if err := sess.CommitTransaction(ctx); err != nil {
-		return err
+	orders := make([]transaction.Order, 0)
+	if err := cur.All(ctx, &orders); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return transaction.NewOrderPage(orders, filter, page), nil
 }
 
-func (r *orderRepository) CancelAndReleaseProducts(ctx context.Context, order *transaction.Order) error {
-	sess, err := r.client.StartSession()
+func (r *orderRepository) Store(ctx context.Context, order *transaction.Order) error {
+	order.ID = primitive.NewObjectID().Hex()
+	ir, err := r.collection.InsertOne(ctx, order)
 	if err != nil {
 		return err
 	}
-	defer sess.EndSession(nil)
+	order.ID = fmt.Sprintf("%v", ir.InsertedID)
 
-	_, err = sess.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
-		for _, cartItem := range order.Cart {
-			_, err := r.db.Collection("products").UpdateOne(sessCtx,
-				bson.M{"_id": cartItem.Product.ID},
-				bson.M{"$inc": bson.M{"quantity": cartItem.Quantity}})
-			if err != nil {
-				return nil, err
-			}
-		}
-		_, err = r.collection.ReplaceOne(sessCtx, bson.M{"_id": order.ID}, order)
-		if err != nil {
-			return nil, err
-		}
-		return nil, nil
-	})
-	if err != nil {
-		return err
-	}
+	return nil
+}
 
-	if err := sess.CommitTransaction(ctx); err != nil {
+func (r *orderRepository) Update(ctx context.Context, order *transaction.Order) error {
+	version := order.Version
+	order.Version++
+	if err := replaceVersioned(ctx, r.collection, bson.M{"_id": order.ID}, version, order, transaction.ErrOrderNotFound); err != nil {
+		order.Version = version
 		return err
 	}
 
@@ -3,9 +3,11 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/muktihari/order-transaction-ddd/transaction"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -14,16 +16,67 @@ type productRepository struct {
 	collection *mongo.Collection
 }
 
-// NewProductRepository creates new product repository in memory
+// NewProductRepository creates new product repository
 func NewProductRepository(db *mongo.Database) transaction.ProductRepository {
 	return &productRepository{db, db.Collection("products")}
 }
 
+var productSchema = Schema{
+	Collection: "products",
+	Indexes: []Index{
+		{Name: "category_id", Keys: bson.D{{Key: "category_id", Value: 1}}},
+		{Name: "tags", Keys: bson.D{{Key: "tags", Value: 1}}},
+	},
+	Validator: bson.M{
+		"bsonType": "object",
+		"required": bson.A{"_id", "name", "price", "quantity", "version"},
+		"properties": bson.M{
+			"_id":         bson.M{"bsonType": "string"},
+			"name":        bson.M{"bsonType": "string"},
+			"description": bson.M{"bsonType": "string"},
+			"price":       bson.M{"bsonType": "decimal"},
+			"quantity":    bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+			"variants": bson.M{
+				"bsonType": "array",
+				"items": bson.M{
+					"bsonType": "object",
+					"required": bson.A{"sku", "name", "quantity"},
+					"properties": bson.M{
+						"sku":      bson.M{"bsonType": "string"},
+						"name":     bson.M{"bsonType": "string"},
+						"price":    bson.M{"bsonType": "decimal"},
+						"quantity": bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+					},
+				},
+			},
+			"components": bson.M{
+				"bsonType": "array",
+				"items": bson.M{
+					"bsonType": "object",
+					"required": bson.A{"product_id", "quantity"},
+					"properties": bson.M{
+						"product_id": bson.M{"bsonType": "string"},
+						"sku":        bson.M{"bsonType": "string"},
+						"quantity":   bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 1},
+					},
+				},
+			},
+			"category_id":       bson.M{"bsonType": "string"},
+			"tags":              bson.M{"bsonType": "array", "items": bson.M{"bsonType": "string"}},
+			"reorder_threshold": bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+			"backorder":         bson.M{"enum": bson.A{"", "backorder", "pre_order"}},
+			"available_at":      bson.M{"bsonType": "date"},
+			"archived":          bson.M{"bsonType": "bool"},
+			"version":           bson.M{"bsonType": bson.A{"int", "long"}},
+		},
+	},
+}
+
 func (r *productRepository) FindByID(ctx context.Context, id string) (*transaction.Product, error) {
 	sr := r.collection.FindOne(ctx, bson.M{"_id": id})
 	if err := sr.Err(); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, transaction.ErrCouponNotFound
+			return nil, fmt.Errorf("product %s: %w", id, transaction.ErrProductNotFound)
 		}
 		return nil, err
 	}
@@ -38,14 +91,11 @@ func (r *productRepository) FindByID(ctx context.Context, id string) (*transacti
 func (r *productRepository) FindAll(ctx context.Context) ([]transaction.Product, error) {
 	cur, err := r.collection.Find(ctx, bson.M{})
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, transaction.ErrCouponNotFound
-		}
 		return nil, err
 	}
-	defer cur.Close(nil)
+	defer cur.Close(ctx)
 
-	var products []transaction.Product
+	products := make([]transaction.Product, 0)
 	if err := cur.All(ctx, &products); err != nil {
 		return nil, err
 	}
@@ -53,11 +103,24 @@ func (r *productRepository) FindAll(ctx context.Context) ([]transaction.Product,
 	return products, nil
 }
 
-func (r *productRepository) Update(ctx context.Context, product *transaction.Product) error {
-	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": product.ID}, product)
+func (r *productRepository) Store(ctx context.Context, product *transaction.Product) error {
+	product.ID = primitive.NewObjectID().Hex()
+	ir, err := r.collection.InsertOne(ctx, product)
 	if err != nil {
 		return err
 	}
+	product.ID = fmt.Sprintf("%v", ir.InsertedID)
+
+	return nil
+}
+
+func (r *productRepository) Update(ctx context.Context, product *transaction.Product) error {
+	version := product.Version
+	product.Version++
+	if err := replaceVersioned(ctx, r.collection, bson.M{"_id": product.ID}, version, product, transaction.ErrProductNotFound); err != nil {
+		product.Version = version
+		return err
+	}
 
 	return nil
 }
@@ -0,0 +1,249 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrSchemaMismatch tells that indexes or validators in the database differ from the declared ones
+	ErrSchemaMismatch = errors.New("database schema does not match the declared schema")
+)
+
+// Schema declares the indexes and the JSON-schema validator of a collection used by a repository
+type Schema struct {
+	Collection string
+	Indexes    []Index
+	Validator  bson.M
+}
+
+// Index declares a secondary index, Keys are ordered fields with their direction.
+// Sparse index leaves out documents missing the indexed fields, index having ExpireAfterSeconds is a TTL index
+// removing documents that many seconds after the date of its single field.
+type Index struct {
+	Name               string
+	Keys               bson.D
+	Unique             bool
+	Sparse             bool
+	ExpireAfterSeconds int32
+}
+
+// Schemas returns the schema of every collection used by the repositories
+func Schemas() []Schema {
+	return []Schema{customerSchema, credentialSchema, adminSchema, apiKeySchema, idempotencySchema, categorySchema, productSchema, couponSchema, orderSchema, inventorySchema}
+}
+
+// ApplySchema creates missing collections, sets their validators and creates their indexes,
+// it is safe to be called on every startup.
+func ApplySchema(ctx context.Context, db *mongo.Database) error {
+	existing, err := db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		exists[name] = true
+	}
+
+	for _, s := range Schemas() {
+		if !exists[s.Collection] {
+			// another instance starting at the same time may have created it in the meantime
+			if err := db.CreateCollection(ctx, s.Collection); err != nil && !isNamespaceExists(err) {
+				return fmt.Errorf("collection %s: %w", s.Collection, err)
+			}
+		}
+
+		// moderate level leaves already invalid documents updatable, new and valid documents must stay valid
+		err := db.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: s.Collection},
+			{Key: "validator", Value: bson.M{"$jsonSchema": s.Validator}},
+			{Key: "validationLevel", Value: "moderate"},
+			{Key: "validationAction", Value: "error"},
+		}).Err()
+		if err != nil {
+			return fmt.Errorf("collection %s validator: %w", s.Collection, err)
+		}
+
+		if len(s.Indexes) == 0 {
+			continue
+		}
+		models := make([]mongo.IndexModel, len(s.Indexes))
+		for i, index := range s.Indexes {
+			opts := options.Index().SetName(index.Name).SetUnique(index.Unique).SetSparse(index.Sparse)
+			if index.ExpireAfterSeconds != 0 {
+				opts.SetExpireAfterSeconds(index.ExpireAfterSeconds)
+			}
+			models[i] = mongo.IndexModel{
+				Keys:    index.Keys,
+				Options: opts,
+			}
+		}
+		if _, err := db.Collection(s.Collection).Indexes().CreateMany(ctx, models); err != nil {
+			return fmt.Errorf("collection %s indexes: %w", s.Collection, err)
+		}
+	}
+	return nil
+}
+
+// isNamespaceExists tells whether the error is returned for creating a collection which already exists
+func isNamespaceExists(err error) bool {
+	var ce mongo.CommandError
+	return errors.As(err, &ce) && ce.Code == 48
+}
+
+// VerifySchema checks that every declared index and validator exists in the database,
+// differences are reported in an error wrapping ErrSchemaMismatch.
+func VerifySchema(ctx context.Context, db *mongo.Database) error {
+	var problems []string
+	for _, s := range Schemas() {
+		p, err := verifyCollection(ctx, db, s)
+		if err != nil {
+			return fmt.Errorf("collection %s: %w", s.Collection, err)
+		}
+		problems = append(problems, p...)
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("%w: %s", ErrSchemaMismatch, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func verifyCollection(ctx context.Context, db *mongo.Database, s Schema) (problems []string, err error) {
+	cur, err := db.ListCollections(ctx, bson.M{"name": s.Collection})
+	if err != nil {
+		return nil, err
+	}
+	var collections []struct {
+		Options struct {
+			Validator bson.M `bson:"validator"`
+		} `bson:"options"`
+	}
+	if err := cur.All(ctx, &collections); err != nil {
+		return nil, err
+	}
+	if len(collections) == 0 {
+		return []string{fmt.Sprintf("collection %s does not exist", s.Collection)}, nil
+	}
+
+	equal, err := bsonEqual(collections[0].Options.Validator, bson.M{"$jsonSchema": s.Validator})
+	if err != nil {
+		return nil, err
+	}
+	if !equal {
+		problems = append(problems, fmt.Sprintf("collection %s has different validator", s.Collection))
+	}
+
+	cur, err = db.Collection(s.Collection).Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []struct {
+		Name               string `bson:"name"`
+		Key                bson.D `bson:"key"`
+		Unique             bool   `bson:"unique"`
+		Sparse             bool   `bson:"sparse"`
+		ExpireAfterSeconds int32  `bson:"expireAfterSeconds"`
+	}
+	if err := cur.All(ctx, &indexes); err != nil {
+		return nil, err
+	}
+
+	for _, want := range s.Indexes {
+		found := false
+		for _, got := range indexes {
+			if got.Name != want.Name {
+				continue
+			}
+			found = true
+			equal, err := keysEqual(got.Key, want.Keys)
+			if err != nil {
+				return nil, err
+			}
+			if !equal || got.Unique != want.Unique || got.Sparse != want.Sparse || got.ExpireAfterSeconds != want.ExpireAfterSeconds {
+				problems = append(problems, fmt.Sprintf("collection %s has different index %s", s.Collection, want.Name))
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("collection %s misses index %s", s.Collection, want.Name))
+		}
+	}
+	return problems, nil
+}
+
+// keysEqual compares index keys, unlike documents the order of index keys matters
+func keysEqual(a, b bson.D) (bool, error) {
+	if len(a) != len(b) {
+		return false, nil
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key {
+			return false, nil
+		}
+		equal, err := bsonEqual(a[i].Value, b[i].Value)
+		if err != nil || !equal {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// bsonEqual compares values by their bson representation, so numeric types and map order do not matter
+func bsonEqual(a, b interface{}) (bool, error) {
+	na, err := normalize(a)
+	if err != nil {
+		return false, err
+	}
+	nb, err := normalize(b)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(na, nb), nil
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	b, err := bson.Marshal(bson.M{"v": v})
+	if err != nil {
+		return nil, err
+	}
+	var m bson.M
+	if err := bson.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return normalizeValue(m["v"]), nil
+}
+
+// normalizeValue converts documents to maps and numbers to float64 recursively
+func normalizeValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case bson.M:
+		m := make(map[string]interface{}, len(t))
+		for key, val := range t {
+			m[key] = normalizeValue(val)
+		}
+		return m
+	case bson.D:
+		m := make(map[string]interface{}, len(t))
+		for _, e := range t {
+			m[e.Key] = normalizeValue(e.Value)
+		}
+		return m
+	case bson.A:
+		a := make([]interface{}, len(t))
+		for i, val := range t {
+			a[i] = normalizeValue(val)
+		}
+		return a
+	case int32:
+		return float64(t)
+	case int64:
+		return float64(t)
+	}
+	return v
+}
@@ -0,0 +1,47 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type unitOfWork struct {
+	client    *mongo.Client
+	orders    transaction.OrderRepository
+	products  transaction.ProductRepository
+	coupons   transaction.CouponRepository
+	inventory transaction.InventoryRepository
+}
+
+// NewUnitOfWork creates new unit of work backed by mongodb multi-document transaction,
+// mongodb should have replica(s) to enable transaction.
+func NewUnitOfWork(client *mongo.Client, db *mongo.Database) transaction.UnitOfWork {
+	return &unitOfWork{
+		client:    client,
+		orders:    NewOrderRepository(db),
+		products:  NewProductRepository(db),
+		coupons:   NewCouponRepository(db),
+		inventory: NewInventoryRepository(db),
+	}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, r transaction.Repositories) error) error {
+	sess, err := u.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer sess.EndSession(ctx)
+
+	// repositories join the transaction through the session context
+	_, err = sess.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx, u)
+	})
+	return err
+}
+
+func (u *unitOfWork) Orders() transaction.OrderRepository        { return u.orders }
+func (u *unitOfWork) Products() transaction.ProductRepository    { return u.products }
+func (u *unitOfWork) Coupons() transaction.CouponRepository      { return u.coupons }
+func (u *unitOfWork) Inventory() transaction.InventoryRepository { return u.inventory }
@@ -0,0 +1,37 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// replaceVersioned replaces the document matched by filter only if its stored version equals to version.
+// It returns errNotFound when no document matches the filter and transaction.ErrConcurrentModification
+// when the document exists but has been changed since it was loaded.
+func replaceVersioned(ctx context.Context, collection *mongo.Collection, filter bson.M, version int64, doc interface{}, errNotFound error) error {
+	versioned := bson.M{"version": version}
+	for key, val := range filter {
+		versioned[key] = val
+	}
+
+	ur, err := collection.ReplaceOne(ctx, versioned, doc)
+	if err != nil {
+		return err
+	}
+	if ur.MatchedCount != 0 {
+		return nil
+	}
+
+	n, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %v: %w", collection.Name(), filter, errNotFound)
+	}
+	return fmt.Errorf("%s %v: %w", collection.Name(), filter, transaction.ErrConcurrentModification)
+}
@@ -3,12 +3,16 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/muktihari/order-transaction-ddd/transaction"
 )
 
 type customerRepository struct {
-	db *sql.DB
+	db querier
 }
 
 // NewCustomerRepository creates new customer repository
@@ -17,49 +21,148 @@ func NewCustomerRepository(db *sql.DB) transaction.CustomerRepository {
 }
 
 func (r *customerRepository) FindByID(ctx context.Context, id string) (*transaction.Customer, error) {
-	stmt, err := r.db.PrepareContext(ctx, "select * from customers where id = $1")
-	if err != nil {
-		return nil, err
-	}
-	sqlRows, err := stmt.Query(id)
+	return r.findOne(ctx, "select * from customers where id = $1", id)
+}
+
+func (r *customerRepository) FindByEmail(ctx context.Context, email string) (*transaction.Customer, error) {
+	return r.findOne(ctx, "select * from customers where email = $1", email)
+}
+
+func (r *customerRepository) findOne(ctx context.Context, query string, args ...interface{}) (*transaction.Customer, error) {
+	sqlRows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer sqlRows.Close()
 
 	var customer transaction.Customer
-	if sqlRows.Next() == false {
+	if !sqlRows.Next() {
+		if err := sqlRows.Err(); err != nil {
+			return nil, err
+		}
 		return nil, transaction.ErrCustomerNotFound
 	}
 	if err := Map(sqlRows, &customer); err != nil {
 		return nil, err
 	}
-	sqlRows.Close()
 
 	return &customer, nil
 }
 
-type adminRepository struct {
-	db *sql.DB
+func (r *customerRepository) Store(ctx context.Context, customer *transaction.Customer) error {
+	id := uuid.NewString()
+	_, err := r.db.ExecContext(ctx,
+		"insert into customers (id, name, phone_number, email, address, email_verified, phone_verified, version) values ($1, $2, $3, $4, $5, $6, $7, $8)",
+		id, customer.Name, customer.PhoneNumber, customer.Email, customer.Address, customer.EmailVerified, customer.PhoneVerified, customer.Version,
+	)
+	if err != nil {
+		return customerConflict(err)
+	}
+	customer.ID = id
+	return nil
 }
 
-func (r *adminRepository) FindByID(ctx context.Context, id string) (*transaction.Admin, error) {
-	stmt, err := r.db.PrepareContext(ctx, "select * from admins where id = $1")
+func (r *customerRepository) Update(ctx context.Context, customer *transaction.Customer) error {
+	c, err := r.FindByID(ctx, customer.ID)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	sqlRows, err := stmt.Query(id)
+	if c.Version != customer.Version {
+		return transaction.ErrConcurrentModification
+	}
+
+	keyVals, err := KeyValsDiff(c, customer)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if err := updateVersioned(ctx, r.db, "customers", "id", customer.ID, customer.Version, keyVals); err != nil {
+		return customerConflict(err)
+	}
+	customer.Version++
+
+	return nil
+}
+
+func (r *customerRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, "delete from customers where id = $1", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
 	}
+	if n == 0 {
+		return fmt.Errorf("customer %s: %w", id, transaction.ErrCustomerNotFound)
+	}
+	return nil
+}
 
-	var admin transaction.Admin
-	if sqlRows.Next() == false {
-		return nil, transaction.ErrAdminNotFound
+// customerConflict tells which unique contact the error of writing a customer violates, other errors are returned as is
+func customerConflict(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return err
+	}
+	switch pqErr.Constraint {
+	case "customers_email":
+		return transaction.ErrEmailTaken
+	case "customers_phone_number":
+		return transaction.ErrPhoneNumberTaken
+	default:
+		return err
 	}
-	if err := Map(sqlRows, &admin); err != nil {
+}
+
+type adminRepository struct {
+	db querier
+}
+
+// NewAdminRepository creates new admin repository
+func NewAdminRepository(db *sql.DB) transaction.AdminRepository {
+	return &adminRepository{db}
+}
+
+const selectAdmin = "select id, name, email, role, password_hash from admins"
+
+func (r *adminRepository) FindByID(ctx context.Context, id string) (*transaction.Admin, error) {
+	return r.findOne(ctx, selectAdmin+" where id = $1", id)
+}
+
+func (r *adminRepository) FindByEmail(ctx context.Context, email string) (*transaction.Admin, error) {
+	return r.findOne(ctx, selectAdmin+" where email = $1", email)
+}
+
+func (r *adminRepository) findOne(ctx context.Context, query string, args ...interface{}) (*transaction.Admin, error) {
+	var a transaction.Admin
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&a.ID, &a.Name, &a.Email, &a.Role, &a.PasswordHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, transaction.ErrAdminNotFound
+		}
 		return nil, err
 	}
-	sqlRows.Close()
+	return &a, nil
+}
 
-	return &admin, nil
+func (r *adminRepository) Store(ctx context.Context, admin *transaction.Admin) error {
+	id := uuid.NewString()
+	_, err := r.db.ExecContext(ctx,
+		"insert into admins (id, name, email, role, password_hash) values ($1, $2, $3, $4, $5)",
+		id, admin.Name, admin.Email, admin.Role, admin.PasswordHash,
+	)
+	if err != nil {
+		return adminConflict(err)
+	}
+	admin.ID = id
+	return nil
+}
+
+// adminConflict tells whether the error of writing an admin violates the unique email, other errors are returned as is
+func adminConflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "admins_email" {
+		return transaction.ErrAdminEmailTaken
+	}
+	return err
 }
@@ -0,0 +1,120 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type categoryRepository struct {
+	db querier
+}
+
+// NewCategoryRepository creates new category repository
+func NewCategoryRepository(db *sql.DB) transaction.CategoryRepository {
+	return &categoryRepository{db}
+}
+
+const selectCategory = "select id, name, parent_id, version from categories"
+
+func scanCategory(s interface{ Scan(...interface{}) error }, c *transaction.Category) error {
+	var parentID sql.NullString
+	if err := s.Scan(&c.ID, &c.Name, &parentID, &c.Version); err != nil {
+		return err
+	}
+	c.ParentID = parentID.String
+	return nil
+}
+
+func (r *categoryRepository) FindByID(ctx context.Context, id string) (*transaction.Category, error) {
+	var c transaction.Category
+	if err := scanCategory(r.db.QueryRowContext(ctx, selectCategory+" where id = $1", id), &c); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("category %s: %w", id, transaction.ErrCategoryNotFound)
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *categoryRepository) FindAll(ctx context.Context) (transaction.Categories, error) {
+	rows, err := r.db.QueryContext(ctx, selectCategory+" order by id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := make(transaction.Categories, 0)
+	for rows.Next() {
+		var c transaction.Category
+		if err := scanCategory(rows, &c); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r *categoryRepository) Store(ctx context.Context, category *transaction.Category) error {
+	id := uuid.NewString()
+	_, err := r.db.ExecContext(ctx,
+		"insert into categories (id, name, parent_id, version) values ($1, $2, $3, $4)",
+		id, category.Name, nullString(category.ParentID), category.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	category.ID = id
+	return nil
+}
+
+func (r *categoryRepository) Update(ctx context.Context, category *transaction.Category) error {
+	res, err := r.db.ExecContext(ctx,
+		"update categories set name = $1, parent_id = $2, version = version + 1 where id = $3 and version = $4",
+		category.Name, nullString(category.ParentID), category.ID, category.Version,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, "select exists(select 1 from categories where id = $1)", category.ID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return transaction.ErrCategoryNotFound
+		}
+		return transaction.ErrConcurrentModification
+	}
+
+	category.Version++
+	return nil
+}
+
+func (r *categoryRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, "delete from categories where id = $1", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("category %s: %w", id, transaction.ErrCategoryNotFound)
+	}
+	return nil
+}
@@ -4,13 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"strings"
 
 	"github.com/muktihari/order-transaction-ddd/transaction"
 )
 
 type couponRepository struct {
-	db    *sql.DB
+	db    querier
 	table string
 }
 
@@ -20,23 +19,22 @@ func NewCouponRepository(db *sql.DB) transaction.CouponRepository {
 }
 
 func (r *couponRepository) FindByCode(ctx context.Context, code string) (*transaction.Coupon, error) {
-	stmt, err := r.db.PrepareContext(ctx, "select * from "+r.table+" where code = $1")
-	if err != nil {
-		return nil, err
-	}
-	sqlRows, err := stmt.Query(code)
+	sqlRows, err := r.db.QueryContext(ctx, "select * from "+r.table+" where code = $1", code)
 	if err != nil {
 		return nil, err
 	}
+	defer sqlRows.Close()
 
 	var coupon transaction.Coupon
-	if sqlRows.Next() == false {
-		return nil, transaction.ErrCouponNotFound
+	if !sqlRows.Next() {
+		if err := sqlRows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("coupon %s: %w", code, transaction.ErrCouponNotFound)
 	}
 	if err := Map(sqlRows, &coupon); err != nil {
 		return nil, err
 	}
-	sqlRows.Close()
 
 	return &coupon, nil
 }
@@ -46,32 +44,19 @@ func (r *couponRepository) Update(ctx context.Context, coupon *transaction.Coupo
 	if err != nil {
 		return err
 	}
+	if c.Version != coupon.Version {
+		return transaction.ErrConcurrentModification
+	}
 
 	diff, err := KeyValsDiff(c, coupon)
 	if err != nil {
 		return err
 	}
 
-	var (
-		columnValues []string
-		args         []interface{}
-	)
-	var i int
-	for key, val := range diff {
-		columnValues = append(columnValues, fmt.Sprintf("%v = $%d", key, i+1))
-		args = append(args, val)
-		i++
-	}
-
-	query := fmt.Sprintf("update %s set %s where code = %s", r.table, strings.Join(columnValues, ", "), c.Code)
-	stmt, err := r.db.PrepareContext(ctx, query)
-	if err != nil {
+	if err := updateVersioned(ctx, r.db, r.table, "code", coupon.Code, coupon.Version, diff); err != nil {
 		return err
 	}
+	coupon.Version++
 
-	if _, err := stmt.ExecContext(ctx, args...); err != nil {
-		return err
-	}
 	return nil
-
 }
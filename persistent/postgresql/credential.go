@@ -0,0 +1,40 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type credentialRepository struct {
+	db querier
+}
+
+// NewCredentialRepository creates new credential repository, credentials are stored in customer_credentials table
+func NewCredentialRepository(db *sql.DB) transaction.CredentialRepository {
+	return &credentialRepository{db}
+}
+
+func (r *credentialRepository) FindByCustomerID(ctx context.Context, customerID string) (*transaction.Credential, error) {
+	var c transaction.Credential
+	err := r.db.QueryRowContext(ctx, "select customer_id, password_hash from customer_credentials where customer_id = $1", customerID).
+		Scan(&c.CustomerID, &c.PasswordHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("credential of customer %s: %w", customerID, transaction.ErrCredentialNotFound)
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *credentialRepository) Store(ctx context.Context, credential *transaction.Credential) error {
+	_, err := r.db.ExecContext(ctx,
+		"insert into customer_credentials (customer_id, password_hash) values ($1, $2) on conflict (customer_id) do update set password_hash = excluded.password_hash",
+		credential.CustomerID, credential.PasswordHash,
+	)
+	return err
+}
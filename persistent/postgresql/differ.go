@@ -1,6 +1,9 @@
 package postgresql
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"reflect"
+)
 
 // KeyValsDiff get difference between two entities
 func KeyValsDiff(old, new interface{}) (map[string]interface{}, error) {
@@ -11,7 +14,7 @@ func KeyValsDiff(old, new interface{}) (map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &old); err != nil {
+	if err := json.Unmarshal(b, &mold); err != nil {
 		return nil, err
 	}
 
@@ -19,13 +22,13 @@ func KeyValsDiff(old, new interface{}) (map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, &old); err != nil {
+	if err := json.Unmarshal(b, &mnew); err != nil {
 		return nil, err
 	}
 
-	var keyVals map[string]interface{}
+	keyVals := make(map[string]interface{})
 	for key, val := range mnew {
-		if mold[key] != mnew[key] {
+		if !reflect.DeepEqual(mold[key], val) {
 			keyVals[key] = val
 		}
 	}
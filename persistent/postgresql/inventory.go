@@ -0,0 +1,57 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type inventoryRepository struct {
+	db querier
+}
+
+// NewInventoryRepository creates new inventory repository
+func NewInventoryRepository(db *sql.DB) transaction.InventoryRepository {
+	return &inventoryRepository{db}
+}
+
+func (r *inventoryRepository) Store(ctx context.Context, movement *transaction.InventoryMovement) error {
+	id := uuid.NewString()
+	_, err := r.db.ExecContext(ctx,
+		"insert into inventory_movements (id, product_id, sku, quantity, reason, actor, order_id, note, created_at) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		id, movement.ProductID, movement.SKU, movement.Quantity, movement.Reason, movement.Actor, movement.OrderID, movement.Note, movement.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	movement.ID = id
+	return nil
+}
+
+func (r *inventoryRepository) FindByProductID(ctx context.Context, productID string) ([]transaction.InventoryMovement, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"select id, product_id, sku, quantity, reason, actor, order_id, note, created_at from inventory_movements where product_id = $1 order by seq",
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := make([]transaction.InventoryMovement, 0)
+	for rows.Next() {
+		var m transaction.InventoryMovement
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.SKU, &m.Quantity, &m.Reason, &m.Actor, &m.OrderID, &m.Note, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
@@ -25,6 +25,11 @@ func Map(sqlRows *sql.Rows, v interface{}) error {
 
 	m := map[string]interface{}{}
 	for i, columnName := range columnNames {
+		// text and numeric are scanned as []byte, which would be encoded as base64 string
+		if b, ok := buff[i].([]byte); ok {
+			m[columnName] = string(b)
+			continue
+		}
 		m[columnName] = buff[i]
 	}
 
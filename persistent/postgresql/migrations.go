@@ -0,0 +1,19 @@
+package postgresql
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations returns versioned schema migrations of the postgresql repositories,
+// see sqlmigration package to apply them.
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic(err) // migrations directory is always embedded
+	}
+	return sub
+}
@@ -0,0 +1,8 @@
+alter table orders add column created_at timestamptz not null default now();
+
+-- listing is sorted by created_at and id, filters with equality on the prefix can use the sort
+create index orders_created_at on orders (created_at desc, id desc);
+create index orders_customer_created_at on orders (customer_id, created_at desc, id desc);
+create index orders_status_created_at on orders (status, created_at desc, id desc);
+create index orders_coupon_code on orders (coupon_code);
+create index order_items_product_id on order_items (product_id);
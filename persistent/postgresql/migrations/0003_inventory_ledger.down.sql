@@ -0,0 +1,4 @@
+drop table inventory_movements;
+drop function inventory_movements_immutable();
+
+alter table products drop column archived;
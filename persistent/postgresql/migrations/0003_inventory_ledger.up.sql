@@ -0,0 +1,30 @@
+alter table products add column archived boolean not null default false;
+
+-- the ledger is append only, seq keeps the order movements were stored in
+create table inventory_movements (
+	seq bigserial primary key,
+	id text not null unique,
+	product_id text not null references products (id),
+	quantity bigint not null,
+	reason text not null,
+	actor text not null,
+	order_id text not null default '',
+	note text not null default '',
+	created_at timestamptz not null
+);
+
+create index inventory_movements_product_id on inventory_movements (product_id, seq);
+
+create function inventory_movements_immutable() returns trigger as $$
+begin
+	raise exception 'inventory movements are immutable';
+end;
+$$ language plpgsql;
+
+create trigger inventory_movements_immutable before update or delete on inventory_movements
+	for each row execute function inventory_movements_immutable();
+
+-- products stocked before the ledger was kept get their stock as opening balance
+insert into inventory_movements (id, product_id, quantity, reason, actor, created_at)
+select 'opening_' || id, id, quantity, 'opening_balance', 'system', now()
+from products where quantity <> 0;
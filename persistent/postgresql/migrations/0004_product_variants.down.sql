@@ -0,0 +1,4 @@
+alter table inventory_movements drop column sku;
+alter table order_items drop column sku;
+
+drop table product_variants;
@@ -0,0 +1,14 @@
+-- product's quantity is kept as the sum of its variants' quantity, price overrides product's price when it's not null
+create table product_variants (
+	product_id text not null references products (id),
+	position integer not null,
+	sku text not null,
+	name text not null,
+	price numeric,
+	quantity bigint not null,
+	primary key (product_id, sku)
+);
+
+-- lines and movements of products without variants have empty sku
+alter table order_items add column sku text not null default '';
+alter table inventory_movements add column sku text not null default '';
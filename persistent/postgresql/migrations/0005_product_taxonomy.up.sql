@@ -0,0 +1,22 @@
+-- categories form a tree, root categories have no parent
+create table categories (
+	id text primary key,
+	name text not null,
+	parent_id text references categories (id),
+	version bigint not null default 0
+);
+
+create index categories_parent_id on categories (parent_id);
+
+alter table products add column category_id text references categories (id);
+
+create index products_category_id on products (category_id);
+
+-- tags are free-form and kept normalized by the product
+create table product_tags (
+	product_id text not null references products (id),
+	tag text not null,
+	primary key (product_id, tag)
+);
+
+create index product_tags_tag on product_tags (tag);
@@ -0,0 +1 @@
+alter table products add column reorder_threshold bigint not null default 0;
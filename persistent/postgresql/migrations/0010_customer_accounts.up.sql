@@ -0,0 +1,7 @@
+-- customers sign up by themselves, their contacts are unique and verified
+alter table customers add column email_verified boolean not null default false;
+alter table customers add column phone_verified boolean not null default false;
+alter table customers add column version bigint not null default 0;
+
+create unique index customers_email on customers (email);
+create unique index customers_phone_number on customers (phone_number);
@@ -0,0 +1 @@
+drop table customer_credentials;
@@ -0,0 +1,15 @@
+-- other systems authenticate with API keys, only their hash is kept, scopes are space separated like OAuth scopes
+create table api_keys (
+	id text primary key,
+	name text not null,
+	scopes text not null,
+	hash bytea not null,
+	created_by text not null references admins (id),
+	created_at timestamptz not null,
+	rotated_at timestamptz,
+	expires_at timestamptz not null,
+	revoked_at timestamptz,
+	version bigint not null default 0
+);
+
+create unique index api_keys_hash on api_keys (hash);
@@ -0,0 +1,35 @@
+-- orders keep the customer and the coupon as they were when ordered, later changes don't rewrite past orders
+alter table orders add column customer_name text not null default '';
+alter table orders add column customer_phone_number text not null default '';
+alter table orders add column customer_email text not null default '';
+alter table orders add column customer_address text not null default '';
+alter table orders add column customer_email_verified boolean not null default false;
+alter table orders add column customer_phone_verified boolean not null default false;
+alter table orders add column customer_version bigint not null default 0;
+alter table orders add column coupon_quantity integer;
+alter table orders add column coupon_amount numeric;
+alter table orders add column coupon_begin timestamptz;
+alter table orders add column coupon_end timestamptz;
+alter table orders add column coupon_type integer;
+alter table orders add column coupon_version bigint;
+
+update orders o set
+	customer_name = c.name,
+	customer_phone_number = c.phone_number,
+	customer_email = c.email,
+	customer_address = c.address,
+	customer_email_verified = c.email_verified,
+	customer_phone_verified = c.phone_verified,
+	customer_version = c.version
+from customers c
+where c.id = o.customer_id;
+
+update orders o set
+	coupon_quantity = cp.quantity,
+	coupon_amount = cp.amount,
+	coupon_begin = cp."begin",
+	coupon_end = cp."end",
+	coupon_type = cp.type,
+	coupon_version = cp.version
+from coupons cp
+where cp.code = o.coupon_code;
@@ -0,0 +1,2 @@
+-- the admin or the API key who shipped the order to the logistics partner
+alter table orders add column shipped_by text not null default '';
@@ -0,0 +1,23 @@
+-- cart items keep the product as it was when added to the order, later changes of the product don't rewrite past orders
+alter table order_items add column product_name text not null default '';
+alter table order_items add column product_description text not null default '';
+alter table order_items add column product_quantity bigint not null default 0;
+alter table order_items add column product_category_id text not null default '';
+alter table order_items add column product_reorder_threshold bigint not null default 0;
+alter table order_items add column product_backorder text not null default '';
+alter table order_items add column product_available_at timestamptz;
+alter table order_items add column product_archived boolean not null default false;
+alter table order_items add column product_version bigint not null default 0;
+
+update order_items i set
+	product_name = p.name,
+	product_description = p.description,
+	product_quantity = p.quantity,
+	product_category_id = coalesce(p.category_id, ''),
+	product_reorder_threshold = p.reorder_threshold,
+	product_backorder = p.backorder,
+	product_available_at = p.available_at,
+	product_archived = p.archived,
+	product_version = p.version
+from products p
+where p.id = i.product_id;
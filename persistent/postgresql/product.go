@@ -3,100 +3,276 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"strings"
 
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
 )
 
 type productRepository struct {
-	db    *sql.DB
-	table string
+	db querier
 }
 
-// NewProductRepository creates new product repository in memory
+// NewProductRepository creates new product repository, variants, tags and components of bundles
+// are stored in product_variants, product_tags and product_components tables
 func NewProductRepository(db *sql.DB) transaction.ProductRepository {
-	return &productRepository{db, "products"}
+	return &productRepository{db}
 }
 
-func (r *productRepository) FindByID(ctx context.Context, id string) (*transaction.Product, error) {
-	stmt, err := r.db.PrepareContext(ctx, "select * from "+r.table+" where id = $1")
-	if err != nil {
-		return nil, err
+const selectProduct = "select id, name, description, price, quantity, category_id, reorder_threshold, backorder, available_at, archived, version from products"
+
+const selectProductVariants = `select product_id, sku, name, price, quantity
+from product_variants
+where product_id = any($1)
+order by product_id, position`
+
+const selectProductTags = `select product_id, tag
+from product_tags
+where product_id = any($1)
+order by product_id, tag`
+
+const selectProductComponents = `select product_id, component_id, sku, quantity
+from product_components
+where product_id = any($1)
+order by product_id, position`
+
+func scanProduct(s interface{ Scan(...interface{}) error }, p *transaction.Product) error {
+	var categoryID sql.NullString
+	var availableAt sql.NullTime
+	if err := s.Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.Quantity, &categoryID, &p.ReorderThreshold, &p.Backorder, &availableAt, &p.Archived, &p.Version); err != nil {
+		return err
 	}
-	sqlRows, err := stmt.Query(id)
-	if err != nil {
-		return nil, err
+	p.CategoryID = categoryID.String
+	if availableAt.Valid {
+		t := availableAt.Time.UTC()
+		p.AvailableAt = &t
 	}
+	return nil
+}
 
+func (r *productRepository) FindByID(ctx context.Context, id string) (*transaction.Product, error) {
 	var p transaction.Product
-	if sqlRows.Next() == false {
-		return nil, transaction.ErrProductNotFound
-	}
-	if err := Map(sqlRows, &p); err != nil {
+	if err := scanProduct(r.db.QueryRowContext(ctx, selectProduct+" where id = $1", id), &p); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("product %s: %w", id, transaction.ErrProductNotFound)
+		}
 		return nil, err
 	}
-	sqlRows.Close()
 
-	return &p, nil
+	ps := []transaction.Product{p}
+	if err := r.loadDetails(ctx, ps); err != nil {
+		return nil, err
+	}
+	return &ps[0], nil
 }
 
 func (r *productRepository) FindAll(ctx context.Context) ([]transaction.Product, error) {
-	stmt, err := r.db.PrepareContext(ctx, "select * from "+r.table)
-	if err != nil {
-		return nil, err
-	}
-	sqlRows, err := stmt.Query(nil)
+	rows, err := r.db.QueryContext(ctx, selectProduct+" order by id")
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	var ps []transaction.Product
-	for sqlRows.Next() {
+	ps := make([]transaction.Product, 0)
+	for rows.Next() {
 		var p transaction.Product
-		if err := Map(sqlRows, &p); err != nil {
+		if err := scanProduct(rows, &p); err != nil {
 			return nil, err
 		}
 		ps = append(ps, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := r.loadDetails(ctx, ps); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
 
+// loadDetails fills the variants, the tags and the components of the products in one query each
+func (r *productRepository) loadDetails(ctx context.Context, ps []transaction.Product) error {
 	if len(ps) == 0 {
-		return nil, transaction.ErrProductNotFound
+		return nil
 	}
 
-	return ps, nil
+	ids := make([]string, len(ps))
+	byID := make(map[string]*transaction.Product, len(ps))
+	for i := range ps {
+		ids[i] = ps[i].ID
+		byID[ps[i].ID] = &ps[i]
+	}
+
+	if err := r.loadVariants(ctx, byID, ids); err != nil {
+		return err
+	}
+	if err := r.loadTags(ctx, byID, ids); err != nil {
+		return err
+	}
+	return r.loadComponents(ctx, byID, ids)
 }
 
-func (r *productRepository) Update(ctx context.Context, product *transaction.Product) error {
-	p, err := r.FindByID(ctx, product.ID)
+func (r *productRepository) loadVariants(ctx context.Context, byID map[string]*transaction.Product, ids []string) error {
+	rows, err := r.db.QueryContext(ctx, selectProductVariants, pq.Array(ids))
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	keyVals, err := KeyValsDiff(p, product)
+	for rows.Next() {
+		var (
+			productID string
+			v         transaction.Variant
+			price     decimal.NullDecimal
+		)
+		if err := rows.Scan(&productID, &v.SKU, &v.Name, &price, &v.Quantity); err != nil {
+			return err
+		}
+		if price.Valid {
+			v.Price = &price.Decimal
+		}
+		p := byID[productID]
+		p.Variants = append(p.Variants, v)
+	}
+	return rows.Err()
+}
+
+func (r *productRepository) loadTags(ctx context.Context, byID map[string]*transaction.Product, ids []string) error {
+	rows, err := r.db.QueryContext(ctx, selectProductTags, pq.Array(ids))
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	var (
-		columnValues []string
-		args         []interface{}
-	)
-	var i int
-	for key, val := range keyVals {
-		columnValues = append(columnValues, fmt.Sprintf("%v = $%d", key, i+1))
-		args = append(args, val)
-		i++
+	for rows.Next() {
+		var productID, tag string
+		if err := rows.Scan(&productID, &tag); err != nil {
+			return err
+		}
+		p := byID[productID]
+		p.Tags = append(p.Tags, tag)
 	}
+	return rows.Err()
+}
 
-	query := fmt.Sprintf("update %s set %s where id = %s", r.table, strings.Join(columnValues, ", "), p.ID)
-	stmt, err := r.db.PrepareContext(ctx, query)
+func (r *productRepository) loadComponents(ctx context.Context, byID map[string]*transaction.Product, ids []string) error {
+	rows, err := r.db.QueryContext(ctx, selectProductComponents, pq.Array(ids))
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			productID string
+			c         transaction.Component
+		)
+		if err := rows.Scan(&productID, &c.ProductID, &c.SKU, &c.Quantity); err != nil {
+			return err
+		}
+		p := byID[productID]
+		p.Components = append(p.Components, c)
+	}
+	return rows.Err()
+}
 
-	if _, err := stmt.ExecContext(ctx, args...); err != nil {
+func (r *productRepository) Store(ctx context.Context, product *transaction.Product) error {
+	id := uuid.NewString()
+
+	err := inTx(ctx, r.db, func(db querier) error {
+		_, err := db.ExecContext(ctx,
+			"insert into products (id, name, description, price, quantity, category_id, reorder_threshold, backorder, available_at, archived, version) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+			id, product.Name, product.Description, product.Price, product.Quantity, nullString(product.CategoryID), product.ReorderThreshold, product.Backorder, nullTime(product.AvailableAt), product.Archived, product.Version,
+		)
+		if err != nil {
+			return err
+		}
+		return insertProductDetails(ctx, db, id, product)
+	})
+	if err != nil {
 		return err
 	}
+
+	product.ID = id
+	return nil
+}
+
+func (r *productRepository) Update(ctx context.Context, product *transaction.Product) error {
+	err := inTx(ctx, r.db, func(db querier) error {
+		res, err := db.ExecContext(ctx,
+			"update products set name = $1, description = $2, price = $3, quantity = $4, category_id = $5, reorder_threshold = $6, backorder = $7, available_at = $8, archived = $9, version = version + 1 where id = $10 and version = $11",
+			product.Name, product.Description, product.Price, product.Quantity, nullString(product.CategoryID), product.ReorderThreshold, product.Backorder, nullTime(product.AvailableAt), product.Archived, product.ID, product.Version,
+		)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			var exists bool
+			if err := db.QueryRowContext(ctx, "select exists(select 1 from products where id = $1)", product.ID).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("product %s: %w", product.ID, transaction.ErrProductNotFound)
+			}
+			return fmt.Errorf("product %s: %w", product.ID, transaction.ErrConcurrentModification)
+		}
+
+		if _, err := db.ExecContext(ctx, "delete from product_variants where product_id = $1", product.ID); err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, "delete from product_tags where product_id = $1", product.ID); err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, "delete from product_components where product_id = $1", product.ID); err != nil {
+			return err
+		}
+		return insertProductDetails(ctx, db, product.ID, product)
+	})
+	if err != nil {
+		return err
+	}
+
+	product.Version++
+	return nil
+}
+
+// insertProductDetails inserts the variants, the tags and the components of the product
+func insertProductDetails(ctx context.Context, db querier, productID string, product *transaction.Product) error {
+	for i, v := range product.Variants {
+		var price decimal.NullDecimal
+		if v.Price != nil {
+			price = decimal.NullDecimal{Decimal: *v.Price, Valid: true}
+		}
+		_, err := db.ExecContext(ctx,
+			"insert into product_variants (product_id, position, sku, name, price, quantity) values ($1, $2, $3, $4, $5, $6)",
+			productID, i, v.SKU, v.Name, price, v.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	for _, tag := range product.Tags {
+		if _, err := db.ExecContext(ctx, "insert into product_tags (product_id, tag) values ($1, $2)", productID, tag); err != nil {
+			return err
+		}
+	}
+	for i, c := range product.Components {
+		_, err := db.ExecContext(ctx,
+			"insert into product_components (product_id, position, component_id, sku, quantity) values ($1, $2, $3, $4, $5)",
+			productID, i, c.ProductID, c.SKU, c.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
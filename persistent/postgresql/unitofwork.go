@@ -0,0 +1,79 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx, so repositories can run within or without a transaction
+type querier interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// inTx runs fn within a transaction, db is used as is when it's already a transaction
+func inTx(ctx context.Context, db querier, fn func(db querier) error) error {
+	sqlDB, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type unitOfWork struct {
+	db *sql.DB
+}
+
+// NewUnitOfWork creates new unit of work backed by sql transaction
+func NewUnitOfWork(db *sql.DB) transaction.UnitOfWork {
+	return &unitOfWork{db}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, r transaction.Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(ctx, &repositories{tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type repositories struct {
+	tx *sql.Tx
+}
+
+func (r *repositories) Orders() transaction.OrderRepository {
+	return &orderRepository{r.tx}
+}
+
+func (r *repositories) Products() transaction.ProductRepository {
+	return &productRepository{r.tx}
+}
+
+func (r *repositories) Coupons() transaction.CouponRepository {
+	return &couponRepository{r.tx, "coupons"}
+}
+
+func (r *repositories) Inventory() transaction.InventoryRepository {
+	return &inventoryRepository{r.tx}
+}
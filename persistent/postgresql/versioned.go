@@ -0,0 +1,44 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// updateVersioned updates the given columns of a row identified by keyColumn only if its stored version equals to version,
+// otherwise transaction.ErrConcurrentModification is returned. The version of the row is incremented.
+func updateVersioned(ctx context.Context, db querier, table, keyColumn string, key interface{}, version int64, keyVals map[string]interface{}) error {
+	var (
+		columnValues []string
+		args         []interface{}
+	)
+	for column, val := range keyVals {
+		if column == "version" {
+			continue
+		}
+		args = append(args, val)
+		columnValues = append(columnValues, fmt.Sprintf("%s = $%d", pq.QuoteIdentifier(column), len(args)))
+	}
+	columnValues = append(columnValues, "version = version + 1")
+	args = append(args, key, version)
+
+	query := fmt.Sprintf("update %s set %s where %s = $%d and version = $%d",
+		pq.QuoteIdentifier(table), strings.Join(columnValues, ", "), pq.QuoteIdentifier(keyColumn), len(args)-1, len(args))
+
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %v: %w", table, key, transaction.ErrConcurrentModification)
+	}
+	return nil
+}
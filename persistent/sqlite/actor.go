@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type customerRepository struct {
+	db querier
+}
+
+// NewCustomerRepository creates new customer repository
+func NewCustomerRepository(db *sql.DB) transaction.CustomerRepository {
+	return &customerRepository{db}
+}
+
+const selectCustomer = "select id, name, phone_number, email, address, email_verified, phone_verified, version from customers"
+
+func (r *customerRepository) FindByID(ctx context.Context, id string) (*transaction.Customer, error) {
+	return r.findOne(ctx, selectCustomer+" where id = ?", id)
+}
+
+func (r *customerRepository) FindByEmail(ctx context.Context, email string) (*transaction.Customer, error) {
+	return r.findOne(ctx, selectCustomer+" where email = ?", email)
+}
+
+func (r *customerRepository) findOne(ctx context.Context, query string, args ...interface{}) (*transaction.Customer, error) {
+	var c transaction.Customer
+	err := r.db.QueryRowContext(ctx, query, args...).
+		Scan(&c.ID, &c.Name, &c.PhoneNumber, &c.Email, &c.Address, &c.EmailVerified, &c.PhoneVerified, &c.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, transaction.ErrCustomerNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *customerRepository) Store(ctx context.Context, customer *transaction.Customer) error {
+	id := uuid.NewString()
+	_, err := r.db.ExecContext(ctx,
+		"insert into customers (id, name, phone_number, email, address, email_verified, phone_verified, version) values (?, ?, ?, ?, ?, ?, ?, ?)",
+		id, customer.Name, customer.PhoneNumber, customer.Email, customer.Address, customer.EmailVerified, customer.PhoneVerified, customer.Version,
+	)
+	if err != nil {
+		return customerConflict(err)
+	}
+	customer.ID = id
+	return nil
+}
+
+func (r *customerRepository) Update(ctx context.Context, customer *transaction.Customer) error {
+	res, err := r.db.ExecContext(ctx,
+		"update customers set name = ?, phone_number = ?, email = ?, address = ?, email_verified = ?, phone_verified = ?, version = version + 1 where id = ? and version = ?",
+		customer.Name, customer.PhoneNumber, customer.Email, customer.Address, customer.EmailVerified, customer.PhoneVerified, customer.ID, customer.Version,
+	)
+	if err != nil {
+		return customerConflict(err)
+	}
+	err = checkUpdated(ctx, r.db, res, "select exists(select 1 from customers where id = ?)", customer.ID, transaction.ErrCustomerNotFound)
+	if err != nil {
+		return err
+	}
+	customer.Version++
+	return nil
+}
+
+func (r *customerRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, "delete from customers where id = ?", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("customer %s: %w", id, transaction.ErrCustomerNotFound)
+	}
+	return nil
+}
+
+// customerConflict tells which unique contact the error of writing a customer violates, other errors are returned as is
+func customerConflict(err error) error {
+	switch msg := err.Error(); {
+	case strings.Contains(msg, "UNIQUE constraint failed: customers.email"):
+		return transaction.ErrEmailTaken
+	case strings.Contains(msg, "UNIQUE constraint failed: customers.phone_number"):
+		return transaction.ErrPhoneNumberTaken
+	default:
+		return err
+	}
+}
+
+type adminRepository struct {
+	db querier
+}
+
+// NewAdminRepository creates new admin repository
+func NewAdminRepository(db *sql.DB) transaction.AdminRepository {
+	return &adminRepository{db}
+}
+
+const selectAdmin = "select id, name, email, role, password_hash from admins"
+
+func (r *adminRepository) FindByID(ctx context.Context, id string) (*transaction.Admin, error) {
+	return r.findOne(ctx, selectAdmin+" where id = ?", id)
+}
+
+func (r *adminRepository) FindByEmail(ctx context.Context, email string) (*transaction.Admin, error) {
+	return r.findOne(ctx, selectAdmin+" where email = ?", email)
+}
+
+func (r *adminRepository) findOne(ctx context.Context, query string, args ...interface{}) (*transaction.Admin, error) {
+	var a transaction.Admin
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&a.ID, &a.Name, &a.Email, &a.Role, &a.PasswordHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, transaction.ErrAdminNotFound
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *adminRepository) Store(ctx context.Context, admin *transaction.Admin) error {
+	id := uuid.NewString()
+	_, err := r.db.ExecContext(ctx,
+		"insert into admins (id, name, email, role, password_hash) values (?, ?, ?, ?, ?)",
+		id, admin.Name, admin.Email, admin.Role, admin.PasswordHash,
+	)
+	if err != nil {
+		return adminConflict(err)
+	}
+	admin.ID = id
+	return nil
+}
+
+// adminConflict tells whether the error of writing an admin violates the unique email, other errors are returned as is
+func adminConflict(err error) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed: admins.email") {
+		return transaction.ErrAdminEmailTaken
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type apiKeyRepository struct {
+	db querier
+}
+
+// NewAPIKeyRepository creates new API key repository
+func NewAPIKeyRepository(db *sql.DB) transaction.APIKeyRepository {
+	return &apiKeyRepository{db}
+}
+
+const selectAPIKey = "select id, name, scopes, hash, created_by, created_at, rotated_at, expires_at, revoked_at, version from api_keys"
+
+func scanAPIKey(s interface{ Scan(...interface{}) error }, k *transaction.APIKey) error {
+	var scopes string
+	var rotatedAt, revokedAt sql.NullTime
+	if err := s.Scan(&k.ID, &k.Name, &scopes, &k.Hash, &k.CreatedBy, &k.CreatedAt, &rotatedAt, &k.ExpiresAt, &revokedAt, &k.Version); err != nil {
+		return err
+	}
+	k.Scopes = splitScopes(scopes)
+	k.CreatedAt = k.CreatedAt.UTC()
+	k.ExpiresAt = k.ExpiresAt.UTC()
+	if rotatedAt.Valid {
+		t := rotatedAt.Time.UTC()
+		k.RotatedAt = &t
+	}
+	if revokedAt.Valid {
+		t := revokedAt.Time.UTC()
+		k.RevokedAt = &t
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, id string) (*transaction.APIKey, error) {
+	return r.findOne(ctx, selectAPIKey+" where id = ?", id)
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, hash []byte) (*transaction.APIKey, error) {
+	return r.findOne(ctx, selectAPIKey+" where hash = ?", hash)
+}
+
+func (r *apiKeyRepository) findOne(ctx context.Context, query string, args ...interface{}) (*transaction.APIKey, error) {
+	var k transaction.APIKey
+	if err := scanAPIKey(r.db.QueryRowContext(ctx, query, args...), &k); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, transaction.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context) ([]transaction.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, selectAPIKey+" order by created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]transaction.APIKey, 0)
+	for rows.Next() {
+		var k transaction.APIKey
+		if err := scanAPIKey(rows, &k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepository) Store(ctx context.Context, key *transaction.APIKey) error {
+	id := uuid.NewString()
+	_, err := r.db.ExecContext(ctx,
+		"insert into api_keys (id, name, scopes, hash, created_by, created_at, rotated_at, expires_at, revoked_at, version) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, key.Name, joinScopes(key.Scopes), key.Hash, key.CreatedBy, key.CreatedAt.UTC(), nullTime(key.RotatedAt), key.ExpiresAt.UTC(), nullTime(key.RevokedAt), key.Version,
+	)
+	if err != nil {
+		return err
+	}
+	key.ID = id
+	return nil
+}
+
+func (r *apiKeyRepository) Update(ctx context.Context, key *transaction.APIKey) error {
+	res, err := r.db.ExecContext(ctx,
+		"update api_keys set name = ?, scopes = ?, hash = ?, rotated_at = ?, expires_at = ?, revoked_at = ?, version = version + 1 where id = ? and version = ?",
+		key.Name, joinScopes(key.Scopes), key.Hash, nullTime(key.RotatedAt), key.ExpiresAt.UTC(), nullTime(key.RevokedAt), key.ID, key.Version,
+	)
+	if err != nil {
+		return err
+	}
+	err = checkUpdated(ctx, r.db, res, "select exists(select 1 from api_keys where id = ?)", key.ID, transaction.ErrAPIKeyNotFound)
+	if err != nil {
+		return err
+	}
+	key.Version++
+	return nil
+}
+
+// joinScopes joins scopes separated by space to be stored in a single column
+func joinScopes(scopes []transaction.Permission) string {
+	s := make([]string, len(scopes))
+	for i := range scopes {
+		s[i] = string(scopes[i])
+	}
+	return strings.Join(s, " ")
+}
+
+func splitScopes(s string) []transaction.Permission {
+	fields := strings.Fields(s)
+	scopes := make([]transaction.Permission, len(fields))
+	for i := range fields {
+		scopes[i] = transaction.Permission(fields[i])
+	}
+	return scopes
+}
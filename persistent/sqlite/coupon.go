@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type couponRepository struct {
+	db querier
+}
+
+// NewCouponRepository creates new coupon repository
+func NewCouponRepository(db *sql.DB) transaction.CouponRepository {
+	return &couponRepository{db}
+}
+
+func (r *couponRepository) FindByCode(ctx context.Context, code string) (*transaction.Coupon, error) {
+	var c transaction.Coupon
+	err := r.db.QueryRowContext(ctx, `select code, quantity, amount, "begin", "end", type, version from coupons where code = ?`, code).
+		Scan(&c.Code, &c.Quantity, &c.Amount, &c.Begin, &c.End, &c.Type, &c.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("coupon %s: %w", code, transaction.ErrCouponNotFound)
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *couponRepository) Update(ctx context.Context, coupon *transaction.Coupon) error {
+	res, err := r.db.ExecContext(ctx,
+		`update coupons set quantity = ?, amount = ?, "begin" = ?, "end" = ?, type = ?, version = version + 1
+		where code = ? and version = ?`,
+		coupon.Quantity, coupon.Amount, coupon.Begin, coupon.End, coupon.Type, coupon.Code, coupon.Version,
+	)
+	if err != nil {
+		return err
+	}
+	err = checkUpdated(ctx, r.db, res, "select exists(select 1 from coupons where code = ?)", coupon.Code, transaction.ErrCouponNotFound)
+	if err != nil {
+		return err
+	}
+
+	coupon.Version++
+	return nil
+}
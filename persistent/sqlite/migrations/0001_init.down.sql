@@ -0,0 +1,6 @@
+drop table order_items;
+drop table orders;
+drop table coupons;
+drop table products;
+drop table admins;
+drop table customers;
@@ -0,0 +1,55 @@
+create table customers (
+	id text primary key,
+	name text not null,
+	phone_number text not null default '',
+	email text not null default '',
+	address text not null default ''
+);
+
+create table admins (
+	id text primary key,
+	name text not null
+);
+
+-- decimals are stored as text to keep their precision
+create table products (
+	id text primary key,
+	name text not null,
+	price text not null,
+	quantity integer not null,
+	version integer not null default 0
+);
+
+create table coupons (
+	code text primary key,
+	quantity integer not null,
+	amount text not null,
+	"begin" timestamp not null,
+	"end" timestamp not null,
+	type integer not null,
+	version integer not null default 0
+);
+
+create table orders (
+	id text primary key,
+	customer_id text not null references customers (id),
+	coupon_code text references coupons (code),
+	status integer not null,
+	price text not null default '0',
+	price_after_reduction text not null default '0',
+	payment_type integer not null default 0,
+	payment_name_holder text not null default '',
+	payment_identifier_id text not null default '',
+	payment_proof text not null default '',
+	shipping_id text not null default '',
+	version integer not null default 0
+);
+
+create table order_items (
+	order_id text not null references orders (id) on delete cascade,
+	position integer not null,
+	product_id text not null references products (id),
+	price text not null,
+	quantity integer not null,
+	primary key (order_id, position)
+);
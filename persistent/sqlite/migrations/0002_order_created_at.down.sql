@@ -0,0 +1,7 @@
+drop index order_items_product_id;
+drop index orders_coupon_code;
+drop index orders_status_created_at;
+drop index orders_customer_created_at;
+drop index orders_created_at;
+
+alter table orders drop column created_at;
@@ -0,0 +1,9 @@
+-- sqlite can only add a column with a constant default, orders created before have the epoch as creation time
+alter table orders add column created_at timestamp not null default '1970-01-01 00:00:00+00:00';
+
+-- listing is sorted by created_at and id, filters with equality on the prefix can use the sort
+create index orders_created_at on orders (created_at desc, id desc);
+create index orders_customer_created_at on orders (customer_id, created_at desc, id desc);
+create index orders_status_created_at on orders (status, created_at desc, id desc);
+create index orders_coupon_code on orders (coupon_code);
+create index order_items_product_id on order_items (product_id);
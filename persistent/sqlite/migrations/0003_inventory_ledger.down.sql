@@ -0,0 +1,6 @@
+drop trigger inventory_movements_no_delete;
+drop trigger inventory_movements_no_update;
+drop index inventory_movements_product_id;
+drop table inventory_movements;
+
+alter table products drop column archived;
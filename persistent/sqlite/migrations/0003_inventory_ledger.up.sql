@@ -0,0 +1,31 @@
+alter table products add column archived boolean not null default false;
+
+-- the ledger is append only, seq keeps the order movements were stored in
+create table inventory_movements (
+	seq integer primary key autoincrement,
+	id text not null unique,
+	product_id text not null references products (id),
+	quantity integer not null,
+	reason text not null,
+	actor text not null,
+	order_id text not null default '',
+	note text not null default '',
+	created_at timestamp not null
+);
+
+create index inventory_movements_product_id on inventory_movements (product_id, seq);
+
+create trigger inventory_movements_no_update before update on inventory_movements
+begin
+	select raise(abort, 'inventory movements are immutable');
+end;
+
+create trigger inventory_movements_no_delete before delete on inventory_movements
+begin
+	select raise(abort, 'inventory movements are immutable');
+end;
+
+-- products stocked before the ledger was kept get their stock as opening balance
+insert into inventory_movements (id, product_id, quantity, reason, actor, created_at)
+select 'opening_' || id, id, quantity, 'opening_balance', 'system', strftime('%Y-%m-%d %H:%M:%f', 'now')
+from products where quantity <> 0;
@@ -0,0 +1,8 @@
+drop index product_tags_tag;
+drop table product_tags;
+
+drop index products_category_id;
+alter table products drop column category_id;
+
+drop index categories_parent_id;
+drop table categories;
@@ -0,0 +1 @@
+alter table products add column description text not null default '';
@@ -0,0 +1 @@
+alter table products add column reorder_threshold integer not null default 0;
@@ -0,0 +1,4 @@
+alter table order_items drop column backordered;
+
+alter table products drop column available_at;
+alter table products drop column backorder;
@@ -0,0 +1,6 @@
+-- products allowed to be ordered beyond their stock, available_at is when a pre-order product is expected in stock
+alter table products add column backorder text not null default '';
+alter table products add column available_at timestamp;
+
+-- the part of the ordered quantity waiting for the product to be restocked
+alter table order_items add column backordered integer not null default 0;
@@ -0,0 +1,2 @@
+drop table order_item_components;
+drop table product_components;
@@ -0,0 +1,20 @@
+-- a product having components is a bundle, its stock is made up of the stock of its components
+create table product_components (
+	product_id text not null references products (id),
+	position integer not null,
+	component_id text not null references products (id),
+	sku text not null default '',
+	quantity integer not null,
+	primary key (product_id, component_id, sku)
+);
+
+-- components of a bundle line as they were reserved on submit
+create table order_item_components (
+	order_id text not null references orders (id),
+	item_position integer not null,
+	position integer not null,
+	product_id text not null references products (id),
+	sku text not null default '',
+	quantity integer not null,
+	primary key (order_id, item_position, position)
+);
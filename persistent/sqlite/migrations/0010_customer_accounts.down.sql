@@ -0,0 +1,6 @@
+drop index customers_phone_number;
+drop index customers_email;
+
+alter table customers drop column version;
+alter table customers drop column phone_verified;
+alter table customers drop column email_verified;
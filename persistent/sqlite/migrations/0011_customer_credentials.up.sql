@@ -0,0 +1,5 @@
+-- only the bcrypt hash of the password is kept, apart from the customer
+create table customer_credentials (
+	customer_id text primary key references customers (id),
+	password_hash blob not null
+);
@@ -0,0 +1,5 @@
+drop index admins_email;
+
+alter table admins drop column password_hash;
+alter table admins drop column role;
+alter table admins drop column email;
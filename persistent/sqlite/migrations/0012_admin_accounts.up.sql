@@ -0,0 +1,6 @@
+-- admins log in with their email and are given a role, admins existing before can not log in until they're given an email and a password
+alter table admins add column email text not null default '';
+alter table admins add column role text not null default 'viewer';
+alter table admins add column password_hash blob not null default x'';
+
+create unique index admins_email on admins (email) where email <> '';
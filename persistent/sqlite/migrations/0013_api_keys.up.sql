@@ -0,0 +1,15 @@
+-- other systems authenticate with API keys, only their hash is kept, scopes are space separated like OAuth scopes
+create table api_keys (
+	id text primary key,
+	name text not null,
+	scopes text not null,
+	hash blob not null,
+	created_by text not null references admins (id),
+	created_at timestamp not null,
+	rotated_at timestamp,
+	expires_at timestamp not null,
+	revoked_at timestamp,
+	version integer not null default 0
+);
+
+create unique index api_keys_hash on api_keys (hash);
@@ -0,0 +1,13 @@
+alter table orders drop column coupon_version;
+alter table orders drop column coupon_type;
+alter table orders drop column coupon_end;
+alter table orders drop column coupon_begin;
+alter table orders drop column coupon_amount;
+alter table orders drop column coupon_quantity;
+alter table orders drop column customer_version;
+alter table orders drop column customer_phone_verified;
+alter table orders drop column customer_email_verified;
+alter table orders drop column customer_address;
+alter table orders drop column customer_email;
+alter table orders drop column customer_phone_number;
+alter table orders drop column customer_name;
@@ -0,0 +1 @@
+drop index orders_shipping_id;
@@ -0,0 +1,2 @@
+-- customers check the shipment of their orders by its shipping ID
+create index orders_shipping_id on orders (shipping_id);
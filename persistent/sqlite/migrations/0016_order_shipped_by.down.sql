@@ -0,0 +1 @@
+alter table orders drop column shipped_by;
@@ -0,0 +1,9 @@
+alter table order_items drop column product_version;
+alter table order_items drop column product_archived;
+alter table order_items drop column product_available_at;
+alter table order_items drop column product_backorder;
+alter table order_items drop column product_reorder_threshold;
+alter table order_items drop column product_category_id;
+alter table order_items drop column product_quantity;
+alter table order_items drop column product_description;
+alter table order_items drop column product_name;
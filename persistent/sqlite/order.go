@@ -0,0 +1,385 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+)
+
+type orderRepository struct {
+	db querier
+}
+
+// NewOrderRepository creates new order repository, cart items are stored in order_items table
+// and the components reserved for bundle lines in order_item_components table
+func NewOrderRepository(db *sql.DB) transaction.OrderRepository {
+	return &orderRepository{db}
+}
+
+const selectOrder = `select
+	o.id, o.status, o.price, o.price_after_reduction,
+	o.payment_type, o.payment_name_holder, o.payment_identifier_id, o.payment_proof,
+	o.shipping_id, o.shipped_by, o.created_at, o.version,
+	o.customer_id, o.customer_name, o.customer_phone_number, o.customer_email, o.customer_address,
+	o.customer_email_verified, o.customer_phone_verified, o.customer_version,
+	o.coupon_code, o.coupon_quantity, o.coupon_amount, o.coupon_begin, o.coupon_end, o.coupon_type, o.coupon_version
+from orders o`
+
+const selectOrderItems = `select i.order_id, i.product_id, i.product_name, i.product_description, i.price, i.product_quantity,
+	i.product_category_id, i.product_reorder_threshold, i.product_backorder, i.product_available_at, i.product_archived, i.product_version,
+	i.sku, i.quantity, i.backordered
+from order_items i
+where i.order_id in (%s)
+order by i.order_id, i.position`
+
+const selectOrderItemComponents = `select order_id, item_position, product_id, sku, quantity
+from order_item_components
+where order_id in (%s)
+order by order_id, item_position, position`
+
+func (r *orderRepository) FindByID(ctx context.Context, id string) (*transaction.Order, error) {
+	o, err := scanOrder(r.db.QueryRowContext(ctx, selectOrder+" where o.id = ?", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("order %s: %w", id, transaction.ErrOrderNotFound)
+		}
+		return nil, err
+	}
+
+	orders := []transaction.Order{*o}
+	if err := r.loadCarts(ctx, orders); err != nil {
+		return nil, err
+	}
+	return &orders[0], nil
+}
+
+func (r *orderRepository) Find(ctx context.Context, filter transaction.OrderFilter, page transaction.Page) (*transaction.OrderPage, error) {
+	cursor, err := transaction.DecodeOrderCursor(page.Cursor, filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "?"
+	}
+
+	if filter.CustomerID != "" {
+		where = append(where, "o.customer_id = "+arg(filter.CustomerID))
+	}
+	if len(filter.Statuses) != 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = arg(status)
+		}
+		where = append(where, "o.status in ("+strings.Join(statuses, ", ")+")")
+	}
+	if !filter.CreatedFrom.IsZero() {
+		where = append(where, "o.created_at >= "+arg(filter.CreatedFrom.UTC()))
+	}
+	if !filter.CreatedTo.IsZero() {
+		where = append(where, "o.created_at < "+arg(filter.CreatedTo.UTC()))
+	}
+	if filter.CouponCode != "" {
+		where = append(where, "o.coupon_code = "+arg(filter.CouponCode))
+	}
+	if filter.ShippingID != "" {
+		where = append(where, "o.shipping_id = "+arg(filter.ShippingID))
+	}
+	if filter.ProductID != "" {
+		where = append(where, "exists(select 1 from order_items i where i.order_id = o.id and i.product_id = "+arg(filter.ProductID)+")")
+	}
+
+	direction, op := "desc", "<"
+	if filter.Sort == transaction.OrderSortOldest {
+		direction, op = "asc", ">"
+	}
+	if cursor != nil {
+		where = append(where, fmt.Sprintf("(o.created_at, o.id) %s (%s, %s)", op, arg(cursor.CreatedAt.UTC()), arg(cursor.ID)))
+	}
+
+	query := selectOrder
+	if len(where) != 0 {
+		query += " where " + strings.Join(where, " and ")
+	}
+	query += fmt.Sprintf(" order by o.created_at %s, o.id %s limit %s", direction, direction, arg(page.Size()+1))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]transaction.Order, 0)
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := r.loadCarts(ctx, orders); err != nil {
+		return nil, err
+	}
+	return transaction.NewOrderPage(orders, filter, page), nil
+}
+
+// scanOrder scans a row of selectOrder, cart is left empty
+func scanOrder(row interface{ Scan(...interface{}) error }) (*transaction.Order, error) {
+	var (
+		o transaction.Order
+
+		couponCode     sql.NullString
+		couponQuantity sql.NullInt64
+		couponAmount   decimal.NullDecimal
+		couponBegin    sql.NullTime
+		couponEnd      sql.NullTime
+		couponType     sql.NullInt64
+		couponVersion  sql.NullInt64
+	)
+
+	err := row.Scan(
+		&o.ID, &o.Status, &o.Price, &o.PriceAfterReduction,
+		&o.PaymentSpecification.Type, &o.PaymentSpecification.NameHolder,
+		&o.PaymentSpecification.IdentifierID, &o.PaymentSpecification.Proof,
+		&o.ShippingID, &o.ShippedBy, &o.CreatedAt, &o.Version,
+		&o.Customer.ID, &o.Customer.Name, &o.Customer.PhoneNumber, &o.Customer.Email, &o.Customer.Address,
+		&o.Customer.EmailVerified, &o.Customer.PhoneVerified, &o.Customer.Version,
+		&couponCode, &couponQuantity, &couponAmount, &couponBegin, &couponEnd, &couponType, &couponVersion,
+	)
+	if err != nil {
+		return nil, err
+	}
+	o.CreatedAt = o.CreatedAt.UTC()
+
+	if couponCode.Valid {
+		o.Coupon = transaction.Coupon{
+			Code:     couponCode.String,
+			Quantity: int(couponQuantity.Int64),
+			Amount:   couponAmount.Decimal,
+			Begin:    couponBegin.Time,
+			End:      couponEnd.Time,
+			Type:     transaction.CouponType(couponType.Int64),
+			Version:  couponVersion.Int64,
+		}
+	}
+
+	o.Cart = []transaction.CartItem{}
+	return &o, nil
+}
+
+// loadCarts fills the cart of the orders with their items in one query
+func (r *orderRepository) loadCarts(ctx context.Context, orders []transaction.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, len(orders))
+	byID := make(map[string]*transaction.Order, len(orders))
+	for i := range orders {
+		ids[i] = orders[i].ID
+		byID[orders[i].ID] = &orders[i]
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(selectOrderItems, placeholders), ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			orderID     string
+			p           transaction.Product
+			sku         string
+			quantity    int64
+			backordered int64
+			availableAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&orderID, &p.ID, &p.Name, &p.Description, &p.Price, &p.Quantity,
+			&p.CategoryID, &p.ReorderThreshold, &p.Backorder, &availableAt, &p.Archived, &p.Version,
+			&sku, &quantity, &backordered,
+		); err != nil {
+			return err
+		}
+		if availableAt.Valid {
+			t := availableAt.Time.UTC()
+			p.AvailableAt = &t
+		}
+		o := byID[orderID]
+		o.Cart = append(o.Cart, transaction.CartItem{Product: &p, SKU: sku, Quantity: quantity, Backordered: backordered})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	// components reserved for bundle lines, the position of a line is its index in the cart
+	rows, err = r.db.QueryContext(ctx, fmt.Sprintf(selectOrderItemComponents, placeholders), ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			orderID  string
+			position int
+			c        transaction.Component
+		)
+		if err := rows.Scan(&orderID, &position, &c.ProductID, &c.SKU, &c.Quantity); err != nil {
+			return err
+		}
+		o := byID[orderID]
+		if position < len(o.Cart) {
+			o.Cart[position].Components = append(o.Cart[position].Components, c)
+		}
+	}
+	return rows.Err()
+}
+
+func (r *orderRepository) Store(ctx context.Context, order *transaction.Order) error {
+	id := uuid.NewString()
+
+	err := inTx(ctx, r.db, func(db querier) error {
+		_, err := db.ExecContext(ctx, `insert into orders (
+			id, customer_id, coupon_code, status, price, price_after_reduction,
+			payment_type, payment_name_holder, payment_identifier_id, payment_proof,
+			shipping_id, shipped_by, created_at, version,
+			customer_name, customer_phone_number, customer_email, customer_address,
+			customer_email_verified, customer_phone_verified, customer_version,
+			coupon_quantity, coupon_amount, coupon_begin, coupon_end, coupon_type, coupon_version
+		) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			append([]interface{}{
+				id, order.Customer.ID, nullString(order.Coupon.Code), order.Status, order.Price, order.PriceAfterReduction,
+				order.PaymentSpecification.Type, order.PaymentSpecification.NameHolder,
+				order.PaymentSpecification.IdentifierID, order.PaymentSpecification.Proof,
+				order.ShippingID, order.ShippedBy, order.CreatedAt.UTC(), order.Version,
+			}, snapshotArgs(order)...)...,
+		)
+		if err != nil {
+			return err
+		}
+		return insertOrderItems(ctx, db, id, order.Cart)
+	})
+	if err != nil {
+		return err
+	}
+
+	order.ID = id
+	return nil
+}
+
+func (r *orderRepository) Update(ctx context.Context, order *transaction.Order) error {
+	err := inTx(ctx, r.db, func(db querier) error {
+		res, err := db.ExecContext(ctx, `update orders set
+			customer_id = ?, coupon_code = ?, status = ?, price = ?, price_after_reduction = ?,
+			payment_type = ?, payment_name_holder = ?, payment_identifier_id = ?, payment_proof = ?,
+			shipping_id = ?, shipped_by = ?,
+			customer_name = ?, customer_phone_number = ?, customer_email = ?, customer_address = ?,
+			customer_email_verified = ?, customer_phone_verified = ?, customer_version = ?,
+			coupon_quantity = ?, coupon_amount = ?, coupon_begin = ?, coupon_end = ?, coupon_type = ?, coupon_version = ?,
+			version = version + 1
+		where id = ? and version = ?`,
+			append(append([]interface{}{
+				order.Customer.ID, nullString(order.Coupon.Code), order.Status, order.Price, order.PriceAfterReduction,
+				order.PaymentSpecification.Type, order.PaymentSpecification.NameHolder,
+				order.PaymentSpecification.IdentifierID, order.PaymentSpecification.Proof,
+				order.ShippingID, order.ShippedBy,
+			}, snapshotArgs(order)...), order.ID, order.Version)...,
+		)
+		if err != nil {
+			return err
+		}
+		err = checkUpdated(ctx, db, res, "select exists(select 1 from orders where id = ?)", order.ID, transaction.ErrOrderNotFound)
+		if err != nil {
+			return err
+		}
+
+		if _, err := db.ExecContext(ctx, "delete from order_item_components where order_id = ?", order.ID); err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, "delete from order_items where order_id = ?", order.ID); err != nil {
+			return err
+		}
+		return insertOrderItems(ctx, db, order.ID, order.Cart)
+	})
+	if err != nil {
+		return err
+	}
+
+	order.Version++
+	return nil
+}
+
+// insertOrderItems inserts the cart items of the order, each keeps its product as it was when added to the cart
+func insertOrderItems(ctx context.Context, db querier, orderID string, cart []transaction.CartItem) error {
+	for i, cartItem := range cart {
+		p := cartItem.Product
+		_, err := db.ExecContext(ctx,
+			`insert into order_items (
+				order_id, position, product_id, sku, price, quantity, backordered,
+				product_name, product_description, product_quantity, product_category_id, product_reorder_threshold,
+				product_backorder, product_available_at, product_archived, product_version
+			) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			orderID, i, p.ID, cartItem.SKU, p.Price, cartItem.Quantity, cartItem.Backordered,
+			p.Name, p.Description, p.Quantity, p.CategoryID, p.ReorderThreshold,
+			p.Backorder, nullTime(p.AvailableAt), p.Archived, p.Version,
+		)
+		if err != nil {
+			return err
+		}
+		for j, c := range cartItem.Components {
+			_, err := db.ExecContext(ctx,
+				"insert into order_item_components (order_id, item_position, position, product_id, sku, quantity) values (?, ?, ?, ?, ?, ?)",
+				orderID, i, j, c.ProductID, c.SKU, c.Quantity,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotArgs returns the values of the customer and the coupon columns of the order, the coupon ones are null without coupon
+func snapshotArgs(order *transaction.Order) []interface{} {
+	args := []interface{}{
+		order.Customer.Name, order.Customer.PhoneNumber, order.Customer.Email, order.Customer.Address,
+		order.Customer.EmailVerified, order.Customer.PhoneVerified, order.Customer.Version,
+	}
+	if !order.HasCoupon() {
+		return append(args, nil, nil, nil, nil, nil, nil)
+	}
+	c := order.Coupon
+	return append(args, c.Quantity, c.Amount, c.Begin.UTC(), c.End.UTC(), c.Type, c.Version)
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
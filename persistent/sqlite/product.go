@@ -0,0 +1,269 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+)
+
+type productRepository struct {
+	db querier
+}
+
+// NewProductRepository creates new product repository, variants, tags and components of bundles
+// are stored in product_variants, product_tags and product_components tables
+func NewProductRepository(db *sql.DB) transaction.ProductRepository {
+	return &productRepository{db}
+}
+
+const selectProduct = "select id, name, description, price, quantity, category_id, reorder_threshold, backorder, available_at, archived, version from products"
+
+const selectProductVariants = `select product_id, sku, name, price, quantity
+from product_variants
+where product_id in (%s)
+order by product_id, position`
+
+const selectProductTags = `select product_id, tag
+from product_tags
+where product_id in (%s)
+order by product_id, tag`
+
+const selectProductComponents = `select product_id, component_id, sku, quantity
+from product_components
+where product_id in (%s)
+order by product_id, position`
+
+func scanProduct(s interface{ Scan(...interface{}) error }, p *transaction.Product) error {
+	var categoryID sql.NullString
+	var availableAt sql.NullTime
+	if err := s.Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.Quantity, &categoryID, &p.ReorderThreshold, &p.Backorder, &availableAt, &p.Archived, &p.Version); err != nil {
+		return err
+	}
+	p.CategoryID = categoryID.String
+	if availableAt.Valid {
+		t := availableAt.Time.UTC()
+		p.AvailableAt = &t
+	}
+	return nil
+}
+
+func (r *productRepository) FindByID(ctx context.Context, id string) (*transaction.Product, error) {
+	var p transaction.Product
+	if err := scanProduct(r.db.QueryRowContext(ctx, selectProduct+" where id = ?", id), &p); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("product %s: %w", id, transaction.ErrProductNotFound)
+		}
+		return nil, err
+	}
+
+	products := []transaction.Product{p}
+	if err := r.loadDetails(ctx, products); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+func (r *productRepository) FindAll(ctx context.Context) ([]transaction.Product, error) {
+	rows, err := r.db.QueryContext(ctx, selectProduct+" order by id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]transaction.Product, 0)
+	for rows.Next() {
+		var p transaction.Product
+		if err := scanProduct(rows, &p); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := r.loadDetails(ctx, products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// loadDetails fills the variants, the tags and the components of the products in one query each
+func (r *productRepository) loadDetails(ctx context.Context, products []transaction.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, len(products))
+	byID := make(map[string]*transaction.Product, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+		byID[products[i].ID] = &products[i]
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	if err := r.loadVariants(ctx, byID, placeholders, ids); err != nil {
+		return err
+	}
+	if err := r.loadTags(ctx, byID, placeholders, ids); err != nil {
+		return err
+	}
+	return r.loadComponents(ctx, byID, placeholders, ids)
+}
+
+func (r *productRepository) loadVariants(ctx context.Context, byID map[string]*transaction.Product, placeholders string, ids []interface{}) error {
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(selectProductVariants, placeholders), ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			productID string
+			v         transaction.Variant
+			price     decimal.NullDecimal
+		)
+		if err := rows.Scan(&productID, &v.SKU, &v.Name, &price, &v.Quantity); err != nil {
+			return err
+		}
+		if price.Valid {
+			v.Price = &price.Decimal
+		}
+		p := byID[productID]
+		p.Variants = append(p.Variants, v)
+	}
+	return rows.Err()
+}
+
+func (r *productRepository) loadTags(ctx context.Context, byID map[string]*transaction.Product, placeholders string, ids []interface{}) error {
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(selectProductTags, placeholders), ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID, tag string
+		if err := rows.Scan(&productID, &tag); err != nil {
+			return err
+		}
+		p := byID[productID]
+		p.Tags = append(p.Tags, tag)
+	}
+	return rows.Err()
+}
+
+func (r *productRepository) loadComponents(ctx context.Context, byID map[string]*transaction.Product, placeholders string, ids []interface{}) error {
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(selectProductComponents, placeholders), ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			productID string
+			c         transaction.Component
+		)
+		if err := rows.Scan(&productID, &c.ProductID, &c.SKU, &c.Quantity); err != nil {
+			return err
+		}
+		p := byID[productID]
+		p.Components = append(p.Components, c)
+	}
+	return rows.Err()
+}
+
+func (r *productRepository) Store(ctx context.Context, product *transaction.Product) error {
+	id := uuid.NewString()
+
+	err := inTx(ctx, r.db, func(db querier) error {
+		_, err := db.ExecContext(ctx,
+			"insert into products (id, name, description, price, quantity, category_id, reorder_threshold, backorder, available_at, archived, version) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			id, product.Name, product.Description, product.Price, product.Quantity, nullString(product.CategoryID), product.ReorderThreshold, product.Backorder, nullTime(product.AvailableAt), product.Archived, product.Version,
+		)
+		if err != nil {
+			return err
+		}
+		return insertProductDetails(ctx, db, id, product)
+	})
+	if err != nil {
+		return err
+	}
+
+	product.ID = id
+	return nil
+}
+
+func (r *productRepository) Update(ctx context.Context, product *transaction.Product) error {
+	err := inTx(ctx, r.db, func(db querier) error {
+		res, err := db.ExecContext(ctx,
+			"update products set name = ?, description = ?, price = ?, quantity = ?, category_id = ?, reorder_threshold = ?, backorder = ?, available_at = ?, archived = ?, version = version + 1 where id = ? and version = ?",
+			product.Name, product.Description, product.Price, product.Quantity, nullString(product.CategoryID), product.ReorderThreshold, product.Backorder, nullTime(product.AvailableAt), product.Archived, product.ID, product.Version,
+		)
+		if err != nil {
+			return err
+		}
+		err = checkUpdated(ctx, db, res, "select exists(select 1 from products where id = ?)", product.ID, transaction.ErrProductNotFound)
+		if err != nil {
+			return err
+		}
+
+		if _, err := db.ExecContext(ctx, "delete from product_variants where product_id = ?", product.ID); err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, "delete from product_tags where product_id = ?", product.ID); err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, "delete from product_components where product_id = ?", product.ID); err != nil {
+			return err
+		}
+		return insertProductDetails(ctx, db, product.ID, product)
+	})
+	if err != nil {
+		return err
+	}
+
+	product.Version++
+	return nil
+}
+
+// insertProductDetails inserts the variants, the tags and the components of the product
+func insertProductDetails(ctx context.Context, db querier, productID string, product *transaction.Product) error {
+	for i, v := range product.Variants {
+		var price decimal.NullDecimal
+		if v.Price != nil {
+			price = decimal.NullDecimal{Decimal: *v.Price, Valid: true}
+		}
+		_, err := db.ExecContext(ctx,
+			"insert into product_variants (product_id, position, sku, name, price, quantity) values (?, ?, ?, ?, ?, ?)",
+			productID, i, v.SKU, v.Name, price, v.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	for _, tag := range product.Tags {
+		if _, err := db.ExecContext(ctx, "insert into product_tags (product_id, tag) values (?, ?)", productID, tag); err != nil {
+			return err
+		}
+	}
+	for i, c := range product.Components {
+		_, err := db.ExecContext(ctx,
+			"insert into product_components (product_id, position, component_id, sku, quantity) values (?, ?, ?, ?, ?)",
+			productID, i, c.ProductID, c.SKU, c.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
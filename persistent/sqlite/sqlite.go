@@ -0,0 +1,90 @@
+// Package sqlite implements transaction repositories on an embedded SQLite database,
+// it uses a pure Go driver so the application can still be built with CGO disabled.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/url"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+
+	_ "modernc.org/sqlite" // register sqlite driver
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations returns versioned schema migrations of the sqlite repositories,
+// see sqlmigration package to apply them.
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic(err) // migrations directory is always embedded
+	}
+	return sub
+}
+
+// Open opens sqlite database file at path. Foreign keys are enforced, WAL journal mode is used so
+// readers do not block writer and transactions take the write lock immediately to avoid deadlock on upgrade.
+// Times are written in sqlite format, so UTC times can be compared as text. The busy timeout is set first,
+// so switching a new database to WAL waits for other processes opening it at the same time.
+func Open(path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?" + url.Values{
+		"_pragma":      []string{"busy_timeout(5000)", "foreign_keys(1)", "journal_mode(WAL)"},
+		"_txlock":      []string{"immediate"},
+		"_time_format": []string{"sqlite"},
+	}.Encode()
+	return sql.Open("sqlite", dsn)
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx, so repositories can run within or without a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// inTx runs fn within a transaction, db is used as is when it's already a transaction
+func inTx(ctx context.Context, db querier, fn func(db querier) error) error {
+	sqlDB, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkUpdated tells whether a versioned update has changed a row, when it has not, exists query
+// is run to tell whether the row has been changed since it was loaded or it does not exist at all.
+func checkUpdated(ctx context.Context, db querier, res sql.Result, exists string, key interface{}, errNotFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 0 {
+		return nil
+	}
+
+	var found bool
+	if err := db.QueryRowContext(ctx, exists, key).Scan(&found); err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%v: %w", key, errNotFound)
+	}
+	return fmt.Errorf("%v: %w", key, transaction.ErrConcurrentModification)
+}
@@ -0,0 +1,250 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/muktihari/order-transaction-ddd/persistent/sqlite"
+	"github.com/muktihari/order-transaction-ddd/persistent/sqlmigration"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/muktihari/order-transaction-ddd/transaction/repotest"
+	"github.com/shopspring/decimal"
+)
+
+// openDB creates sqlite database in a temporary directory with migrations applied and seed data
+func openDB(t *testing.T, data repotest.Seed) *sql.DB {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("could not open sqlite: %v", err)
+	}
+
+	migrator, err := sqlmigration.New(db, sqlite.Migrations())
+	if err != nil {
+		t.Fatalf("could not read migrations: %v", err)
+	}
+	if err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("could not migrate: %v", err)
+	}
+
+	if err := seed(db, data); err != nil {
+		t.Fatalf("could not seed: %v", err)
+	}
+
+	return db
+}
+
+func TestOrderRepository(t *testing.T) {
+	db := openDB(t, repotest.DefaultSeed())
+	defer db.Close()
+
+	var (
+		customers = sqlite.NewCustomerRepository(db)
+		products  = sqlite.NewProductRepository(db)
+		coupons   = sqlite.NewCouponRepository(db)
+		orders    = sqlite.NewOrderRepository(db)
+	)
+
+	ctx := context.Background()
+	c, err := customers.FindByID(ctx, "CUSTOMER1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	p, err := products.FindByID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	coupon, err := coupons.FindByCode(ctx, "DISCOUNT_20%")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	o := transaction.NewOrder(c)
+	if err := orders.Store(ctx, o); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := o.AddProduct(p, "", 5); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := o.ApplyCoupon(*coupon); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := orders.Update(ctx, o); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	found, err := orders.FindByID(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if found.Version != 1 {
+		t.Errorf("got version %d, expected %d", found.Version, 1)
+	}
+	if len(found.Cart) != 1 || found.Cart[0].Product.ID != "PRODUCT1" || found.Cart[0].Quantity != 5 {
+		t.Errorf("got cart %+v, expected 5 of PRODUCT1", found.Cart)
+	}
+	if !found.Price.Equal(decimal.NewFromInt(2500)) || !found.PriceAfterReduction.Equal(decimal.NewFromInt(2000)) {
+		t.Errorf("got price %s and %s, expected 2500 and 2000", found.Price, found.PriceAfterReduction)
+	}
+	if found.Coupon.Code != "DISCOUNT_20%" || found.Customer.ID != "CUSTOMER1" {
+		t.Errorf("got coupon %q and customer %q", found.Coupon.Code, found.Customer.ID)
+	}
+
+	// the order keeps the customer and the coupon as they were when ordered
+	c.Address = "New Address"
+	if err := customers.Update(ctx, c); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := coupon.Use(); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := coupons.Update(ctx, coupon); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	found, err = orders.FindByID(ctx, o.ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if found.Customer != o.Customer {
+		t.Errorf("got customer %+v, expected %+v", found.Customer, o.Customer)
+	}
+	if found.Coupon.Quantity != o.Coupon.Quantity || found.Coupon.Version != o.Coupon.Version || !found.Coupon.Amount.Equal(o.Coupon.Amount) {
+		t.Errorf("got coupon %+v, expected %+v", found.Coupon, o.Coupon)
+	}
+
+	stale := *found
+	if err := orders.Update(ctx, found); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if err := orders.Update(ctx, &stale); !errors.Is(err, transaction.ErrConcurrentModification) {
+		t.Fatalf("got %v, expected %v", err, transaction.ErrConcurrentModification)
+	}
+
+	if _, err := orders.FindByID(ctx, "UNKNOWN"); !errors.Is(err, transaction.ErrOrderNotFound) {
+		t.Fatalf("got %v, expected %v", err, transaction.ErrOrderNotFound)
+	}
+}
+
+func TestUnitOfWork(t *testing.T) {
+	db := openDB(t, repotest.DefaultSeed())
+	defer db.Close()
+
+	var (
+		products = sqlite.NewProductRepository(db)
+		uow      = sqlite.NewUnitOfWork(db)
+	)
+
+	ctx := context.Background()
+	err := uow.Do(ctx, func(ctx context.Context, r transaction.Repositories) error {
+		p, err := r.Products().FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			return err
+		}
+		p.ReserveQuantity("", 10)
+		if err := r.Products().Update(ctx, p); err != nil {
+			return err
+		}
+
+		p, err = r.Products().FindByID(ctx, "PRODUCT2")
+		if err != nil {
+			return err
+		}
+		return p.TryReserveQuantity("", 5000)
+	})
+	if !errors.Is(err, transaction.ErrQuantityExceedProductStock) {
+		t.Fatalf("got %v, expected %v", err, transaction.ErrQuantityExceedProductStock)
+	}
+
+	p, err := products.FindByID(ctx, "PRODUCT1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if p.Quantity != 200 || p.Version != 0 {
+		t.Fatalf("got quantity %d version %d, expected rolled back to 200 and 0", p.Quantity, p.Version)
+	}
+}
+
+func seed(db *sql.DB, data repotest.Seed) error {
+	for _, c := range data.Customers {
+		_, err := db.Exec("insert into customers (id, name, phone_number, email, address) values (?, ?, ?, ?, ?)",
+			c.ID, c.Name, c.PhoneNumber, c.Email, c.Address)
+		if err != nil {
+			return err
+		}
+	}
+	for _, a := range data.Admins {
+		if _, err := db.Exec("insert into admins (id, name, email, role, password_hash) values (?, ?, ?, ?, ?)", a.ID, a.Name, a.Email, a.Role, a.PasswordHash); err != nil {
+			return err
+		}
+	}
+	for _, c := range data.Categories {
+		if _, err := db.Exec("insert into categories (id, name, version) values (?, ?, ?)", c.ID, c.Name, c.Version); err != nil {
+			return err
+		}
+	}
+	// parents are set once every category exists, so the order of the seed does not matter
+	for _, c := range data.Categories {
+		if c.ParentID == "" {
+			continue
+		}
+		if _, err := db.Exec("update categories set parent_id = ? where id = ?", c.ParentID, c.ID); err != nil {
+			return err
+		}
+	}
+	for _, p := range data.Products {
+		_, err := db.Exec("insert into products (id, name, price, quantity, version) values (?, ?, ?, ?, ?)",
+			p.ID, p.Name, p.Price, p.Quantity, p.Version)
+		if err != nil {
+			return err
+		}
+	}
+	for _, c := range data.Coupons {
+		_, err := db.Exec(`insert into coupons (code, quantity, amount, "begin", "end", type, version) values (?, ?, ?, ?, ?, ?, ?)`,
+			c.Code, c.Quantity, c.Amount, c.Begin, c.End, c.Type, c.Version)
+		if err != nil {
+			return err
+		}
+	}
+	for _, m := range data.Movements {
+		_, err := db.Exec("insert into inventory_movements (id, product_id, quantity, reason, actor, order_id, note, created_at) values (?, ?, ?, ?, ?, ?, ?, ?)",
+			m.ID, m.ProductID, m.Quantity, m.Reason, m.Actor, m.OrderID, m.Note, m.CreatedAt.UTC())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T, data repotest.Seed) repotest.Repositories {
+		db := openDB(t, data)
+		t.Cleanup(func() { db.Close() })
+
+		return repotest.Repositories{
+			Orders:      sqlite.NewOrderRepository(db),
+			Products:    sqlite.NewProductRepository(db),
+			Categories:  sqlite.NewCategoryRepository(db),
+			Coupons:     sqlite.NewCouponRepository(db),
+			Customers:   sqlite.NewCustomerRepository(db),
+			Credentials: sqlite.NewCredentialRepository(db),
+			Admins:      sqlite.NewAdminRepository(db),
+			APIKeys:     sqlite.NewAPIKeyRepository(db),
+			Inventory:   sqlite.NewInventoryRepository(db),
+			UnitOfWork:  sqlite.NewUnitOfWork(db),
+		}
+	})
+}
+
+func TestInventoryIsAppendOnly(t *testing.T) {
+	db := openDB(t, repotest.DefaultSeed())
+	defer db.Close()
+
+	if _, err := db.Exec("update inventory_movements set quantity = 0 where id = 'MOVEMENT1'"); err == nil {
+		t.Errorf("update: got nil, expected error")
+	}
+	if _, err := db.Exec("delete from inventory_movements where id = 'MOVEMENT1'"); err == nil {
+		t.Errorf("delete: got nil, expected error")
+	}
+}
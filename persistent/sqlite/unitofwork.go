@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+type unitOfWork struct {
+	db *sql.DB
+}
+
+// NewUnitOfWork creates new unit of work backed by sqlite transaction
+func NewUnitOfWork(db *sql.DB) transaction.UnitOfWork {
+	return &unitOfWork{db}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, r transaction.Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(ctx, &repositories{tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type repositories struct {
+	tx *sql.Tx
+}
+
+func (r *repositories) Orders() transaction.OrderRepository        { return &orderRepository{r.tx} }
+func (r *repositories) Products() transaction.ProductRepository    { return &productRepository{r.tx} }
+func (r *repositories) Coupons() transaction.CouponRepository      { return &couponRepository{r.tx} }
+func (r *repositories) Inventory() transaction.InventoryRepository { return &inventoryRepository{r.tx} }
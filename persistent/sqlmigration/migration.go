@@ -0,0 +1,288 @@
+// Package sqlmigration applies versioned schema migrations to sql databases.
+//
+// Migrations are pairs of files named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// applied versions are recorded in schema_migrations table. Every migration is applied holding the lock of
+// schema_migrations_lock table, so instances of the application starting together apply it only once.
+package sqlmigration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrSchemaTooNew tells that the database has migrations applied which are unknown to the application
+	ErrSchemaTooNew = errors.New("database schema is newer than the application")
+	// ErrNoMigrationToRollback tells that there is no applied migration to be rolled back
+	ErrNoMigrationToRollback = errors.New("no migration to rollback")
+)
+
+var filenamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a versioned change of the database schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status tells whether a migration has been applied
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New creates new migrator reading migrations from source
+func New(db *sql.DB, source fs.FS) (*Migrator, error) {
+	migrations, err := Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Parse reads migrations in the root of source ordered by their version,
+// every version should have both up and down file.
+func Parse(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		matches := filenamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %v", entry.Name(), err)
+		}
+		b, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		}
+		if m.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d has different names: %s and %s", version, m.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s should have both up and down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Latest returns the latest version known by the migrator
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the latest version applied to the database
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	if err := m.init(ctx); err != nil {
+		return 0, err
+	}
+	return appliedVersion(ctx, m.db)
+}
+
+// Check checks that the database schema is not newer than the migrator,
+// it returns the number of pending migrations.
+func (m *Migrator) Check(ctx context.Context) (pending int, err error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if version > m.Latest() {
+		return 0, fmt.Errorf("%w: database version %d, latest known version %d", ErrSchemaTooNew, version, m.Latest())
+	}
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations, each migration is applied in its own transaction
+func (m *Migrator) Up(ctx context.Context) error {
+	if err := m.init(ctx); err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		migration := migration
+		err := m.inLock(ctx, func(tx *sql.Tx) error {
+			// the version is read holding the lock, another migrator may have applied the migration in the meantime
+			version, err := appliedVersion(ctx, tx)
+			if err != nil {
+				return err
+			}
+			if version > m.Latest() {
+				return fmt.Errorf("%w: database version %d, latest known version %d", ErrSchemaTooNew, version, m.Latest())
+			}
+			if migration.Version <= version {
+				return nil
+			}
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+			}
+			_, err = tx.ExecContext(ctx, "insert into schema_migrations (version, name, applied_at) values ($1, $2, CURRENT_TIMESTAMP)",
+				migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down rolls back the latest applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	if err := m.init(ctx); err != nil {
+		return err
+	}
+
+	return m.inLock(ctx, func(tx *sql.Tx) error {
+		version, err := appliedVersion(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			return ErrNoMigrationToRollback
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version != version {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+			}
+			_, err := tx.ExecContext(ctx, "delete from schema_migrations where version = $1", migration.Version)
+			return err
+		}
+
+		return fmt.Errorf("%w: database version %d, latest known version %d", ErrSchemaTooNew, version, m.Latest())
+	})
+}
+
+// Status returns every known migration and when it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "select version, applied_at from schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) init(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `create table if not exists schema_migrations (
+		version integer primary key,
+		name text not null,
+		applied_at timestamp not null
+	)`)
+	if err != nil {
+		return err
+	}
+	_, err = m.db.ExecContext(ctx, "create table if not exists schema_migrations_lock (id integer primary key)")
+	if err != nil {
+		return err
+	}
+	_, err = m.db.ExecContext(ctx, "insert into schema_migrations_lock (id) values (1) on conflict do nothing")
+	return err
+}
+
+// inLock runs fn in a transaction holding the lock of schema_migrations_lock, which is kept until the transaction ends.
+// Updating the row takes the row lock on postgres and the write lock of the database on sqlite.
+func (m *Migrator) inLock(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "update schema_migrations_lock set id = id"); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// appliedVersion returns the latest version recorded in schema_migrations
+func appliedVersion(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}) (int, error) {
+	var version sql.NullInt64
+	if err := q.QueryRowContext(ctx, "select max(version) from schema_migrations").Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
@@ -0,0 +1,174 @@
+package sqlmigration_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/muktihari/order-transaction-ddd/persistent/postgresql"
+	"github.com/muktihari/order-transaction-ddd/persistent/sqlite"
+	"github.com/muktihari/order-transaction-ddd/persistent/sqlmigration"
+)
+
+func TestParse(t *testing.T) {
+	tt := []struct {
+		Name             string
+		Source           fstest.MapFS
+		ExpectedVersions []int
+		ExpectedErr      bool
+	}{
+		{Name: "Ordered By Version", Source: fstest.MapFS{
+			"0010_add_index.up.sql":   {Data: []byte("create index")},
+			"0010_add_index.down.sql": {Data: []byte("drop index")},
+			"0002_init.up.sql":        {Data: []byte("create table")},
+			"0002_init.down.sql":      {Data: []byte("drop table")},
+			"README.md":               {Data: []byte("ignored")},
+		}, ExpectedVersions: []int{2, 10}},
+		{Name: "Missing Down File", Source: fstest.MapFS{
+			"0001_init.up.sql": {Data: []byte("create table")},
+		}, ExpectedErr: true},
+		{Name: "Different Names Of A Version", Source: fstest.MapFS{
+			"0001_init.up.sql":    {Data: []byte("create table")},
+			"0001_other.down.sql": {Data: []byte("drop table")},
+		}, ExpectedErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			migrations, err := sqlmigration.Parse(tc.Source)
+			if tc.ExpectedErr {
+				if err == nil {
+					t.Fatalf("got nil, expected err")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if len(migrations) != len(tc.ExpectedVersions) {
+				t.Fatalf("got %d migrations, expected %d", len(migrations), len(tc.ExpectedVersions))
+			}
+			for i, m := range migrations {
+				if m.Version != tc.ExpectedVersions[i] {
+					t.Errorf("got version %d, expected %d", m.Version, tc.ExpectedVersions[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParsePostgresqlMigrations(t *testing.T) {
+	if _, err := sqlmigration.Parse(postgresql.Migrations()); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	defer db.Close()
+
+	source := fstest.MapFS{
+		"0001_init.up.sql":           {Data: []byte("create table a (id text primary key);")},
+		"0001_init.down.sql":         {Data: []byte("drop table a;")},
+		"0002_add_table.up.sql":      {Data: []byte("create table b (id text primary key);")},
+		"0002_add_table.down.sql":    {Data: []byte("drop table b;")},
+		"0003_broken_table.up.sql":   {Data: []byte("create table c (id text primary key); create table;")},
+		"0003_broken_table.down.sql": {Data: []byte("drop table c;")},
+	}
+
+	ctx := context.Background()
+	m, err := sqlmigration.New(db, source)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	// the broken migration is rolled back entirely while the previous ones are kept
+	if err := m.Up(ctx); err == nil {
+		t.Fatalf("got nil, expected err")
+	}
+	if version, _ := m.Version(ctx); version != 2 {
+		t.Fatalf("got version %d, expected %d", version, 2)
+	}
+	var n int
+	if err := db.QueryRow("select count(*) from sqlite_master where name = 'c'").Scan(&n); err != nil || n != 0 {
+		t.Fatalf("got %d table c (err: %v), expected 0", n, err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if len(statuses) != 3 || statuses[1].AppliedAt == nil || statuses[2].AppliedAt != nil {
+		t.Fatalf("got %+v, expected 2 applied and 1 pending", statuses)
+	}
+
+	if err := m.Down(ctx); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if version, _ := m.Version(ctx); version != 1 {
+		t.Fatalf("got version %d, expected %d", version, 1)
+	}
+
+	// the database is migrated by newer application
+	if _, err := db.Exec("insert into schema_migrations (version, name, applied_at) values (99, 'newer', CURRENT_TIMESTAMP)"); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if _, err := m.Check(ctx); !errors.Is(err, sqlmigration.ErrSchemaTooNew) {
+		t.Fatalf("got %v, expected %v", err, sqlmigration.ErrSchemaTooNew)
+	}
+	if err := m.Up(ctx); !errors.Is(err, sqlmigration.ErrSchemaTooNew) {
+		t.Fatalf("got %v, expected %v", err, sqlmigration.ErrSchemaTooNew)
+	}
+}
+
+func TestMigratorConcurrentUp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	source := fstest.MapFS{
+		"0001_init.up.sql":        {Data: []byte("create table a (id text primary key);")},
+		"0001_init.down.sql":      {Data: []byte("drop table a;")},
+		"0002_add_table.up.sql":   {Data: []byte("create table b (id text primary key);")},
+		"0002_add_table.down.sql": {Data: []byte("drop table b;")},
+	}
+
+	// every instance of the application has its own connections, creating a table twice would fail
+	const instances = 4
+	errs := make(chan error, instances)
+	for i := 0; i < instances; i++ {
+		db, err := sqlite.Open(path)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		defer db.Close()
+		m, err := sqlmigration.New(db, source)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		go func() { errs <- m.Up(context.Background()) }()
+	}
+	for i := 0; i < instances; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+	}
+
+	db, err := sqlite.Open(path)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	defer db.Close()
+	var n int
+	if err := db.QueryRow("select count(*) from schema_migrations").Scan(&n); err != nil || n != 2 {
+		t.Fatalf("got %d applied migrations (err: %v), expected 2", n, err)
+	}
+}
+
+func TestParseSqliteMigrations(t *testing.T) {
+	if _, err := sqlmigration.Parse(sqlite.Migrations()); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Package problem writes errors as RFC 7807 problem details, it's the one place errors are mapped to HTTP status.
+package problem
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// ContentType is the media type of problem details
+const ContentType = "application/problem+json"
+
+var (
+	// ErrRouteNotFound tells that no route matches the path of the request
+	ErrRouteNotFound = transaction.NewError(transaction.KindNotFound, "route_not_found", "route not found")
+)
+
+// Problem is the body of every failed response. Code is the stable code of the error clients should rely on,
+// Detail is its message meant for people. Type is always about:blank, Title is the text of the status.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	Code   string `json:"code"`
+}
+
+var statuses = map[transaction.Kind]int{
+	transaction.KindInternal:        http.StatusInternalServerError,
+	transaction.KindInvalid:         http.StatusBadRequest,
+	transaction.KindUnauthenticated: http.StatusUnauthorized,
+	transaction.KindForbidden:       http.StatusForbidden,
+	transaction.KindNotFound:        http.StatusNotFound,
+	transaction.KindConflict:        http.StatusConflict,
+	transaction.KindUnprocessable:   http.StatusUnprocessableEntity,
+	transaction.KindExternal:        http.StatusBadGateway,
+}
+
+// New creates the problem of err, unexpected errors become an internal problem without their details
+func New(err error) Problem {
+	e := transaction.ErrorOf(err)
+	status, ok := statuses[e.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: e.Message,
+		Code:   e.Code,
+	}
+}
+
+// Write writes the problem of err to the response, unauthenticated callers are told to send a bearer token
+func Write(w http.ResponseWriter, err error) {
+	write(w, New(err))
+}
+
+// NotFound responds to requests matching no route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Write(w, ErrRouteNotFound)
+}
+
+// MethodNotAllowed responds to requests of a method the route doesn't have
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	write(w, Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusMethodNotAllowed),
+		Status: http.StatusMethodNotAllowed,
+		Detail: "method not allowed",
+		Code:   "method_not_allowed",
+	})
+}
+
+// Recoverer is middleware recovering from panics of the handler, the panic is logged with its stack
+// and the request is answered with an internal problem. http.ErrAbortHandler is passed on to abort the response.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+			if rvr == http.ErrAbortHandler {
+				panic(rvr)
+			}
+			log.Printf("panic: %v\n%s", rvr, debug.Stack())
+			Write(w, fmt.Errorf("panic: %v", rvr))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func write(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	if p.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,106 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/muktihari/order-transaction-ddd/auth"
+	"github.com/muktihari/order-transaction-ddd/problem"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+func TestWrite(t *testing.T) {
+	tt := []struct {
+		Name   string
+		Err    error
+		Status int
+		Code   string
+		Detail string
+		Bearer bool
+	}{
+		{Name: "Not Found", Err: transaction.ErrOrderNotFound, Status: http.StatusNotFound, Code: "order_not_found", Detail: "order not found"},
+		{Name: "Wrapped", Err: fmt.Errorf("order ORDER1: %w", transaction.ErrConcurrentModification), Status: http.StatusConflict, Code: "concurrent_modification", Detail: "error concurrent modification"},
+		{Name: "Rule Of Domain", Err: transaction.ErrOrderIsAlreadyFinalized, Status: http.StatusConflict, Code: "order_already_finalized", Detail: "error order is already finalized"},
+		{Name: "Invalid", Err: transaction.ErrPaymentTypeNotAllowed, Status: http.StatusBadRequest, Code: "payment_type_not_allowed", Detail: "error payment method not allowed"},
+		{Name: "Unauthenticated", Err: auth.ErrUnauthenticated, Status: http.StatusUnauthorized, Code: "unauthenticated", Detail: "error unauthenticated", Bearer: true},
+		{Name: "External", Err: transaction.ErrLogisticsRegister, Status: http.StatusBadGateway, Code: "logistics_register_failed", Detail: "error register logistics"},
+		{Name: "Unexpected", Err: errors.New("dial tcp: connection refused"), Status: http.StatusInternalServerError, Code: "internal", Detail: "internal error"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			problem.Write(w, tc.Err)
+
+			if w.Code != tc.Status {
+				t.Errorf("got status %d, expected %d", w.Code, tc.Status)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+				t.Errorf("got content type %q, expected %q", ct, problem.ContentType)
+			}
+			if bearer := w.Header().Get("WWW-Authenticate") == "Bearer"; bearer != tc.Bearer {
+				t.Errorf("got bearer challenge %t, expected %t", bearer, tc.Bearer)
+			}
+			var p problem.Problem
+			if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			expected := problem.Problem{Type: "about:blank", Title: http.StatusText(tc.Status), Status: tc.Status, Detail: tc.Detail, Code: tc.Code}
+			if p != expected {
+				t.Errorf("got problem %+v, expected %+v", p, expected)
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	w := httptest.NewRecorder()
+	problem.MethodNotAllowed(w, httptest.NewRequest(http.MethodDelete, "/ordering/v1/order/make", nil))
+
+	var p problem.Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if w.Code != http.StatusMethodNotAllowed || p.Status != http.StatusMethodNotAllowed || p.Code != "method_not_allowed" {
+		t.Errorf("got status %d with problem %+v, expected %d", w.Code, p, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestRecoverer(t *testing.T) {
+	handler := problem.Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler panics")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ordering/v1/order/make", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got status %d, expected %d", w.Code, http.StatusInternalServerError)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Errorf("got content type %q, expected %q", ct, problem.ContentType)
+	}
+	var p problem.Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	expected := problem.Problem{Type: "about:blank", Title: http.StatusText(http.StatusInternalServerError), Status: http.StatusInternalServerError, Detail: "internal error", Code: "internal"}
+	if p != expected {
+		t.Errorf("got problem %+v, expected %+v", p, expected)
+	}
+}
+
+func TestRecovererAbort(t *testing.T) {
+	handler := problem.Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if rvr := recover(); rvr != http.ErrAbortHandler {
+			t.Errorf("got panic %v, expected %v", rvr, http.ErrAbortHandler)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
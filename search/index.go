@@ -0,0 +1,333 @@
+// Package search contains in-process full-text search of products, it keeps an inverted index
+// of product name, description and tags so it works with every repository backend.
+// The index holds the products on sale as well, so the catalog is browsed without reading the repository.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// weight of a term by the field it's found in, a term found in the name is more relevant than in the description
+const (
+	nameWeight        = 3
+	tagWeight         = 2
+	descriptionWeight = 1
+)
+
+// score of a query token by how it matches a term
+const (
+	exactScore  = 1
+	prefixScore = 0.7
+	typoScore   = 0.5
+)
+
+// Hit is a product matching the query, higher Score is more relevant
+type Hit struct {
+	ProductID string
+	Score     float64
+	Product   transaction.Product
+}
+
+// document is an indexed product
+type document struct {
+	version int64
+	name    string
+	terms   map[string]float64
+	product transaction.Product
+}
+
+// Index is an inverted index of products, it's safe for concurrent use
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[string]document
+	postings map[string]map[string]float64 // term -> product ID -> weight
+	terms    []string                      // sorted vocabulary for prefix lookup, nil when it has to be rebuilt
+}
+
+// NewIndex creates new empty index
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]document),
+		postings: make(map[string]map[string]float64),
+	}
+}
+
+// Sync brings the index up to date with products, it's the whole list of products as returned by ProductRepository.FindAll.
+// Only products whose Version changed are reindexed, archived products and products not in the list are removed.
+// The held product is replaced anyway, quantity of a bundle changes with its components without changing its Version.
+func (ix *Index) Sync(products []transaction.Product) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	seen := make(map[string]bool, len(products))
+	for i := range products {
+		p := &products[i]
+		if p.Archived {
+			continue
+		}
+		seen[p.ID] = true
+		if d, ok := ix.docs[p.ID]; ok && d.version == p.Version {
+			d.product = *p
+			ix.docs[p.ID] = d
+			continue
+		}
+		ix.put(p)
+	}
+	for id := range ix.docs {
+		if !seen[id] {
+			ix.remove(id)
+		}
+	}
+}
+
+// Put indexes the product, replacing its previous version
+func (ix *Index) Put(p *transaction.Product) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if p.Archived {
+		ix.remove(p.ID)
+		return
+	}
+	ix.put(p)
+}
+
+// Remove removes the product from the index
+func (ix *Index) Remove(productID string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(productID)
+}
+
+func (ix *Index) put(p *transaction.Product) {
+	ix.remove(p.ID)
+
+	terms := make(map[string]float64)
+	for _, t := range Tokenize(p.Name) {
+		terms[t] += nameWeight
+	}
+	for _, tag := range p.Tags {
+		for _, t := range Tokenize(tag) {
+			terms[t] += tagWeight
+		}
+	}
+	for _, t := range Tokenize(p.Description) {
+		terms[t] += descriptionWeight
+	}
+
+	for t, w := range terms {
+		posting, ok := ix.postings[t]
+		if !ok {
+			posting = make(map[string]float64)
+			ix.postings[t] = posting
+			ix.terms = nil
+		}
+		posting[p.ID] = w
+	}
+	ix.docs[p.ID] = document{version: p.Version, name: strings.ToLower(p.Name), terms: terms, product: *p}
+}
+
+func (ix *Index) remove(productID string) {
+	d, ok := ix.docs[productID]
+	if !ok {
+		return
+	}
+	for t := range d.terms {
+		posting := ix.postings[t]
+		delete(posting, productID)
+		if len(posting) == 0 {
+			delete(ix.postings, t)
+			ix.terms = nil
+		}
+	}
+	delete(ix.docs, productID)
+}
+
+// Products returns every indexed product in no particular order, their variants, tags and components
+// are shared with the index and must not be changed
+func (ix *Index) Products() []transaction.Product {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	products := make([]transaction.Product, 0, len(ix.docs))
+	for _, d := range ix.docs {
+		products = append(products, d.product)
+	}
+	return products
+}
+
+// Search finds products matching every token of the query, most relevant first, at most limit hits are returned.
+// A token matches a term exactly, as its prefix or with a typo, an exact match is the most relevant.
+func (ix *Index) Search(query string, limit int) []Hit {
+	tokens := Tokenize(query)
+	if len(tokens) == 0 || limit <= 0 {
+		return []Hit{}
+	}
+
+	// the vocabulary is sorted under the write lock, searches then share the read lock
+	ix.mu.RLock()
+	for ix.terms == nil {
+		ix.mu.RUnlock()
+		ix.mu.Lock()
+		ix.sortTerms()
+		ix.mu.Unlock()
+		ix.mu.RLock()
+	}
+	defer ix.mu.RUnlock()
+
+	var scores map[string]float64
+	for _, token := range tokens {
+		tokenScores := make(map[string]float64)
+		for term, match := range ix.matches(token) {
+			idf := math.Log(1 + float64(len(ix.docs))/float64(len(ix.postings[term])))
+			for id, w := range ix.postings[term] {
+				if s := match * w * idf; s > tokenScores[id] {
+					tokenScores[id] = s
+				}
+			}
+		}
+
+		if scores == nil {
+			scores = tokenScores
+			continue
+		}
+		for id := range scores {
+			if s, ok := tokenScores[id]; ok {
+				scores[id] += s
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, s := range scores {
+		hits = append(hits, Hit{ProductID: id, Score: s, Product: ix.docs[id].product})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if a, b := ix.docs[hits[i].ProductID].name, ix.docs[hits[j].ProductID].name; a != b {
+			return a < b
+		}
+		return hits[i].ProductID < hits[j].ProductID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+func (ix *Index) sortTerms() {
+	if ix.terms != nil {
+		return
+	}
+	ix.terms = make([]string, 0, len(ix.postings))
+	for t := range ix.postings {
+		ix.terms = append(ix.terms, t)
+	}
+	sort.Strings(ix.terms)
+}
+
+// matches lists the terms matching the token with the score of the match
+func (ix *Index) matches(token string) map[string]float64 {
+	matches := make(map[string]float64)
+
+	i := sort.SearchStrings(ix.terms, token)
+	for ; i < len(ix.terms) && strings.HasPrefix(ix.terms[i], token); i++ {
+		if ix.terms[i] == token {
+			matches[token] = exactScore
+		} else {
+			matches[ix.terms[i]] = prefixScore
+		}
+	}
+
+	edits := maxEdits(token)
+	if edits == 0 {
+		return matches
+	}
+	for _, term := range ix.terms {
+		if _, ok := matches[term]; ok {
+			continue
+		}
+		if d := distance(token, term, edits); d <= edits {
+			matches[term] = typoScore / float64(d)
+		}
+	}
+	return matches
+}
+
+// maxEdits is the number of typos tolerated in the token, short tokens have to be typed correctly
+func maxEdits(token string) int {
+	switch n := len([]rune(token)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// distance is the number of insertions, deletions, substitutions and transpositions of adjacent runes
+// to turn a into b, any distance above max is reported as max+1.
+func distance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return max + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minOf(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = minOf(cur[j], prev2[j-2]+1)
+			}
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	if prev[len(rb)] > max {
+		return max + 1
+	}
+	return prev[len(rb)]
+}
+
+func minOf(v int, vs ...int) int {
+	for _, x := range vs {
+		if x < v {
+			v = x
+		}
+	}
+	return v
+}
+
+// Tokenize splits text into lower-cased terms of letters and digits
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,110 @@
+package search_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/muktihari/order-transaction-ddd/search"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+func products() []transaction.Product {
+	return []transaction.Product{
+		{ID: "PRODUCT1", Name: "Sony Xperia 10", Description: "6 inch OLED display", Tags: []string{"android", "smartphone"}},
+		{ID: "PRODUCT2", Name: "Ultramilk 1L", Description: "Full cream UHT milk", Tags: []string{"dairy"}},
+		{ID: "PRODUCT3", Name: "Xperia Charger", Description: "Fast charger for Sony smartphones"},
+		{ID: "PRODUCT4", Name: "Samsung Galaxy S20", Description: "Android smartphone", Archived: true},
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ix := search.NewIndex()
+	ix.Sync(products())
+
+	tt := []struct {
+		Name     string
+		Query    string
+		Expected []string
+	}{
+		{Name: "Exact", Query: "ultramilk", Expected: []string{"PRODUCT2"}},
+		{Name: "Case Insensitive", Query: "ULTRAMILK", Expected: []string{"PRODUCT2"}},
+		{Name: "Prefix", Query: "ultra", Expected: []string{"PRODUCT2"}},
+		{Name: "Typo", Query: "utramilk", Expected: []string{"PRODUCT2"}},
+		{Name: "Transposition", Query: "xpeira", Expected: []string{"PRODUCT1", "PRODUCT3"}},
+		{Name: "Short Token Without Typo", Query: "sny", Expected: []string{}},
+		{Name: "Tag", Query: "dairy", Expected: []string{"PRODUCT2"}},
+		{Name: "Description", Query: "oled", Expected: []string{"PRODUCT1"}},
+		{Name: "Every Token", Query: "sony charger", Expected: []string{"PRODUCT3"}},
+		{Name: "Name Ranks Above Description", Query: "sony", Expected: []string{"PRODUCT1", "PRODUCT3"}},
+		{Name: "Exact Ranks Above Prefix", Query: "smartphone", Expected: []string{"PRODUCT1", "PRODUCT3"}},
+		{Name: "Archived", Query: "galaxy", Expected: []string{}},
+		{Name: "Empty Query", Query: " , ", Expected: []string{}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			ids := make([]string, 0)
+			for _, h := range ix.Search(tc.Query, 10) {
+				ids = append(ids, h.ProductID)
+			}
+			if diff := cmp.Diff(tc.Expected, ids); diff != "" {
+				t.Fatalf("(-expected +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	ix := search.NewIndex()
+	all := products()
+	ix.Sync(all)
+
+	all[1].Name = "Greenfields 1L"
+	all[1].Version++
+	all = all[1:]
+	ix.Sync(all)
+
+	if hits := ix.Search("ultramilk", 10); len(hits) != 0 {
+		t.Errorf("got %v, expected renamed product to be reindexed", hits)
+	}
+	if hits := ix.Search("greenfields", 10); len(hits) != 1 || hits[0].ProductID != "PRODUCT2" {
+		t.Errorf("got %v, expected PRODUCT2", hits)
+	}
+	if hits := ix.Search("oled", 10); len(hits) != 0 {
+		t.Errorf("got %v, expected removed product to be unindexed", hits)
+	}
+
+	// an unchanged version is not reindexed
+	all[1].Name = "Other Charger"
+	ix.Sync(all)
+	if hits := ix.Search("xperia", 10); len(hits) != 1 || hits[0].ProductID != "PRODUCT3" {
+		t.Errorf("got %v, expected PRODUCT3", hits)
+	}
+
+	if hits := ix.Search("charger", 1); len(hits) != 1 {
+		t.Errorf("got %d hits, expected limit of 1", len(hits))
+	}
+}
+
+func TestProducts(t *testing.T) {
+	ix := search.NewIndex()
+	all := products()
+	ix.Sync(all)
+
+	// quantity of a bundle changes with its components, the held product is replaced without reindexing
+	all[2].Quantity = 7
+	ix.Sync(all)
+
+	quantities := make(map[string]int64)
+	for _, p := range ix.Products() {
+		quantities[p.ID] = p.Quantity
+	}
+	if diff := cmp.Diff(map[string]int64{"PRODUCT1": 0, "PRODUCT2": 0, "PRODUCT3": 7}, quantities); diff != "" {
+		t.Fatalf("(-expected +got): %s", diff)
+	}
+
+	hits := ix.Search("charger", 10)
+	if len(hits) != 1 || hits[0].Product.Quantity != 7 {
+		t.Errorf("got %+v, expected PRODUCT3 with quantity 7", hits)
+	}
+}
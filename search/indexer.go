@@ -0,0 +1,48 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"github.com/muktihari/order-transaction-ddd/transaction"
+)
+
+// Indexer keeps the index up to date with the products of the repository. Products are changed by other services
+// and inside units of work, so the index is synced periodically instead of on every query.
+type Indexer struct {
+	products transaction.ProductRepository
+	index    *Index
+}
+
+// NewIndexer creates new indexer of the products into the index
+func NewIndexer(products transaction.ProductRepository, index *Index) *Indexer {
+	return &Indexer{products: products, index: index}
+}
+
+// Sync brings the index up to date with the products, quantity of bundles is resolved from their components
+func (i *Indexer) Sync(ctx context.Context) error {
+	all, err := i.products.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	transaction.ResolveBundles(all)
+	i.index.Sync(all)
+	return nil
+}
+
+// Run syncs the index every interval until ctx is done, failures are passed to onError
+func (i *Indexer) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := i.Sync(ctx); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
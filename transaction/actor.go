@@ -2,37 +2,150 @@ package transaction
 
 import (
 	"context"
-	"errors"
+	"net/mail"
+	"strings"
 )
 
 var (
-	// ErrCustomerNotFound tells that product can not be found
-	ErrCustomerNotFound = errors.New("customer not found")
-	// ErrAdminNotFound tells that product can not be found
-	ErrAdminNotFound = errors.New("admin not found")
+	// ErrAdminNotFound tells that admin can not be found
+	ErrAdminNotFound = NewError(KindNotFound, "admin_not_found", "admin not found")
+	// ErrInvalidAdmin tells that admin has no name, invalid email or unknown role
+	ErrInvalidAdmin = NewError(KindInvalid, "invalid_admin", "error invalid admin")
+	// ErrAdminEmailTaken tells that the email already belongs to another admin
+	ErrAdminEmailTaken = NewError(KindConflict, "admin_email_taken", "error admin email is already taken")
 )
 
-// Admin represents the person in charge to administrate the shop
+// ActorSystem is the actor of changes made by the system itself rather than by a person
+const ActorSystem = "system"
+
+// AdminActor identifies an admin as the actor of a change
+func AdminActor(id string) string {
+	return "admin:" + id
+}
+
+// CustomerActor identifies a customer as the actor of a change
+func CustomerActor(id string) string {
+	return "customer:" + id
+}
+
+// APIKeyActor identifies an API key as the actor of a change
+func APIKeyActor(id string) string {
+	return "apikey:" + id
+}
+
+// Permission is an operation an admin may be allowed to do, it's also the scope of an API key allowing the operation
+type Permission string
+
+const (
+	// PermissionViewOrders allows viewing and searching orders
+	PermissionViewOrders Permission = "orders:read"
+	// PermissionCancelOrders allows canceling orders, which gives back coupon and stock
+	PermissionCancelOrders Permission = "orders:cancel"
+	// PermissionShipOrders allows handing orders over to the logistics partner
+	PermissionShipOrders Permission = "orders:ship"
+	// PermissionViewStock allows viewing stock and its movements
+	PermissionViewStock Permission = "stock:read"
+	// PermissionManageInventory allows changing products, their stock and categories
+	PermissionManageInventory Permission = "inventory:write"
+	// PermissionManageAdmins allows registering other admins
+	PermissionManageAdmins Permission = "admins:write"
+	// PermissionManageAPIKeys allows creating, rotating and revoking API keys
+	PermissionManageAPIKeys Permission = "apikeys:write"
+)
+
+var permissions = []Permission{
+	PermissionViewOrders, PermissionCancelOrders, PermissionShipOrders, PermissionViewStock,
+	PermissionManageInventory, PermissionManageAdmins, PermissionManageAPIKeys,
+}
+
+// Valid tells whether the permission is known
+func (p Permission) Valid() bool {
+	for _, permission := range permissions {
+		if permission == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Role is the set of permissions given to an admin
+type Role string
+
+const (
+	// RoleViewer can only look at orders and stock
+	RoleViewer Role = "viewer"
+	// RoleFulfillment ships orders and manages the inventory
+	RoleFulfillment Role = "fulfillment"
+	// RoleFinance cancels orders, which refunds the customer
+	RoleFinance Role = "finance"
+	// RoleSuperadmin can do everything
+	RoleSuperadmin Role = "superadmin"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleViewer:      {PermissionViewOrders, PermissionViewStock},
+	RoleFulfillment: {PermissionViewOrders, PermissionViewStock, PermissionShipOrders, PermissionManageInventory},
+	RoleFinance:     {PermissionViewOrders, PermissionViewStock, PermissionCancelOrders},
+	RoleSuperadmin:  permissions,
+}
+
+// Valid tells whether the role is known
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Allows tells whether the role is given the permission, unknown roles are given nothing
+func (r Role) Allows(p Permission) bool {
+	for _, permission := range rolePermissions[r] {
+		if permission == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Admin represents the person in charge to administrate the shop.
+// Email is unique among admins, only the bcrypt hash of the password is kept and it's never encoded to JSON.
 type Admin struct {
-	ID   string `bson:"_id" json:"id"`
-	Name string `bson:"name" json:"name"`
+	ID           string `bson:"_id" json:"id"`
+	Name         string `bson:"name" json:"name"`
+	Email        string `bson:"email" json:"email"`
+	Role         Role   `bson:"role" json:"role"`
+	PasswordHash []byte `bson:"password_hash" json:"-"`
+}
+
+// NewAdmin creates new admin with the role and the password, the email is kept lower-cased
+func NewAdmin(name, email string, role Role, password string) (*Admin, error) {
+	name = strings.TrimSpace(name)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if name == "" || !role.Valid() {
+		return nil, ErrInvalidAdmin
+	}
+	if a, err := mail.ParseAddress(email); err != nil || a.Address != email {
+		return nil, ErrInvalidAdmin
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return &Admin{Name: name, Email: email, Role: role, PasswordHash: hash}, nil
 }
 
-// Customer represents customer who want to buy products from the shop
-type Customer struct {
-	ID          string `bson:"_id" json:"id"`
-	Name        string `bson:"name" json:"name"`
-	PhoneNumber string `bson:"phone_number" json:"phone_number"`
-	Email       string `bson:"email" json:"email"`
-	Address     string `bson:"address" json:"address"`
+// CheckPassword checks the password matches the hash
+func (a *Admin) CheckPassword(password string) error {
+	return checkPassword(a.PasswordHash, password)
 }
 
-// CustomerRepository provides access to customers
-type CustomerRepository interface {
-	FindByID(ctx context.Context, id string) (*Customer, error)
+// Can tells whether the role of the admin allows the permission
+func (a *Admin) Can(p Permission) bool {
+	return a.Role.Allows(p)
 }
 
-// AdminRepository provides access to admins
+// AdminRepository provides access to admins. Store generates the ID of the admin and returns ErrAdminEmailTaken
+// when another admin has the same email, FindByEmail looks up the lower-cased email.
 type AdminRepository interface {
 	FindByID(ctx context.Context, id string) (*Admin, error)
+	FindByEmail(ctx context.Context, email string) (*Admin, error)
+	Store(ctx context.Context, admin *Admin) error
 }
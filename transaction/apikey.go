@@ -0,0 +1,134 @@
+package transaction
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix starts every API key, it tells API keys apart from other bearer tokens
+const APIKeyPrefix = "otk_"
+
+var (
+	// ErrAPIKeyNotFound tells that API key can not be found
+	ErrAPIKeyNotFound = NewError(KindNotFound, "api_key_not_found", "api key not found")
+	// ErrInvalidAPIKey tells that API key has no name, no or unknown scope, or expires in the past
+	ErrInvalidAPIKey = NewError(KindInvalid, "invalid_api_key", "error invalid api key")
+	// ErrAPIKeyRevoked tells that API key has been revoked and can not be changed anymore
+	ErrAPIKeyRevoked = NewError(KindConflict, "api_key_revoked", "error api key is revoked")
+)
+
+// APIKey authenticates another system calling the shop, such as a warehouse or a BI system.
+// It's allowed the operations of its scopes until it expires or is revoked. Only the SHA-256 hash of the key is kept,
+// the key itself is shown once when it's created or rotated. The hash is never encoded to JSON.
+type APIKey struct {
+	ID        string       `bson:"_id" json:"id"`
+	Name      string       `bson:"name" json:"name"`
+	Scopes    []Permission `bson:"scopes" json:"scopes"`
+	Hash      []byte       `bson:"hash" json:"-"`
+	CreatedBy string       `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time    `bson:"created_at" json:"created_at"`
+	RotatedAt *time.Time   `bson:"rotated_at" json:"rotated_at,omitempty"`
+	ExpiresAt time.Time    `bson:"expires_at" json:"expires_at"`
+	RevokedAt *time.Time   `bson:"revoked_at" json:"revoked_at,omitempty"`
+	Version   int64        `bson:"version" json:"version"`
+}
+
+// NewAPIKey creates new API key of the scopes created by the admin, the returned key is the only time it's known
+func NewAPIKey(name string, scopes []Permission, expiresAt time.Time, adminID string, now time.Time) (*APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(scopes) == 0 || !expiresAt.After(now) {
+		return nil, "", ErrInvalidAPIKey
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, "", ErrInvalidAPIKey
+		}
+	}
+	key, hash, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	return &APIKey{
+		Name:      name,
+		Scopes:    scopes,
+		Hash:      hash,
+		CreatedBy: adminID,
+		CreatedAt: now.UTC(),
+		ExpiresAt: expiresAt.UTC(),
+	}, key, nil
+}
+
+// Rotate replaces the key with a new one expiring at expiresAt, the previous key stops working at once
+func (k *APIKey) Rotate(expiresAt, now time.Time) (string, error) {
+	if k.RevokedAt != nil {
+		return "", ErrAPIKeyRevoked
+	}
+	if !expiresAt.After(now) {
+		return "", ErrInvalidAPIKey
+	}
+	key, hash, err := generateAPIKey()
+	if err != nil {
+		return "", err
+	}
+	rotatedAt := now.UTC()
+	k.Hash = hash
+	k.RotatedAt = &rotatedAt
+	k.ExpiresAt = expiresAt.UTC()
+	return key, nil
+}
+
+// Revoke stops the key from working for good
+func (k *APIKey) Revoke(now time.Time) error {
+	if k.RevokedAt != nil {
+		return ErrAPIKeyRevoked
+	}
+	revokedAt := now.UTC()
+	k.RevokedAt = &revokedAt
+	return nil
+}
+
+// Active tells whether the key can be used at now
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
+
+// Can tells whether the scopes of the key allow the permission
+func (k *APIKey) Can(p Permission) bool {
+	for _, scope := range k.Scopes {
+		if scope == p {
+			return true
+		}
+	}
+	return false
+}
+
+// HashAPIKey returns the hash an API key is looked up by
+func HashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// generateAPIKey returns new random key with its hash, the key has enough entropy for a fast hash to be safe
+func generateAPIKey() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, HashAPIKey(key), nil
+}
+
+// APIKeyRepository provides access to API keys. Store generates the ID of the key, FindByHash looks up the key by HashAPIKey.
+// Update only succeeds when the stored key has the same Version as the given one,
+// otherwise ErrConcurrentModification is returned. On success the Version of the given key is incremented.
+type APIKeyRepository interface {
+	FindByID(ctx context.Context, id string) (*APIKey, error)
+	FindByHash(ctx context.Context, hash []byte) (*APIKey, error)
+	FindAll(ctx context.Context) ([]APIKey, error)
+	Store(ctx context.Context, key *APIKey) error
+	Update(ctx context.Context, key *APIKey) error
+}
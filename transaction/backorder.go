@@ -0,0 +1,75 @@
+package transaction
+
+import "context"
+
+var (
+	// ErrOrderNotAllocated tells that part of the order is still backordered, it can not be shipped until it's allocated
+	ErrOrderNotAllocated = NewError(KindConflict, "order_not_allocated", "error order is not fully allocated")
+)
+
+// BackorderPolicy tells whether a product can be ordered beyond its stock
+type BackorderPolicy string
+
+const (
+	// BackorderNone does not allow ordering a product beyond its stock
+	BackorderNone BackorderPolicy = ""
+	// BackorderAllowed allows ordering a product beyond its stock, the missing quantity is allocated once it's restocked
+	BackorderAllowed BackorderPolicy = "backorder"
+	// BackorderPreOrder allows ordering a product before its release, it's expected to be available at AvailableAt
+	BackorderPreOrder BackorderPolicy = "pre_order"
+)
+
+// AllocateBackorders reserves the stock of the product for the backordered lines of submitted orders, first come first served
+// in the order the orders were placed. Allocations are recorded in the inventory ledger and the orders are updated,
+// the product is left to the caller to update along with the change that replenished its stock.
+func AllocateBackorders(ctx context.Context, r Repositories, p *Product) error {
+	filter := OrderFilter{
+		ProductID: p.ID,
+		Statuses:  []OrderStatus{OrderStatusSubmitted, OrderStatusPaid},
+		Sort:      OrderSortOldest,
+	}
+	page := Page{Limit: MaxPageLimit}
+	for p.Quantity > 0 {
+		result, err := r.Orders().Find(ctx, filter, page)
+		if err != nil {
+			return err
+		}
+		for i := range result.Orders {
+			if err := allocateBackorder(ctx, r, p, &result.Orders[i]); err != nil {
+				return err
+			}
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		page.Cursor = result.NextCursor
+	}
+	return nil
+}
+
+func allocateBackorder(ctx context.Context, r Repositories, p *Product, o *Order) error {
+	allocated := false
+	for i := range o.Cart {
+		cartItem := &o.Cart[i]
+		if cartItem.Product.ID != p.ID || cartItem.Backordered == 0 {
+			continue
+		}
+		left := p.ReserveAvailable(cartItem.SKU, cartItem.Backordered)
+		quantity := cartItem.Backordered - left
+		if quantity == 0 {
+			continue
+		}
+		cartItem.Backordered = left
+		allocated = true
+
+		m := NewInventoryMovement(p.ID, cartItem.SKU, -quantity, MovementReasonOrderReservation, ActorSystem)
+		m.OrderID = o.ID
+		if err := r.Inventory().Store(ctx, m); err != nil {
+			return err
+		}
+	}
+	if !allocated {
+		return nil
+	}
+	return r.Orders().Update(ctx, o)
+}
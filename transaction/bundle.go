@@ -0,0 +1,187 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrInvalidBundle tells that bundle has no components, has stock, variants or backorder of its own,
+	// or one of its components is invalid
+	ErrInvalidBundle = NewError(KindInvalid, "invalid_bundle", "error invalid bundle")
+)
+
+// Component is a product, or a variant of it when SKU is set, contained in a bundle with the quantity per bundle
+type Component struct {
+	ProductID string `bson:"product_id" json:"product_id"`
+	SKU       string `bson:"sku" json:"sku"`
+	Quantity  int64  `bson:"quantity" json:"quantity"`
+}
+
+// NewComponent creates component of quantity of the variant of the product, or of the product itself when sku is empty.
+// Bundles can not be components of another bundle.
+func NewComponent(p *Product, sku string, quantity int64) (Component, error) {
+	if p.Archived {
+		return Component{}, ErrProductArchived
+	}
+	if p.IsBundle() || quantity <= 0 {
+		return Component{}, ErrInvalidBundle
+	}
+	if _, err := p.stock(sku); err != nil {
+		return Component{}, err
+	}
+	return Component{ProductID: p.ID, SKU: sku, Quantity: quantity}, nil
+}
+
+// IsBundle tells whether the product is a bundle, its stock is the stock of its components
+func (p *Product) IsBundle() bool {
+	return len(p.Components) != 0
+}
+
+// SetComponents replaces the components of the bundle. A bundle is priced on its own,
+// it has no stock, variants nor backorder since its availability comes from its components.
+func (p *Product) SetComponents(components []Component) error {
+	if p.Archived {
+		return ErrProductArchived
+	}
+	if len(components) == 0 || p.HasVariants() || p.Backorderable() || (!p.IsBundle() && p.Quantity != 0) {
+		return ErrInvalidBundle
+	}
+	seen := make(map[Component]bool, len(components))
+	for _, c := range components {
+		key := Component{ProductID: c.ProductID, SKU: c.SKU}
+		if c.ProductID == "" || c.ProductID == p.ID || c.Quantity <= 0 || seen[key] {
+			return ErrInvalidBundle
+		}
+		seen[key] = true
+	}
+	p.Components = append([]Component(nil), components...)
+	p.Quantity = 0
+	return nil
+}
+
+// AssembleQuantity returns how many bundles can be made up from the stock of the components,
+// products are looked up by ID and a missing or archived component makes the bundle unavailable
+func (p *Product) AssembleQuantity(products map[string]*Product) int64 {
+	var quantity int64 = -1
+	for _, c := range p.Components {
+		cp, ok := products[c.ProductID]
+		if !ok || cp.Archived {
+			return 0
+		}
+		stock, err := cp.stock(c.SKU)
+		if err != nil || *stock < c.Quantity {
+			return 0
+		}
+		if n := *stock / c.Quantity; quantity < 0 || n < quantity {
+			quantity = n
+		}
+	}
+	if quantity < 0 {
+		return 0
+	}
+	return quantity
+}
+
+// ResolveBundles sets the Quantity of the bundles to how many of them can be made up,
+// the components are looked up in products which is usually the whole list of products
+func ResolveBundles(products []Product) {
+	byID := make(map[string]*Product, len(products))
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+	}
+	for i := range products {
+		if products[i].IsBundle() {
+			products[i].Quantity = products[i].AssembleQuantity(byID)
+		}
+	}
+}
+
+// ResolveBundle sets the Quantity of the bundle to how many of it can be made up, it does nothing for other products
+func ResolveBundle(ctx context.Context, products ProductRepository, p *Product) error {
+	if !p.IsBundle() {
+		return nil
+	}
+	byID := make(map[string]*Product, len(p.Components))
+	for _, c := range p.Components {
+		cp, err := products.FindByID(ctx, c.ProductID)
+		if errors.Is(err, ErrProductNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		byID[cp.ID] = cp
+	}
+	p.Quantity = p.AssembleQuantity(byID)
+	return nil
+}
+
+// ReserveBundle reserves the components of the bundle line of the order from their stock, components are never backordered.
+// The components are kept on the line so they are released as they were reserved even when the bundle is changed later on.
+// Reservations are recorded in the inventory ledger, alerts of the components crossing their reorder threshold are returned.
+func ReserveBundle(ctx context.Context, r Repositories, o *Order, cartItem *CartItem, bundle *Product) ([]StockAlert, error) {
+	if bundle.Archived {
+		return nil, ErrProductArchived
+	}
+	var alerts []StockAlert
+	for _, c := range bundle.Components {
+		p, err := r.Products().FindByID(ctx, c.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if p.Archived {
+			return nil, ErrProductArchived
+		}
+		quantity := c.Quantity * cartItem.Quantity
+		stock, err := p.stock(c.SKU)
+		if err != nil {
+			return nil, err
+		}
+		if *stock < quantity {
+			return nil, ErrQuantityExceedProductStock
+		}
+
+		previous := p.Quantity
+		p.ReserveQuantity(c.SKU, quantity)
+		if err := r.Products().Update(ctx, p); err != nil {
+			return nil, err
+		}
+		if alert, ok := p.StockAlert(previous); ok {
+			alerts = append(alerts, alert)
+		}
+
+		m := NewInventoryMovement(p.ID, c.SKU, -quantity, MovementReasonOrderReservation, CustomerActor(o.Customer.ID))
+		m.OrderID = o.ID
+		if err := r.Inventory().Store(ctx, m); err != nil {
+			return nil, err
+		}
+	}
+	cartItem.Components = append([]Component(nil), bundle.Components...)
+	return alerts, nil
+}
+
+// ReleaseBundle puts the components reserved for the bundle line of the order back in stock, the release is recorded
+// in the inventory ledger on behalf of actor. IDs of the released products are returned.
+func ReleaseBundle(ctx context.Context, r Repositories, o *Order, cartItem CartItem, actor string) ([]string, error) {
+	released := make([]string, 0, len(cartItem.Components))
+	for _, c := range cartItem.Components {
+		p, err := r.Products().FindByID(ctx, c.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		quantity := c.Quantity * cartItem.Quantity
+		p.RollbackQuantity(c.SKU, quantity)
+		if err := r.Products().Update(ctx, p); err != nil {
+			return nil, err
+		}
+		released = append(released, p.ID)
+
+		m := NewInventoryMovement(p.ID, c.SKU, quantity, MovementReasonOrderRelease, actor)
+		m.OrderID = o.ID
+		if err := r.Inventory().Store(ctx, m); err != nil {
+			return nil, err
+		}
+	}
+	return released, nil
+}
@@ -0,0 +1,109 @@
+package transaction
+
+import "context"
+
+var (
+	// ErrCategoryNotFound tells that category can not be found
+	ErrCategoryNotFound = NewError(KindNotFound, "category_not_found", "category not found")
+	// ErrInvalidCategory tells that category has no name, or its parent does not exist or is the category itself or one of its descendants
+	ErrInvalidCategory = NewError(KindInvalid, "invalid_category", "error invalid category")
+	// ErrCategoryInUse tells that category still has subcategories or products, it can not be deleted
+	ErrCategoryInUse = NewError(KindConflict, "category_in_use", "error category is in use")
+)
+
+// Category groups products, categories form a tree where root categories have no ParentID
+type Category struct {
+	ID       string `bson:"_id" json:"id"`
+	Name     string `bson:"name" json:"name"`
+	ParentID string `bson:"parent_id" json:"parent_id"`
+	Version  int64  `bson:"version" json:"version"`
+}
+
+// NewCategory creates new category under parent, empty parentID creates a root category
+func NewCategory(name, parentID string, categories Categories) (*Category, error) {
+	c := &Category{}
+	if err := c.Edit(name, parentID, categories); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Edit renames the category and moves it under parent, the category can not be moved into its own subtree
+func (c *Category) Edit(name, parentID string, categories Categories) error {
+	if name == "" {
+		return ErrInvalidCategory
+	}
+	if parentID != "" {
+		if !categories.Has(parentID) {
+			return ErrInvalidCategory
+		}
+		if c.ID != "" && categories.InSubtree(parentID, c.ID) {
+			return ErrInvalidCategory
+		}
+	}
+	c.Name = name
+	c.ParentID = parentID
+	return nil
+}
+
+// Categories is the whole category tree
+type Categories []Category
+
+// Has tells whether the category exists
+func (cs Categories) Has(id string) bool {
+	for _, c := range cs {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// InSubtree tells whether category id is rootID or one of its descendants
+func (cs Categories) InSubtree(id, rootID string) bool {
+	parents := make(map[string]string, len(cs))
+	for _, c := range cs {
+		parents[c.ID] = c.ParentID
+	}
+	// the walk is bounded in case the stored tree is already broken
+	for i := 0; id != "" && i <= len(cs); i++ {
+		if id == rootID {
+			return true
+		}
+		id = parents[id]
+	}
+	return false
+}
+
+// Subtree lists the ID of rootID and of all its descendants
+func (cs Categories) Subtree(rootID string) []string {
+	ids := make([]string, 0)
+	for _, c := range cs {
+		if cs.InSubtree(c.ID, rootID) {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids
+}
+
+// Children lists the direct subcategories of the category
+func (cs Categories) Children(id string) Categories {
+	children := make(Categories, 0)
+	for _, c := range cs {
+		if c.ParentID == id && c.ID != id {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// CategoryRepository provides access to categories. Store generates the ID of the category.
+// Update only succeeds when the stored category has the same Version as the given one,
+// otherwise ErrConcurrentModification is returned. On success the Version of the given category is incremented.
+type CategoryRepository interface {
+	FindByID(ctx context.Context, id string) (*Category, error)
+	FindAll(ctx context.Context) (Categories, error)
+	Store(ctx context.Context, category *Category) error
+	Update(ctx context.Context, category *Category) error
+	Delete(ctx context.Context, id string) error
+}
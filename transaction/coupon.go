@@ -2,7 +2,6 @@ package transaction
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -10,9 +9,9 @@ import (
 
 var (
 	// ErrInvalidCoupon tells that the coupon is not valid, whether has not been started, expired, or limit exceeded.
-	ErrInvalidCoupon = errors.New("error invalid coupon")
+	ErrInvalidCoupon = NewError(KindConflict, "invalid_coupon", "error invalid coupon")
 	// ErrCouponNotFound tells that order can not be found
-	ErrCouponNotFound = errors.New("coupon not found")
+	ErrCouponNotFound = NewError(KindNotFound, "coupon_not_found", "coupon not found")
 )
 
 // Coupon is price reduction scheme that can be applied to an order
@@ -23,6 +22,7 @@ type Coupon struct {
 	Begin    time.Time       `bson:"begin" json:"begin"`
 	End      time.Time       `bson:"end" json:"end"`
 	Type     CouponType      `bson:"type" json:"type"`
+	Version  int64           `bson:"version" json:"version"`
 }
 
 // CouponType type of the coupon
@@ -57,6 +57,20 @@ func (c *Coupon) Validate() error {
 	return nil
 }
 
+// Use takes one coupon's quota for an order
+func (c *Coupon) Use() error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	c.Quantity--
+	return nil
+}
+
+// Release returns coupon's quota taken by an order
+func (c *Coupon) Release() {
+	c.Quantity++
+}
+
 // GetPriceAfterReduction applies coupon reduction to the price
 func (c *Coupon) GetPriceAfterReduction(price decimal.Decimal) decimal.Decimal {
 	switch c.Type {
@@ -68,7 +82,9 @@ func (c *Coupon) GetPriceAfterReduction(price decimal.Decimal) decimal.Decimal {
 	return price
 }
 
-// CouponRepository provides access to coupons
+// CouponRepository provides access to coupons.
+// Update only succeeds when the stored coupon has the same Version as the given one,
+// otherwise ErrConcurrentModification is returned. On success the Version of the given coupon is incremented.
 type CouponRepository interface {
 	FindByCode(ctx context.Context, code string) (*Coupon, error)
 	Update(ctx context.Context, coupon *Coupon) error
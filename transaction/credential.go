@@ -0,0 +1,66 @@
+package transaction
+
+import (
+	"context"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// minPasswordLength is the shortest password a customer or an admin can choose
+const minPasswordLength = 8
+
+var (
+	// ErrCredentialNotFound tells that customer has no password set
+	ErrCredentialNotFound = NewError(KindNotFound, "credential_not_found", "credential not found")
+	// ErrInvalidPassword tells that password is too short or too long to be hashed
+	ErrInvalidPassword = NewError(KindInvalid, "invalid_password", "error invalid password")
+	// ErrInvalidCredentials tells that email or password is wrong, which one is not told on purpose
+	ErrInvalidCredentials = NewError(KindUnauthenticated, "invalid_credentials", "error invalid email or password")
+)
+
+// Credential is the password of the customer, only its bcrypt hash is kept.
+// It's kept apart from the customer so the hash never ends up in orders or responses.
+type Credential struct {
+	CustomerID   string `bson:"_id" json:"customer_id"`
+	PasswordHash []byte `bson:"password_hash" json:"password_hash"`
+}
+
+// NewCredential hashes the password of the customer
+func NewCredential(customerID, password string) (*Credential, error) {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return &Credential{CustomerID: customerID, PasswordHash: hash}, nil
+}
+
+// CheckPassword checks the password matches the hash
+func (c *Credential) CheckPassword(password string) error {
+	return checkPassword(c.PasswordHash, password)
+}
+
+// hashPassword returns the bcrypt hash of the password
+func hashPassword(password string) ([]byte, error) {
+	if len(password) < minPasswordLength {
+		return nil, ErrInvalidPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+	return hash, nil
+}
+
+// checkPassword returns ErrInvalidCredentials when the password does not match the hash
+func checkPassword(hash []byte, password string) error {
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// CredentialRepository provides access to credentials of customers, Store replaces the credential the customer already has
+type CredentialRepository interface {
+	FindByCustomerID(ctx context.Context, customerID string) (*Credential, error)
+	Store(ctx context.Context, credential *Credential) error
+}
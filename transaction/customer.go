@@ -0,0 +1,152 @@
+package transaction
+
+import (
+	"context"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrCustomerNotFound tells that customer can not be found
+	ErrCustomerNotFound = NewError(KindNotFound, "customer_not_found", "customer not found")
+	// ErrInvalidCustomer tells that customer has no name, or has invalid email or phone number
+	ErrInvalidCustomer = NewError(KindInvalid, "invalid_customer", "error invalid customer")
+	// ErrEmailTaken tells that the email already belongs to another customer
+	ErrEmailTaken = NewError(KindConflict, "email_taken", "error email is already taken")
+	// ErrPhoneNumberTaken tells that the phone number already belongs to another customer
+	ErrPhoneNumberTaken = NewError(KindConflict, "phone_number_taken", "error phone number is already taken")
+	// ErrInvalidContact tells that the kind of contact is neither email nor phone
+	ErrInvalidContact = NewError(KindInvalid, "invalid_contact", "error invalid contact")
+	// ErrInvalidVerification tells that the verification token is invalid, expired or was issued for another contact
+	ErrInvalidVerification = NewError(KindInvalid, "invalid_verification", "error invalid verification")
+)
+
+// Customer represents customer who want to buy products from the shop.
+// Email and phone number are unique among customers, they are verified by the customer
+// and changing them has to be verified again.
+type Customer struct {
+	ID            string `bson:"_id" json:"id"`
+	Name          string `bson:"name" json:"name"`
+	PhoneNumber   string `bson:"phone_number" json:"phone_number"`
+	Email         string `bson:"email" json:"email"`
+	Address       string `bson:"address" json:"address"`
+	EmailVerified bool   `bson:"email_verified" json:"email_verified"`
+	PhoneVerified bool   `bson:"phone_verified" json:"phone_verified"`
+	Version       int64  `bson:"version" json:"version"`
+}
+
+// ContactKind is a way to contact the customer
+type ContactKind string
+
+const (
+	// ContactEmail is the email of the customer
+	ContactEmail ContactKind = "email"
+	// ContactPhone is the phone number of the customer
+	ContactPhone ContactKind = "phone"
+)
+
+var phoneNumberPattern = regexp.MustCompile(`^\+?[0-9][0-9-]{3,19}$`)
+
+// NewCustomer creates new customer with unverified contacts
+func NewCustomer(name, email, phoneNumber, address string) (*Customer, error) {
+	c := &Customer{}
+	if err := c.EditProfile(name, address); err != nil {
+		return nil, err
+	}
+	if err := c.ChangeEmail(email); err != nil {
+		return nil, err
+	}
+	if err := c.ChangePhoneNumber(phoneNumber); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// EditProfile changes name and address of the customer
+func (c *Customer) EditProfile(name, address string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrInvalidCustomer
+	}
+	c.Name = name
+	c.Address = strings.TrimSpace(address)
+	return nil
+}
+
+// ChangeEmail changes the email of the customer, the email is kept lower-cased.
+// A different email is unverified.
+func (c *Customer) ChangeEmail(email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if a, err := mail.ParseAddress(email); err != nil || a.Address != email {
+		return ErrInvalidCustomer
+	}
+	if email != c.Email {
+		c.Email = email
+		c.EmailVerified = false
+	}
+	return nil
+}
+
+// ChangePhoneNumber changes the phone number of the customer, spaces are removed.
+// A different phone number is unverified.
+func (c *Customer) ChangePhoneNumber(phoneNumber string) error {
+	phoneNumber = strings.Join(strings.Fields(phoneNumber), "")
+	if !phoneNumberPattern.MatchString(phoneNumber) {
+		return ErrInvalidCustomer
+	}
+	if phoneNumber != c.PhoneNumber {
+		c.PhoneNumber = phoneNumber
+		c.PhoneVerified = false
+	}
+	return nil
+}
+
+// Contact returns the email or the phone number of the customer
+func (c *Customer) Contact(kind ContactKind) (string, error) {
+	switch kind {
+	case ContactEmail:
+		return c.Email, nil
+	case ContactPhone:
+		return c.PhoneNumber, nil
+	default:
+		return "", ErrInvalidContact
+	}
+}
+
+// VerifyContact marks the email or the phone number of the customer as verified,
+// contact is the one which was verified so a contact changed in the meantime stays unverified.
+func (c *Customer) VerifyContact(kind ContactKind, contact string) error {
+	current, err := c.Contact(kind)
+	if err != nil {
+		return err
+	}
+	if contact != current {
+		return ErrInvalidVerification
+	}
+	if kind == ContactEmail {
+		c.EmailVerified = true
+	} else {
+		c.PhoneVerified = true
+	}
+	return nil
+}
+
+// ContactVerificationSender sends the token to the contact of the customer, the customer verifies the contact with it.
+// Sending is best effort, the customer can request another verification.
+type ContactVerificationSender interface {
+	SendContactVerification(ctx context.Context, customer *Customer, kind ContactKind, token string)
+}
+
+// CustomerRepository provides access to customers. Store generates the ID of the customer, FindByEmail looks up the lower-cased email.
+// Store and Update return ErrEmailTaken or ErrPhoneNumberTaken when another customer has the same email or phone number.
+// Update only succeeds when the stored customer has the same Version as the given one,
+// otherwise ErrConcurrentModification is returned. On success the Version of the given customer is incremented.
+// Delete removes the customer, it's only meant for undoing a signup that couldn't be completed.
+type CustomerRepository interface {
+	FindByID(ctx context.Context, id string) (*Customer, error)
+	FindByEmail(ctx context.Context, email string) (*Customer, error)
+	Store(ctx context.Context, customer *Customer) error
+	Update(ctx context.Context, customer *Customer) error
+	Delete(ctx context.Context, id string) error
+}
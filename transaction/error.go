@@ -0,0 +1,54 @@
+package transaction
+
+import "errors"
+
+// Kind classifies errors by what went wrong, regardless of which aggregate it's about.
+// Adapters map it to their own vocabulary, e.g. HTTP status codes.
+type Kind int
+
+const (
+	// KindInternal is an unexpected failure, its details are not meant for the caller
+	KindInternal Kind = iota
+	// KindInvalid tells that the input is malformed or breaks a rule of the domain
+	KindInvalid
+	// KindUnauthenticated tells that the caller is not known
+	KindUnauthenticated
+	// KindForbidden tells that the caller is not allowed to do the operation
+	KindForbidden
+	// KindNotFound tells that the subject of the operation does not exist
+	KindNotFound
+	// KindConflict tells that the operation can't be done in the current state of the subject
+	KindConflict
+	// KindUnprocessable tells that the input is well-formed but can't be processed as it is
+	KindUnprocessable
+	// KindExternal tells that a system the shop depends on, such as the logistics partner, failed
+	KindExternal
+)
+
+// Error is an error having a stable Code meant for machines, the message may change anytime.
+// Errors are compared with errors.Is against the sentinel errors, which still works after they're wrapped with %w,
+// and their Code and Kind are read with errors.As.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+// NewError creates new error of the kind with the code and the message
+func NewError(kind Kind, code, message string) error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrorOf returns the Error in the chain of err, errors not created by NewError are unexpected ones
+// and return an Error of KindInternal
+func ErrorOf(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Kind: KindInternal, Code: "internal", Message: "internal error"}
+}
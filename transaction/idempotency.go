@@ -0,0 +1,50 @@
+package transaction
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyNotFound tells that no request has been made with the idempotency key
+	ErrIdempotencyKeyNotFound = NewError(KindNotFound, "idempotency_key_not_found", "idempotency key not found")
+	// ErrIdempotencyKeyExists tells that a request has already been made with the idempotency key
+	ErrIdempotencyKeyExists = NewError(KindConflict, "idempotency_key_exists", "error idempotency key already exists")
+)
+
+// IdempotencyTTL is how long the response of a request made with an idempotency key is replayed
+const IdempotencyTTL = 24 * time.Hour
+
+// IdempotencyRecord is a request made by a client with an idempotency key together with its response.
+// Fingerprint tells repeats of the request apart from other requests reusing the key,
+// StatusCode stays zero until the response is known.
+type IdempotencyRecord struct {
+	Client      string    `bson:"client"`
+	Key         string    `bson:"key"`
+	Fingerprint []byte    `bson:"fingerprint"`
+	StatusCode  int       `bson:"status_code"`
+	ContentType string    `bson:"content_type"`
+	Body        []byte    `bson:"body"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+// Completed tells whether the response of the request is known
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// Expired tells whether the record is too old to be replayed at now
+func (r *IdempotencyRecord) Expired(now time.Time) bool {
+	return !now.Before(r.CreatedAt.Add(IdempotencyTTL))
+}
+
+// IdempotencyRepository provides access to idempotency records identified by their client and key,
+// records older than IdempotencyTTL are treated as not found. Reserve stores a record without response
+// and returns ErrIdempotencyKeyExists when the client already made a request with the key,
+// Complete stores the response of a reserved record and Release removes it so the request can be made again.
+type IdempotencyRepository interface {
+	Find(ctx context.Context, client, key string) (*IdempotencyRecord, error)
+	Reserve(ctx context.Context, record *IdempotencyRecord) error
+	Complete(ctx context.Context, record *IdempotencyRecord) error
+	Release(ctx context.Context, client, key string) error
+}
@@ -0,0 +1,105 @@
+package transaction
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	// ErrInvalidMovement tells that an inventory movement does not change the stock or its reason is not allowed
+	ErrInvalidMovement = NewError(KindInvalid, "invalid_inventory_movement", "error invalid inventory movement")
+)
+
+// MovementReason tells why the stock of a product is changed
+type MovementReason string
+
+const (
+	// MovementReasonOpeningBalance records the stock a product had before the ledger was kept
+	MovementReasonOpeningBalance MovementReason = "opening_balance"
+	// MovementReasonRestock records goods received from supplier
+	MovementReasonRestock MovementReason = "restock"
+	// MovementReasonCorrection records a stock take finding the stock is different from the recorded one
+	MovementReasonCorrection MovementReason = "correction"
+	// MovementReasonDamage records goods written off since they are damaged or lost
+	MovementReasonDamage MovementReason = "damage"
+	// MovementReasonOrderReservation records stock reserved by a submitted order
+	MovementReasonOrderReservation MovementReason = "order_reservation"
+	// MovementReasonOrderRelease records stock returned by a cancelled order
+	MovementReasonOrderRelease MovementReason = "order_release"
+)
+
+// Manual tells whether the reason can be used by admin adjusting stock by hand,
+// other reasons are recorded by the system.
+func (r MovementReason) Manual() bool {
+	switch r {
+	case MovementReasonRestock, MovementReasonCorrection, MovementReasonDamage:
+		return true
+	}
+	return false
+}
+
+// Allows tells whether the reason can change the stock by quantity, e.g. restock only adds stock while damage only takes it out
+func (r MovementReason) Allows(quantity int64) bool {
+	switch r {
+	case MovementReasonOpeningBalance, MovementReasonRestock, MovementReasonOrderRelease:
+		return quantity > 0
+	case MovementReasonDamage, MovementReasonOrderReservation:
+		return quantity < 0
+	case MovementReasonCorrection:
+		return quantity != 0
+	}
+	return false
+}
+
+// InventoryMovement is an immutable entry of the inventory ledger, the stock of a product is the sum of its movements.
+// SKU is the variant whose stock is changed, empty for a product without variants.
+type InventoryMovement struct {
+	ID        string         `bson:"_id" json:"id"`
+	ProductID string         `bson:"product_id" json:"product_id"`
+	SKU       string         `bson:"sku" json:"sku"`
+	Quantity  int64          `bson:"quantity" json:"quantity"`
+	Reason    MovementReason `bson:"reason" json:"reason"`
+	Actor     string         `bson:"actor" json:"actor"`
+	OrderID   string         `bson:"order_id" json:"order_id"`
+	Note      string         `bson:"note" json:"note"`
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+}
+
+// NewInventoryMovement creates new movement changing the stock of the product's variant by quantity, negative quantity takes stock out
+func NewInventoryMovement(productID, sku string, quantity int64, reason MovementReason, actor string) *InventoryMovement {
+	return &InventoryMovement{
+		ProductID: productID,
+		SKU:       sku,
+		Quantity:  quantity,
+		Reason:    reason,
+		Actor:     actor,
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+// Stock reconstructs the stock of a product out of its movements
+func Stock(movements []InventoryMovement) int64 {
+	var stock int64
+	for _, m := range movements {
+		stock += m.Quantity
+	}
+	return stock
+}
+
+// StockOf reconstructs the stock of a variant out of the movements of its product
+func StockOf(movements []InventoryMovement, sku string) int64 {
+	var stock int64
+	for _, m := range movements {
+		if m.SKU == sku {
+			stock += m.Quantity
+		}
+	}
+	return stock
+}
+
+// InventoryRepository provides access to the inventory ledger. Store generates the ID of the movement,
+// movements are never changed nor removed. FindByProductID lists movements in the order they were stored.
+type InventoryRepository interface {
+	Store(ctx context.Context, movement *InventoryMovement) error
+	FindByProductID(ctx context.Context, productID string) ([]InventoryMovement, error)
+}
@@ -1,15 +1,12 @@
 package transaction
 
-import (
-	"context"
-	"errors"
-)
+import "context"
 
 var (
 	// ErrLogisticsRegister occurs when trying to register order to logistics partner
-	ErrLogisticsRegister = errors.New("error register logistics")
+	ErrLogisticsRegister = NewError(KindExternal, "logistics_register_failed", "error register logistics")
 	// ErrLogisticsCheckShipment occurs when trying to check shipment status from logistics partner
-	ErrLogisticsCheckShipment = errors.New("error check shipment logistics")
+	ErrLogisticsCheckShipment = NewError(KindExternal, "logistics_check_shipment_failed", "error check shipment logistics")
 )
 
 // ShipmentStatus type shipment status
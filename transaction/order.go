@@ -3,22 +3,26 @@ package transaction
 
 import (
 	"context"
-	"errors"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
 
 var (
 	// ErrOrderIsAlreadyFinalized tells that an order can not be changed since it's already finalized.
-	ErrOrderIsAlreadyFinalized = errors.New("error order is already finalized")
+	ErrOrderIsAlreadyFinalized = NewError(KindConflict, "order_already_finalized", "error order is already finalized")
 	// ErrOrderIsAlreadyCompleted tells that an order can not be changed since it's already completed.
-	ErrOrderIsAlreadyCompleted = errors.New("error order is already completed")
+	ErrOrderIsAlreadyCompleted = NewError(KindConflict, "order_already_completed", "error order is already completed")
 	// ErrOrderIsAlreadyCanceled tells that an order can not be changed since it's already canceled.
-	ErrOrderIsAlreadyCanceled = errors.New("error order is already completed")
+	ErrOrderIsAlreadyCanceled = NewError(KindConflict, "order_already_canceled", "error order is already canceled")
 	// ErrOrderIsAlreadyShipped tells that an order can not be changed since it's already shipped.
-	ErrOrderIsAlreadyShipped = errors.New("error order is already shipped")
+	ErrOrderIsAlreadyShipped = NewError(KindConflict, "order_already_shipped", "error order is already shipped")
+	// ErrInvalidQuantity tells that the quantity of a cart item is not positive
+	ErrInvalidQuantity = NewError(KindInvalid, "invalid_quantity", "error invalid quantity")
 	// ErrOrderNotFound tells that order can not be found
-	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderNotFound = NewError(KindNotFound, "order_not_found", "order not found")
+	// ErrConcurrentModification tells that an aggregate has been changed by someone else since it was loaded.
+	ErrConcurrentModification = NewError(KindConflict, "concurrent_modification", "error concurrent modification")
 )
 
 // Order is the central class in the domain model
@@ -32,12 +36,21 @@ type Order struct {
 	Customer             Customer             `bson:"customer" json:"customer"`
 	PaymentSpecification PaymentSpecification `bson:"payment_specification" json:"payment_specification"`
 	ShippingID           ShippingID           `bson:"shipping_id" json:"shipping_id"`
+	ShippedBy            string               `bson:"shipped_by" json:"shipped_by"`
+	CreatedAt            time.Time            `bson:"created_at" json:"created_at"`
+	Version              int64                `bson:"version" json:"version"`
 }
 
-// CartItem represents list of potential bought product with its quantity
+// CartItem represents list of potential bought product with its quantity,
+// SKU is the chosen variant of the product or empty when the product has no variants.
+// Backordered is the part of Quantity which could not be reserved on submit, it's waiting for the product to be restocked.
+// Components are the components of a bundle per bundle as they were reserved on submit.
 type CartItem struct {
-	Product  *Product
-	Quantity int64
+	Product     *Product
+	SKU         string
+	Quantity    int64
+	Backordered int64
+	Components  []Component
 }
 
 // OrderStatus type of status order
@@ -76,27 +89,44 @@ func (s OrderStatus) String() string {
 	return ""
 }
 
-// NewOrder makes an order
+// NewOrder makes an order, its creation time is truncated to millisecond which every repository can store
 func NewOrder(customer *Customer) *Order {
 	return &Order{
-		Customer: *customer,
-		Cart:     []CartItem{},
-		Status:   OrderStatusOpen,
+		Customer:  *customer,
+		Cart:      []CartItem{},
+		Status:    OrderStatusOpen,
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
 	}
 }
 
-// AddProduct add product to the order's ChartItems
-func (o *Order) AddProduct(p *Product, quantity int64) error {
+// AddProduct add the variant of the product, or the product itself when sku is empty, to the order's ChartItems.
+// The cart keeps the product as it is when added, priced at the price of the variant.
+// Adding the product already in the cart changes its quantity.
+func (o *Order) AddProduct(p *Product, sku string, quantity int64) error {
 	if o.Status != OrderStatusOpen {
 		return ErrOrderIsAlreadyFinalized
 	}
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+	if sku == "" && p.HasVariants() {
+		return ErrVariantRequired
+	}
+	price, err := p.PriceOf(sku)
+	if err != nil {
+		return err
+	}
 	for i := range o.Cart {
-		if o.Cart[i].Product.ID == p.ID {
+		if o.Cart[i].Product.ID == p.ID && o.Cart[i].SKU == sku {
 			o.Cart[i].Quantity = quantity
+			o.CalculateTotalPrice()
 			return nil
 		}
 	}
-	o.Cart = append(o.Cart, CartItem{Product: p, Quantity: quantity})
+	item := *p
+	item.Price = price
+	item.Variants = nil
+	o.Cart = append(o.Cart, CartItem{Product: &item, SKU: sku, Quantity: quantity})
 	o.CalculateTotalPrice()
 	return nil
 }
@@ -128,12 +158,16 @@ func (o *Order) ChangeStatusTo(status OrderStatus) error {
 	if o.Status == status && status == OrderStatusShipped {
 		return ErrOrderIsAlreadyShipped
 	}
+	if status == OrderStatusShipped && !o.Allocated() {
+		return ErrOrderNotAllocated
+	}
 	o.Status = status
 	return nil
 }
 
 // CalculateTotalPrice calculates total price of added products and price after reduction if any coupon is applied
 func (o *Order) CalculateTotalPrice() {
+	o.Price = decimal.Zero
 	for _, cartItem := range o.Cart {
 		o.Price = o.Price.Add(cartItem.Product.Price.Mul(decimal.NewFromInt(cartItem.Quantity)))
 	}
@@ -142,6 +176,31 @@ func (o *Order) CalculateTotalPrice() {
 	}
 }
 
+// HasCoupon tells whether a coupon is applied to the order
+func (o *Order) HasCoupon() bool {
+	return o.Coupon.Code != ""
+}
+
+// HoldsReservation is a policy to an order is holding reserved products quantity and coupon,
+// it's true since the order is submitted until it's completed or canceled.
+func (o *Order) HoldsReservation() bool {
+	switch o.Status {
+	case OrderStatusSubmitted, OrderStatusPaid, OrderStatusShipped:
+		return true
+	}
+	return false
+}
+
+// Allocated tells whether every line of the order is reserved, i.e. nothing is backordered
+func (o *Order) Allocated() bool {
+	for _, cartItem := range o.Cart {
+		if cartItem.Backordered > 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // AllowMakePayment is a policy to an order is allowed payment to be made
 func (o *Order) AllowMakePayment() bool {
 	return o.Status == OrderStatusSubmitted
@@ -152,16 +211,19 @@ func (o *Order) SpecifyNewPayment(ps PaymentSpecification) {
 	o.PaymentSpecification = ps
 }
 
-// SpecifyShippingID specifies shippingID retrieved from logistics partner
-func (o *Order) SpecifyShippingID(shippingID ShippingID) {
+// SpecifyShippingID specifies shippingID retrieved from logistics partner and the actor who shipped the order
+func (o *Order) SpecifyShippingID(shippingID ShippingID, actor string) {
 	o.ShippingID = shippingID
+	o.ShippedBy = actor
 }
 
-// OrderRepository provides access to orders
+// OrderRepository provides access to orders.
+// Update only succeeds when the stored order has the same Version as the given one,
+// otherwise ErrConcurrentModification is returned. On success the Version of the given order is incremented.
+// Find lists orders matching the filter, ErrInvalidCursor is returned when the page cursor can not be decoded.
 type OrderRepository interface {
 	FindByID(ctx context.Context, id string) (*Order, error)
+	Find(ctx context.Context, filter OrderFilter, page Page) (*OrderPage, error)
 	Store(ctx context.Context, order *Order) error
 	Update(ctx context.Context, order *Order) error
-	FinalizeAndReserveProducts(ctx context.Context, order *Order) error
-	CancelAndReleaseProducts(ctx context.Context, order *Order) error
 }
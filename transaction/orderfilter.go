@@ -0,0 +1,145 @@
+package transaction
+
+import (
+	"time"
+)
+
+// OrderSort is the order of a listing of orders, orders created at the same time are ordered by their ID
+type OrderSort int
+
+const (
+	// OrderSortNewest lists the most recently created orders first
+	OrderSortNewest OrderSort = iota
+	// OrderSortOldest lists the least recently created orders first, e.g. the queue of orders to be handled
+	OrderSortOldest
+)
+
+// OrderFilter narrows down a listing of orders, zero value fields do not filter.
+// CreatedFrom is inclusive and CreatedTo is exclusive.
+type OrderFilter struct {
+	CustomerID  string
+	Statuses    []OrderStatus
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	CouponCode  string
+	ProductID   string
+	ShippingID  ShippingID
+	Sort        OrderSort
+}
+
+// Match tells whether an order passes the filter
+func (f OrderFilter) Match(o *Order) bool {
+	if f.CustomerID != "" && o.Customer.ID != f.CustomerID {
+		return false
+	}
+	if len(f.Statuses) != 0 {
+		found := false
+		for _, status := range f.Statuses {
+			if o.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.CreatedFrom.IsZero() && o.CreatedAt.Before(f.CreatedFrom) {
+		return false
+	}
+	if !f.CreatedTo.IsZero() && !o.CreatedAt.Before(f.CreatedTo) {
+		return false
+	}
+	if f.CouponCode != "" && o.Coupon.Code != f.CouponCode {
+		return false
+	}
+	if f.ShippingID != "" && o.ShippingID != f.ShippingID {
+		return false
+	}
+	if f.ProductID != "" {
+		found := false
+		for _, cartItem := range o.Cart {
+			if cartItem.Product != nil && cartItem.Product.ID == f.ProductID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Less tells whether order a comes before order b in the sort order of the filter
+func (f OrderFilter) Less(a, b *Order) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		if f.Sort == OrderSortOldest {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	if f.Sort == OrderSortOldest {
+		return a.ID < b.ID
+	}
+	return a.ID > b.ID
+}
+
+// OrderCursor is the position of the last order of a page, next page starts after it
+type OrderCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	Sort      OrderSort `json:"sort"`
+}
+
+// NewOrderCursor creates cursor positioned at the order
+func NewOrderCursor(o *Order, sort OrderSort) OrderCursor {
+	return OrderCursor{CreatedAt: o.CreatedAt, ID: o.ID, Sort: sort}
+}
+
+// String encodes the cursor into an opaque string
+func (c OrderCursor) String() string {
+	return encodeCursor(c)
+}
+
+// After tells whether an order comes after the cursor
+func (c OrderCursor) After(o *Order) bool {
+	return OrderFilter{Sort: c.Sort}.Less(&Order{ID: c.ID, CreatedAt: c.CreatedAt}, o)
+}
+
+// DecodeOrderCursor decodes a cursor of a listing sorted by sort, it returns nil on empty string.
+func DecodeOrderCursor(s string, sort OrderSort) (*OrderCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var c OrderCursor
+	if err := decodeCursor(s, &c); err != nil {
+		return nil, err
+	}
+	if c.Sort != sort || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// OrderPage is a page of a listing of orders, NextCursor is empty on the last page
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"next_cursor"`
+}
+
+// NewOrderPage makes a page out of orders fetched with one more than the page size,
+// the extra order tells that there is a next page.
+func NewOrderPage(orders []Order, filter OrderFilter, page Page) *OrderPage {
+	if orders == nil {
+		orders = []Order{}
+	}
+	if len(orders) <= page.Size() {
+		return &OrderPage{Orders: orders}
+	}
+	orders = orders[:page.Size()]
+	return &OrderPage{
+		Orders:     orders,
+		NextCursor: NewOrderCursor(&orders[len(orders)-1], filter.Sort).String(),
+	}
+}
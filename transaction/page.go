@@ -0,0 +1,53 @@
+package transaction
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+var (
+	// ErrInvalidCursor tells that a page cursor is malformed or does not belong to the listing
+	ErrInvalidCursor = NewError(KindInvalid, "invalid_cursor", "invalid cursor")
+)
+
+const (
+	// DefaultPageLimit is the number of items of a page when the limit is not specified
+	DefaultPageLimit = 20
+	// MaxPageLimit is the maximum number of items of a page
+	MaxPageLimit = 100
+)
+
+// Page requests a part of a listing. Cursor is the NextCursor of the previous page, empty for the first page.
+type Page struct {
+	Cursor string
+	Limit  int
+}
+
+// Size returns the limit bounded to DefaultPageLimit and MaxPageLimit
+func (p Page) Size() int {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return p.Limit
+}
+
+// encodeCursor encodes a cursor into an opaque string
+func encodeCursor(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor decodes an opaque string made by encodeCursor
+func decodeCursor(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
@@ -1,15 +1,12 @@
 package transaction
 
-import (
-	"encoding/base64"
-	"errors"
-)
+import "encoding/base64"
 
 var (
 	// ErrPaymentTypeNotAllowed tells that payment type to purchase the order is not allowed.
-	ErrPaymentTypeNotAllowed = errors.New("error payment method not allowed")
+	ErrPaymentTypeNotAllowed = NewError(KindInvalid, "payment_type_not_allowed", "error payment method not allowed")
 	// ErrPaymentProofIsNotBase64EncodedString tells that payment proof is not a base64 encoded string.
-	ErrPaymentProofIsNotBase64EncodedString = errors.New("payment proof is not base64 encoded string")
+	ErrPaymentProofIsNotBase64EncodedString = NewError(KindInvalid, "invalid_payment_proof", "payment proof is not base64 encoded string")
 )
 
 // PaymentSpecification contains information about a payment: its type,
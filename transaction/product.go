@@ -2,47 +2,344 @@ package transaction
 
 import (
 	"context"
-	"errors"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
 
 var (
 	// ErrQuantityExceedProductStock occurs when customer trying to add product more than product available
-	ErrQuantityExceedProductStock = errors.New("error quantity exceed product's stock")
+	ErrQuantityExceedProductStock = NewError(KindConflict, "quantity_exceeds_stock", "error quantity exceed product's stock")
 	// ErrProductNotFound tells that product can not be found
-	ErrProductNotFound = errors.New("product not found")
+	ErrProductNotFound = NewError(KindNotFound, "product_not_found", "product not found")
+	// ErrProductArchived tells that product is archived, it can no longer be ordered nor edited
+	ErrProductArchived = NewError(KindConflict, "product_archived", "error product is archived")
+	// ErrInvalidProduct tells that product has no name or has negative price
+	ErrInvalidProduct = NewError(KindInvalid, "invalid_product", "error invalid product")
+	// ErrVariantNotFound tells that product has no variant with the SKU
+	ErrVariantNotFound = NewError(KindNotFound, "variant_not_found", "variant not found")
+	// ErrVariantRequired tells that product has variants, one of them has to be chosen
+	ErrVariantRequired = NewError(KindInvalid, "variant_required", "error product variant is required")
+	// ErrInvalidVariant tells that variant has no SKU or name, has negative price, or its SKU is already taken
+	ErrInvalidVariant = NewError(KindInvalid, "invalid_variant", "error invalid variant")
 )
 
-// Product represent item to sell
+// Product represent item to sell. A product having variants is sold through its variants,
+// its Quantity is the sum of the stock of its variants. CategoryID is empty for uncategorized product.
+// The product needs reordering once its Quantity is at or below ReorderThreshold.
+// A product can be ordered beyond its stock as told by Backorder, AvailableAt is when it's expected to be in stock.
+// A product having Components is a bundle, its Quantity is only known once it's resolved from the stock of its components.
 type Product struct {
-	ID       string          `bson:"_id" json:"id"`
-	Name     string          `bson:"name" json:"name"`
-	Price    decimal.Decimal `bson:"price" json:"price"`
-	Quantity int64           `bson:"quantity" json:"quantity"`
+	ID               string          `bson:"_id" json:"id"`
+	Name             string          `bson:"name" json:"name"`
+	Description      string          `bson:"description" json:"description"`
+	Price            decimal.Decimal `bson:"price" json:"price"`
+	Quantity         int64           `bson:"quantity" json:"quantity"`
+	Variants         []Variant       `bson:"variants,omitempty" json:"variants,omitempty"`
+	CategoryID       string          `bson:"category_id" json:"category_id"`
+	Tags             []string        `bson:"tags,omitempty" json:"tags,omitempty"`
+	ReorderThreshold int64           `bson:"reorder_threshold" json:"reorder_threshold"`
+	Backorder        BackorderPolicy `bson:"backorder" json:"backorder"`
+	AvailableAt      *time.Time      `bson:"available_at,omitempty" json:"available_at"`
+	Components       []Component     `bson:"components,omitempty" json:"components,omitempty"`
+	Archived         bool            `bson:"archived" json:"archived"`
+	Version          int64           `bson:"version" json:"version"`
 }
 
-// TryReserveQuantity checks whether product's quantity can be reserved
-func (p *Product) TryReserveQuantity(quantity int64) error {
-	if p.Quantity < quantity {
+// Variant is a version of a product such as its color or storage size, identified by SKU and having its own stock.
+// Price overrides the price of the product when it's set.
+type Variant struct {
+	SKU      string           `bson:"sku" json:"sku"`
+	Name     string           `bson:"name" json:"name"`
+	Price    *decimal.Decimal `bson:"price,omitempty" json:"price,omitempty"`
+	Quantity int64            `bson:"quantity" json:"quantity"`
+}
+
+// NewProduct creates new product without stock, stock is added through inventory movements
+func NewProduct(name string, price decimal.Decimal) (*Product, error) {
+	p := &Product{}
+	if err := p.Edit(name, price); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Edit changes name and price of the product
+func (p *Product) Edit(name string, price decimal.Decimal) error {
+	if p.Archived {
+		return ErrProductArchived
+	}
+	if name == "" || price.IsNegative() {
+		return ErrInvalidProduct
+	}
+	p.Name = name
+	p.Price = price
+	return nil
+}
+
+// Describe replaces the description of the product
+func (p *Product) Describe(description string) error {
+	if p.Archived {
+		return ErrProductArchived
+	}
+	p.Description = strings.TrimSpace(description)
+	return nil
+}
+
+// SetReorderThreshold changes the stock at or below which the product needs reordering
+func (p *Product) SetReorderThreshold(threshold int64) error {
+	if p.Archived {
+		return ErrProductArchived
+	}
+	if threshold < 0 {
+		return ErrInvalidProduct
+	}
+	p.ReorderThreshold = threshold
+	return nil
+}
+
+// SetBackorder changes whether the product can be ordered beyond its stock, availableAt is required by pre-order
+func (p *Product) SetBackorder(policy BackorderPolicy, availableAt *time.Time) error {
+	if p.Archived {
+		return ErrProductArchived
+	}
+	if p.IsBundle() && policy != BackorderNone {
+		return ErrInvalidBundle
+	}
+	switch policy {
+	case BackorderNone, BackorderAllowed:
+	case BackorderPreOrder:
+		if availableAt == nil {
+			return ErrInvalidProduct
+		}
+	default:
+		return ErrInvalidProduct
+	}
+	if availableAt != nil {
+		t := availableAt.UTC().Truncate(time.Millisecond)
+		availableAt = &t
+	}
+	p.Backorder = policy
+	p.AvailableAt = availableAt
+	return nil
+}
+
+// Backorderable tells whether the product can be ordered beyond its stock
+func (p *Product) Backorderable() bool {
+	return p.Backorder != BackorderNone
+}
+
+// LowStock tells whether the product on sale needs reordering, bundles are reordered through their components
+func (p *Product) LowStock() bool {
+	return !p.Archived && !p.IsBundle() && p.Quantity <= p.ReorderThreshold
+}
+
+// Archive withdraws the product from sale, its stock and history are kept
+func (p *Product) Archive() error {
+	if p.Archived {
+		return ErrProductArchived
+	}
+	p.Archived = true
+	return nil
+}
+
+// Categorize puts the product into the category and replaces its tags, empty categoryID uncategorizes the product.
+// Tags are free-form, they are kept lower-cased, trimmed, sorted and without duplicates.
+func (p *Product) Categorize(categoryID string, tags []string) error {
+	if p.Archived {
+		return ErrProductArchived
+	}
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	if len(normalized) == 0 {
+		normalized = nil
+	}
+	p.CategoryID = categoryID
+	p.Tags = normalized
+	return nil
+}
+
+// HasTag tells whether the product is tagged with tag, tag is compared case-insensitively
+func (p *Product) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// HasVariants tells whether the product is sold through its variants
+func (p *Product) HasVariants() bool {
+	return len(p.Variants) != 0
+}
+
+// Variant finds the variant of the product by its SKU
+func (p *Product) Variant(sku string) (*Variant, error) {
+	for i := range p.Variants {
+		if p.Variants[i].SKU == sku {
+			return &p.Variants[i], nil
+		}
+	}
+	return nil, ErrVariantNotFound
+}
+
+// AddVariant adds variant without stock to the product. Stock of a product without variants is not
+// attributed to any variant, so it has to be taken out before the first variant is added.
+func (p *Product) AddVariant(sku, name string, price *decimal.Decimal) error {
+	if p.Archived {
+		return ErrProductArchived
+	}
+	if sku == "" || name == "" || (price != nil && price.IsNegative()) || p.IsBundle() {
+		return ErrInvalidVariant
+	}
+	if !p.HasVariants() && p.Quantity != 0 {
+		return ErrInvalidVariant
+	}
+	if _, err := p.Variant(sku); err == nil {
+		return ErrInvalidVariant
+	}
+	p.Variants = append(p.Variants, Variant{SKU: sku, Name: name, Price: price})
+	return nil
+}
+
+// EditVariant changes name and price override of the variant
+func (p *Product) EditVariant(sku, name string, price *decimal.Decimal) error {
+	if p.Archived {
+		return ErrProductArchived
+	}
+	v, err := p.Variant(sku)
+	if err != nil {
+		return err
+	}
+	if name == "" || (price != nil && price.IsNegative()) {
+		return ErrInvalidVariant
+	}
+	v.Name = name
+	v.Price = price
+	return nil
+}
+
+// PriceOf returns the price of the variant, or the price of the product when the variant does not override it
+func (p *Product) PriceOf(sku string) (decimal.Decimal, error) {
+	if sku == "" {
+		return p.Price, nil
+	}
+	v, err := p.Variant(sku)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if v.Price != nil {
+		return *v.Price, nil
+	}
+	return p.Price, nil
+}
+
+// stock returns the stock of the variant, or of the product itself when sku is empty
+func (p *Product) stock(sku string) (*int64, error) {
+	if sku == "" {
+		if p.HasVariants() {
+			return nil, ErrVariantRequired
+		}
+		return &p.Quantity, nil
+	}
+	v, err := p.Variant(sku)
+	if err != nil {
+		return nil, err
+	}
+	return &v.Quantity, nil
+}
+
+// TryReserveQuantity checks whether quantity of the variant, or of the product itself when sku is empty, can be reserved.
+// Quantity beyond the stock of a backorderable product can be reserved as backorder.
+func (p *Product) TryReserveQuantity(sku string, quantity int64) error {
+	if p.Archived {
+		return ErrProductArchived
+	}
+	stock, err := p.stock(sku)
+	if err != nil {
+		return err
+	}
+	if *stock < quantity && !p.Backorderable() {
 		return ErrQuantityExceedProductStock
 	}
 	return nil
 }
 
-// ReserveQuantity subtracts quantity from product
-func (p *Product) ReserveQuantity(quantity int64) {
-	p.Quantity -= quantity
+// ReserveQuantity subtracts quantity from the variant, or from the product itself when sku is empty
+func (p *Product) ReserveQuantity(sku string, quantity int64) {
+	p.changeQuantity(sku, -quantity)
+}
+
+// ReserveAvailable subtracts as much of quantity as there is in stock of the variant, or of the product itself
+// when sku is empty, and returns the quantity left to be backordered
+func (p *Product) ReserveAvailable(sku string, quantity int64) int64 {
+	stock, err := p.stock(sku)
+	if err != nil {
+		return quantity
+	}
+	reserved := quantity
+	if *stock < reserved {
+		reserved = *stock
+	}
+	if reserved < 0 {
+		reserved = 0
+	}
+	p.changeQuantity(sku, -reserved)
+	return quantity - reserved
+}
+
+// RollbackQuantity adds quantity from order to the variant, or to the product itself when sku is empty
+func (p *Product) RollbackQuantity(sku string, quantity int64) {
+	p.changeQuantity(sku, quantity)
 }
 
-// RollbackQuantity adds quantity from order to product
-func (p *Product) RollbackQuantity(quantity int64) {
+// AdjustQuantity changes quantity by hand, negative quantity takes stock out of the variant,
+// or out of the product itself when sku is empty. Stock of a bundle is adjusted through its components.
+func (p *Product) AdjustQuantity(sku string, quantity int64) error {
+	if p.IsBundle() {
+		return ErrInvalidBundle
+	}
+	if quantity == 0 {
+		return ErrInvalidMovement
+	}
+	stock, err := p.stock(sku)
+	if err != nil {
+		return err
+	}
+	if *stock+quantity < 0 {
+		return ErrQuantityExceedProductStock
+	}
+	p.changeQuantity(sku, quantity)
+	return nil
+}
+
+// changeQuantity changes the stock of the variant, the product's quantity is kept as the sum of its variants
+func (p *Product) changeQuantity(sku string, quantity int64) {
+	if v, err := p.Variant(sku); err == nil && sku != "" {
+		v.Quantity += quantity
+	}
 	p.Quantity += quantity
 }
 
-// ProductRepository represent
+// ProductRepository provides access to products. Store generates the ID of the product.
+// Update only succeeds when the stored product has the same Version as the given one,
+// otherwise ErrConcurrentModification is returned. On success the Version of the given product is incremented.
 type ProductRepository interface {
 	FindByID(ctx context.Context, id string) (*Product, error)
 	FindAll(ctx context.Context) ([]Product, error)
+	Store(ctx context.Context, product *Product) error
 	Update(ctx context.Context, product *Product) error
 }
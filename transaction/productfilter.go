@@ -0,0 +1,156 @@
+package transaction
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// ProductSort is the order of a listing of products, products with the same sort key are ordered by their ID
+type ProductSort int
+
+const (
+	// ProductSortName lists products alphabetically by name
+	ProductSortName ProductSort = iota
+	// ProductSortPriceLowest lists the cheapest products first
+	ProductSortPriceLowest
+	// ProductSortPriceHighest lists the most expensive products first
+	ProductSortPriceHighest
+)
+
+// ProductAvailability narrows down a listing of products by their stock
+type ProductAvailability int
+
+const (
+	// ProductAvailabilityAny does not filter products by their stock
+	ProductAvailabilityAny ProductAvailability = iota
+	// ProductAvailabilityInStock lists products having quantity left
+	ProductAvailabilityInStock
+	// ProductAvailabilityOutOfStock lists products having no quantity left
+	ProductAvailabilityOutOfStock
+)
+
+// ProductFilter narrows down a listing of products, zero value fields do not filter.
+// MinPrice and MaxPrice are inclusive. Products in any of Categories pass, a category is
+// expanded into its subtree with Categories.Subtree to include products of its subcategories.
+type ProductFilter struct {
+	MinPrice     decimal.NullDecimal
+	MaxPrice     decimal.NullDecimal
+	Availability ProductAvailability
+	Categories   []string
+	Tag          string
+	Sort         ProductSort
+}
+
+// Match tells whether a product passes the filter
+func (f ProductFilter) Match(p *Product) bool {
+	if f.MinPrice.Valid && p.Price.LessThan(f.MinPrice.Decimal) {
+		return false
+	}
+	if f.MaxPrice.Valid && p.Price.GreaterThan(f.MaxPrice.Decimal) {
+		return false
+	}
+	switch f.Availability {
+	case ProductAvailabilityInStock:
+		if p.Quantity <= 0 {
+			return false
+		}
+	case ProductAvailabilityOutOfStock:
+		if p.Quantity > 0 {
+			return false
+		}
+	}
+	if len(f.Categories) != 0 && !contains(f.Categories, p.CategoryID) {
+		return false
+	}
+	if f.Tag != "" && !p.HasTag(f.Tag) {
+		return false
+	}
+	return true
+}
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Less tells whether product a comes before product b in the sort order of the filter
+func (f ProductFilter) Less(a, b *Product) bool {
+	switch f.Sort {
+	case ProductSortPriceLowest:
+		if !a.Price.Equal(b.Price) {
+			return a.Price.LessThan(b.Price)
+		}
+	case ProductSortPriceHighest:
+		if !a.Price.Equal(b.Price) {
+			return a.Price.GreaterThan(b.Price)
+		}
+	default:
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+	}
+	return a.ID < b.ID
+}
+
+// ProductCursor is the position of the last product of a page, next page starts after it
+type ProductCursor struct {
+	Name  string          `json:"name"`
+	Price decimal.Decimal `json:"price"`
+	ID    string          `json:"id"`
+	Sort  ProductSort     `json:"sort"`
+}
+
+// NewProductCursor creates cursor positioned at the product
+func NewProductCursor(p *Product, sort ProductSort) ProductCursor {
+	return ProductCursor{Name: p.Name, Price: p.Price, ID: p.ID, Sort: sort}
+}
+
+// String encodes the cursor into an opaque string
+func (c ProductCursor) String() string {
+	return encodeCursor(c)
+}
+
+// After tells whether a product comes after the cursor
+func (c ProductCursor) After(p *Product) bool {
+	return ProductFilter{Sort: c.Sort}.Less(&Product{ID: c.ID, Name: c.Name, Price: c.Price}, p)
+}
+
+// DecodeProductCursor decodes a cursor of a listing sorted by sort, it returns nil on empty string.
+func DecodeProductCursor(s string, sort ProductSort) (*ProductCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var c ProductCursor
+	if err := decodeCursor(s, &c); err != nil {
+		return nil, err
+	}
+	if c.Sort != sort || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ProductPage is a page of a listing of products, NextCursor is empty on the last page
+type ProductPage struct {
+	Products   []Product `json:"products"`
+	NextCursor string    `json:"next_cursor"`
+}
+
+// NewProductPage makes a page out of products fetched with one more than the page size,
+// the extra product tells that there is a next page.
+func NewProductPage(products []Product, filter ProductFilter, page Page) *ProductPage {
+	if products == nil {
+		products = []Product{}
+	}
+	if len(products) <= page.Size() {
+		return &ProductPage{Products: products}
+	}
+	products = products[:page.Size()]
+	return &ProductPage{
+		Products:   products,
+		NextCursor: NewProductCursor(&products[len(products)-1], filter.Sort).String(),
+	}
+}
@@ -0,0 +1,1543 @@
+// Package repotest provides behavioral checks shared by every implementation of the transaction repositories,
+// each persistent backend runs them against its own repositories:
+//
+//	func TestRepositories(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T, seed repotest.Seed) repotest.Repositories {
+//			// create isolated repositories containing exactly the seed data
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/muktihari/order-transaction-ddd/transaction"
+	"github.com/shopspring/decimal"
+)
+
+// Seed is the data a backend should contain before a check is run
+type Seed struct {
+	Customers  []transaction.Customer
+	Admins     []transaction.Admin
+	Categories []transaction.Category
+	Products   []transaction.Product
+	Coupons    []transaction.Coupon
+	Movements  []transaction.InventoryMovement
+}
+
+// Repositories holds the repositories under test
+type Repositories struct {
+	Orders      transaction.OrderRepository
+	Products    transaction.ProductRepository
+	Categories  transaction.CategoryRepository
+	Coupons     transaction.CouponRepository
+	Customers   transaction.CustomerRepository
+	Credentials transaction.CredentialRepository
+	Admins      transaction.AdminRepository
+	APIKeys     transaction.APIKeyRepository
+	Inventory   transaction.InventoryRepository
+	UnitOfWork  transaction.UnitOfWork
+	// Idempotency is optional, its checks are skipped for backends without one
+	Idempotency transaction.IdempotencyRepository
+}
+
+// Setup creates repositories containing exactly the seed data. Every call should return repositories
+// isolated from the previous ones, cleanup can be registered by the given t.
+type Setup func(t *testing.T, seed Seed) Repositories
+
+// DefaultSeed returns the data seeded before each check
+func DefaultSeed() Seed {
+	// truncated since backends may store less precision than nanoseconds
+	now := time.Now().UTC().Truncate(time.Second)
+	return Seed{
+		Customers: []transaction.Customer{
+			{ID: "CUSTOMER1", Name: "Hari", PhoneNumber: "+62-12345", Email: "example@email.com", Address: "No, Street, City, Indonesia"},
+			{ID: "CUSTOMER2", Name: "Mukti", PhoneNumber: "+62-67890", Email: "other@email.com", Address: "No, Street, City, Indonesia"},
+		},
+		Admins: []transaction.Admin{
+			{ID: "ADMIN1", Name: "Mukti", Email: "admin@email.com", Role: transaction.RoleSuperadmin, PasswordHash: []byte("hash")},
+		},
+		Categories: []transaction.Category{
+			{ID: "CATEGORY1", Name: "Electronics"},
+			{ID: "CATEGORY2", Name: "Smartphones", ParentID: "CATEGORY1"},
+			{ID: "CATEGORY3", Name: "Groceries"},
+		},
+		Products: []transaction.Product{
+			{ID: "PRODUCT1", Name: "Sony Xperia 10", Price: decimal.NewFromInt(500), Quantity: 200},
+			{ID: "PRODUCT2", Name: "Ultramilk 1L", Price: decimal.NewFromInt(5), Quantity: 2000},
+		},
+		Coupons: []transaction.Coupon{
+			{Code: "DISCOUNT_20%", Quantity: 100, Amount: decimal.NewFromFloat(0.2), Type: transaction.CouponTypePercentage,
+				Begin: now.Add(-24 * time.Hour), End: now.Add(10 * 24 * time.Hour)},
+		},
+		Movements: []transaction.InventoryMovement{
+			{ID: "MOVEMENT1", ProductID: "PRODUCT1", Quantity: 200, Reason: transaction.MovementReasonOpeningBalance, Actor: transaction.ActorSystem, CreatedAt: now},
+			{ID: "MOVEMENT2", ProductID: "PRODUCT2", Quantity: 2000, Reason: transaction.MovementReasonOpeningBalance, Actor: transaction.ActorSystem, CreatedAt: now},
+		},
+	}
+}
+
+// Run runs every check against repositories created by setup
+func Run(t *testing.T, setup Setup) {
+	t.Run("CustomerRepository", func(t *testing.T) { TestCustomerRepository(t, setup) })
+	t.Run("CredentialRepository", func(t *testing.T) { TestCredentialRepository(t, setup) })
+	t.Run("AdminRepository", func(t *testing.T) { TestAdminRepository(t, setup) })
+	t.Run("APIKeyRepository", func(t *testing.T) { TestAPIKeyRepository(t, setup) })
+	t.Run("IdempotencyRepository", func(t *testing.T) { TestIdempotencyRepository(t, setup) })
+	t.Run("ProductRepository", func(t *testing.T) { TestProductRepository(t, setup) })
+	t.Run("CategoryRepository", func(t *testing.T) { TestCategoryRepository(t, setup) })
+	t.Run("CouponRepository", func(t *testing.T) { TestCouponRepository(t, setup) })
+	t.Run("OrderRepository", func(t *testing.T) { TestOrderRepository(t, setup) })
+	t.Run("OrderFind", func(t *testing.T) { TestOrderFind(t, setup) })
+	t.Run("InventoryRepository", func(t *testing.T) { TestInventoryRepository(t, setup) })
+	t.Run("UnitOfWork", func(t *testing.T) { TestUnitOfWork(t, setup) })
+}
+
+// TestCustomerRepository checks transaction.CustomerRepository behavior
+func TestCustomerRepository(t *testing.T, setup Setup) {
+	seed := DefaultSeed()
+	r := setup(t, seed).Customers
+	ctx := context.Background()
+
+	c, err := r.FindByID(ctx, "CUSTOMER1")
+	if err != nil {
+		t.Fatalf("FindByID: got %v, expected nil", err)
+	}
+	if diff := cmp.Diff(*c, seed.Customers[0]); diff != "" {
+		t.Errorf("FindByID: different customer:\n%s", diff)
+	}
+
+	if _, err := r.FindByID(ctx, "UNKNOWN"); !errors.Is(err, transaction.ErrCustomerNotFound) {
+		t.Errorf("FindByID unknown: got %v, expected %v", err, transaction.ErrCustomerNotFound)
+	}
+
+	c, err = r.FindByEmail(ctx, "other@email.com")
+	if err != nil {
+		t.Fatalf("FindByEmail: got %v, expected nil", err)
+	}
+	if diff := cmp.Diff(*c, seed.Customers[1]); diff != "" {
+		t.Errorf("FindByEmail: different customer:\n%s", diff)
+	}
+	if _, err := r.FindByEmail(ctx, "unknown@email.com"); !errors.Is(err, transaction.ErrCustomerNotFound) {
+		t.Errorf("FindByEmail unknown: got %v, expected %v", err, transaction.ErrCustomerNotFound)
+	}
+
+	t.Run("Store", func(t *testing.T) {
+		r := setup(t, DefaultSeed()).Customers
+
+		c, err := transaction.NewCustomer("Budi", "budi@email.com", "+62-55555", "No, Street, City, Indonesia")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Store(ctx, c); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if c.ID == "" {
+			t.Fatalf("got empty customer id, expected generated by repository")
+		}
+
+		found, err := r.FindByID(ctx, c.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if diff := cmp.Diff(c, found); diff != "" {
+			t.Errorf("(-expected +got): %s", diff)
+		}
+
+		sameEmail, _ := transaction.NewCustomer("Budi", "example@email.com", "+62-66666", "")
+		if err := r.Store(ctx, sameEmail); !errors.Is(err, transaction.ErrEmailTaken) {
+			t.Errorf("same email: got %v, expected %v", err, transaction.ErrEmailTaken)
+		}
+		samePhone, _ := transaction.NewCustomer("Budi", "third@email.com", "+62-12345", "")
+		if err := r.Store(ctx, samePhone); !errors.Is(err, transaction.ErrPhoneNumberTaken) {
+			t.Errorf("same phone number: got %v, expected %v", err, transaction.ErrPhoneNumberTaken)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		r := setup(t, DefaultSeed()).Customers
+
+		c, err := r.FindByID(ctx, "CUSTOMER1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		stale := *c
+
+		if err := c.ChangeEmail("hari@email.com"); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := c.VerifyContact(transaction.ContactEmail, "hari@email.com"); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Update(ctx, c); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if c.Version != 1 {
+			t.Errorf("got version %d, expected 1", c.Version)
+		}
+
+		found, err := r.FindByID(ctx, "CUSTOMER1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if diff := cmp.Diff(c, found); diff != "" {
+			t.Errorf("(-expected +got): %s", diff)
+		}
+
+		if err := r.Update(ctx, &stale); !errors.Is(err, transaction.ErrConcurrentModification) {
+			t.Errorf("stale: got %v, expected %v", err, transaction.ErrConcurrentModification)
+		}
+		unknown := *found
+		unknown.ID = "UNKNOWN"
+		if err := r.Update(ctx, &unknown); !errors.Is(err, transaction.ErrCustomerNotFound) {
+			t.Errorf("unknown: got %v, expected %v", err, transaction.ErrCustomerNotFound)
+		}
+
+		other, _ := transaction.NewCustomer("Budi", "budi@email.com", "+62-55555", "")
+		if err := r.Store(ctx, other); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := other.ChangeEmail("hari@email.com"); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Update(ctx, other); !errors.Is(err, transaction.ErrEmailTaken) {
+			t.Errorf("same email: got %v, expected %v", err, transaction.ErrEmailTaken)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		r := setup(t, DefaultSeed()).Customers
+
+		c, _ := transaction.NewCustomer("Budi", "budi@email.com", "+62-55555", "")
+		if err := r.Store(ctx, c); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Delete(ctx, c.ID); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if _, err := r.FindByID(ctx, c.ID); !errors.Is(err, transaction.ErrCustomerNotFound) {
+			t.Errorf("deleted: got %v, expected %v", err, transaction.ErrCustomerNotFound)
+		}
+		if err := r.Delete(ctx, c.ID); !errors.Is(err, transaction.ErrCustomerNotFound) {
+			t.Errorf("deleted twice: got %v, expected %v", err, transaction.ErrCustomerNotFound)
+		}
+
+		// the contacts of the deleted customer are free again
+		again, _ := transaction.NewCustomer("Budi", "budi@email.com", "+62-55555", "")
+		if err := r.Store(ctx, again); err != nil {
+			t.Errorf("same contacts: got %v, expected nil", err)
+		}
+	})
+}
+
+// TestCredentialRepository checks transaction.CredentialRepository behavior
+func TestCredentialRepository(t *testing.T, setup Setup) {
+	r := setup(t, DefaultSeed()).Credentials
+	ctx := context.Background()
+
+	if _, err := r.FindByCustomerID(ctx, "CUSTOMER1"); !errors.Is(err, transaction.ErrCredentialNotFound) {
+		t.Fatalf("FindByCustomerID without password: got %v, expected %v", err, transaction.ErrCredentialNotFound)
+	}
+
+	for _, hash := range []string{"first hash", "second hash"} {
+		if err := r.Store(ctx, &transaction.Credential{CustomerID: "CUSTOMER1", PasswordHash: []byte(hash)}); err != nil {
+			t.Fatalf("Store: got %v, expected nil", err)
+		}
+		c, err := r.FindByCustomerID(ctx, "CUSTOMER1")
+		if err != nil {
+			t.Fatalf("FindByCustomerID: got %v, expected nil", err)
+		}
+		if diff := cmp.Diff(*c, transaction.Credential{CustomerID: "CUSTOMER1", PasswordHash: []byte(hash)}); diff != "" {
+			t.Errorf("FindByCustomerID: different credential:\n%s", diff)
+		}
+	}
+
+	if _, err := r.FindByCustomerID(ctx, "CUSTOMER2"); !errors.Is(err, transaction.ErrCredentialNotFound) {
+		t.Errorf("FindByCustomerID other customer: got %v, expected %v", err, transaction.ErrCredentialNotFound)
+	}
+}
+
+// TestAdminRepository checks transaction.AdminRepository behavior
+func TestAdminRepository(t *testing.T, setup Setup) {
+	seed := DefaultSeed()
+	r := setup(t, seed).Admins
+	ctx := context.Background()
+
+	a, err := r.FindByID(ctx, "ADMIN1")
+	if err != nil {
+		t.Fatalf("FindByID: got %v, expected nil", err)
+	}
+	if diff := cmp.Diff(*a, seed.Admins[0]); diff != "" {
+		t.Errorf("FindByID: different admin:\n%s", diff)
+	}
+
+	if _, err := r.FindByID(ctx, "CUSTOMER1"); !errors.Is(err, transaction.ErrAdminNotFound) {
+		t.Errorf("FindByID customer: got %v, expected %v", err, transaction.ErrAdminNotFound)
+	}
+
+	a, err = r.FindByEmail(ctx, "admin@email.com")
+	if err != nil {
+		t.Fatalf("FindByEmail: got %v, expected nil", err)
+	}
+	if a.ID != "ADMIN1" {
+		t.Errorf("FindByEmail: got admin %q, expected %q", a.ID, "ADMIN1")
+	}
+	if _, err := r.FindByEmail(ctx, "example@email.com"); !errors.Is(err, transaction.ErrAdminNotFound) {
+		t.Errorf("FindByEmail customer: got %v, expected %v", err, transaction.ErrAdminNotFound)
+	}
+
+	stored := &transaction.Admin{Name: "Kiki", Email: "kiki@email.com", Role: transaction.RoleFulfillment, PasswordHash: []byte("other hash")}
+	if err := r.Store(ctx, stored); err != nil {
+		t.Fatalf("Store: got %v, expected nil", err)
+	}
+	if stored.ID == "" {
+		t.Fatalf("Store: got empty ID, expected generated")
+	}
+	a, err = r.FindByID(ctx, stored.ID)
+	if err != nil {
+		t.Fatalf("FindByID stored: got %v, expected nil", err)
+	}
+	if diff := cmp.Diff(*a, *stored); diff != "" {
+		t.Errorf("FindByID stored: different admin:\n%s", diff)
+	}
+
+	taken := &transaction.Admin{Name: "Kika", Email: "admin@email.com", Role: transaction.RoleViewer, PasswordHash: []byte("hash")}
+	if err := r.Store(ctx, taken); !errors.Is(err, transaction.ErrAdminEmailTaken) {
+		t.Errorf("Store taken email: got %v, expected %v", err, transaction.ErrAdminEmailTaken)
+	}
+}
+
+// TestAPIKeyRepository checks transaction.APIKeyRepository behavior
+func TestAPIKeyRepository(t *testing.T, setup Setup) {
+	r := setup(t, DefaultSeed()).APIKeys
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	keys, err := r.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("FindAll empty: got %v, expected nil", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("FindAll empty: got %d keys, expected 0", len(keys))
+	}
+
+	warehouse := &transaction.APIKey{
+		Name:      "Warehouse",
+		Scopes:    []transaction.Permission{transaction.PermissionViewOrders, transaction.PermissionShipOrders},
+		Hash:      transaction.HashAPIKey("warehouse key"),
+		CreatedBy: "ADMIN1",
+		CreatedAt: now.Add(-time.Hour),
+		ExpiresAt: now.Add(24 * time.Hour),
+	}
+	bi := &transaction.APIKey{
+		Name:      "BI",
+		Scopes:    []transaction.Permission{transaction.PermissionViewOrders},
+		Hash:      transaction.HashAPIKey("bi key"),
+		CreatedBy: "ADMIN1",
+		CreatedAt: now,
+		ExpiresAt: now.Add(24 * time.Hour),
+	}
+	for _, k := range []*transaction.APIKey{bi, warehouse} {
+		if err := r.Store(ctx, k); err != nil {
+			t.Fatalf("Store: got %v, expected nil", err)
+		}
+		if k.ID == "" {
+			t.Fatalf("Store: got empty ID, expected generated")
+		}
+	}
+
+	k, err := r.FindByHash(ctx, transaction.HashAPIKey("warehouse key"))
+	if err != nil {
+		t.Fatalf("FindByHash: got %v, expected nil", err)
+	}
+	if diff := cmp.Diff(*k, *warehouse); diff != "" {
+		t.Errorf("FindByHash: different key:\n%s", diff)
+	}
+	if _, err := r.FindByHash(ctx, transaction.HashAPIKey("unknown key")); !errors.Is(err, transaction.ErrAPIKeyNotFound) {
+		t.Errorf("FindByHash unknown: got %v, expected %v", err, transaction.ErrAPIKeyNotFound)
+	}
+	if _, err := r.FindByID(ctx, "UNKNOWN"); !errors.Is(err, transaction.ErrAPIKeyNotFound) {
+		t.Errorf("FindByID unknown: got %v, expected %v", err, transaction.ErrAPIKeyNotFound)
+	}
+
+	keys, err = r.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("FindAll: got %v, expected nil", err)
+	}
+	if len(keys) != 2 || keys[0].ID != warehouse.ID || keys[1].ID != bi.ID {
+		t.Errorf("FindAll: got %+v, expected the oldest key first", keys)
+	}
+
+	stale := *warehouse
+	rotatedAt := now
+	warehouse.Hash = transaction.HashAPIKey("rotated key")
+	warehouse.RotatedAt = &rotatedAt
+	warehouse.ExpiresAt = now.Add(48 * time.Hour)
+	if err := r.Update(ctx, warehouse); err != nil {
+		t.Fatalf("Update: got %v, expected nil", err)
+	}
+	if warehouse.Version != 1 {
+		t.Errorf("Update: got version %d, expected 1", warehouse.Version)
+	}
+	k, err = r.FindByID(ctx, warehouse.ID)
+	if err != nil {
+		t.Fatalf("FindByID: got %v, expected nil", err)
+	}
+	if diff := cmp.Diff(*k, *warehouse); diff != "" {
+		t.Errorf("FindByID updated: different key:\n%s", diff)
+	}
+	if _, err := r.FindByHash(ctx, transaction.HashAPIKey("warehouse key")); !errors.Is(err, transaction.ErrAPIKeyNotFound) {
+		t.Errorf("FindByHash rotated: got %v, expected %v", err, transaction.ErrAPIKeyNotFound)
+	}
+
+	revokedAt := now
+	stale.RevokedAt = &revokedAt
+	if err := r.Update(ctx, &stale); !errors.Is(err, transaction.ErrConcurrentModification) {
+		t.Errorf("Update stale: got %v, expected %v", err, transaction.ErrConcurrentModification)
+	}
+}
+
+// TestIdempotencyRepository checks transaction.IdempotencyRepository behavior
+func TestIdempotencyRepository(t *testing.T, setup Setup) {
+	r := setup(t, DefaultSeed()).Idempotency
+	if r == nil {
+		t.Skip("backend has no idempotency repository")
+	}
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if _, err := r.Find(ctx, "customer:CUSTOMER1", "KEY1"); !errors.Is(err, transaction.ErrIdempotencyKeyNotFound) {
+		t.Errorf("Find unknown: got %v, expected %v", err, transaction.ErrIdempotencyKeyNotFound)
+	}
+
+	record := &transaction.IdempotencyRecord{Client: "customer:CUSTOMER1", Key: "KEY1", Fingerprint: []byte("fingerprint"), CreatedAt: now}
+	if err := r.Reserve(ctx, record); err != nil {
+		t.Fatalf("Reserve: got %v, expected nil", err)
+	}
+	if err := r.Reserve(ctx, record); !errors.Is(err, transaction.ErrIdempotencyKeyExists) {
+		t.Errorf("Reserve twice: got %v, expected %v", err, transaction.ErrIdempotencyKeyExists)
+	}
+	other := &transaction.IdempotencyRecord{Client: "customer:CUSTOMER2", Key: "KEY1", Fingerprint: []byte("fingerprint"), CreatedAt: now}
+	if err := r.Reserve(ctx, other); err != nil {
+		t.Errorf("Reserve key of other client: got %v, expected nil", err)
+	}
+
+	got, err := r.Find(ctx, "customer:CUSTOMER1", "KEY1")
+	if err != nil {
+		t.Fatalf("Find: got %v, expected nil", err)
+	}
+	if got.Completed() {
+		t.Errorf("Find: got %+v, expected reserved without response", got)
+	}
+
+	record.StatusCode = 200
+	record.ContentType = "application/json; charset=utf-8"
+	record.Body = []byte(`{"id":"ORDER1"}`)
+	if err := r.Complete(ctx, record); err != nil {
+		t.Fatalf("Complete: got %v, expected nil", err)
+	}
+	got, err = r.Find(ctx, "customer:CUSTOMER1", "KEY1")
+	if err != nil {
+		t.Fatalf("Find: got %v, expected nil", err)
+	}
+	if diff := cmp.Diff(*got, *record); diff != "" {
+		t.Errorf("Find completed: different record:\n%s", diff)
+	}
+
+	if err := r.Release(ctx, "customer:CUSTOMER2", "KEY1"); err != nil {
+		t.Fatalf("Release: got %v, expected nil", err)
+	}
+	if _, err := r.Find(ctx, "customer:CUSTOMER2", "KEY1"); !errors.Is(err, transaction.ErrIdempotencyKeyNotFound) {
+		t.Errorf("Find released: got %v, expected %v", err, transaction.ErrIdempotencyKeyNotFound)
+	}
+	other.StatusCode = 200
+	if err := r.Complete(ctx, other); !errors.Is(err, transaction.ErrIdempotencyKeyNotFound) {
+		t.Errorf("Complete released: got %v, expected %v", err, transaction.ErrIdempotencyKeyNotFound)
+	}
+
+	expired := &transaction.IdempotencyRecord{Client: "customer:CUSTOMER1", Key: "KEY2", Fingerprint: []byte("fingerprint"), CreatedAt: now.Add(-transaction.IdempotencyTTL - time.Minute)}
+	if err := r.Reserve(ctx, expired); err != nil {
+		t.Fatalf("Reserve expired: got %v, expected nil", err)
+	}
+	if _, err := r.Find(ctx, "customer:CUSTOMER1", "KEY2"); !errors.Is(err, transaction.ErrIdempotencyKeyNotFound) {
+		t.Errorf("Find expired: got %v, expected %v", err, transaction.ErrIdempotencyKeyNotFound)
+	}
+	renewed := &transaction.IdempotencyRecord{Client: "customer:CUSTOMER1", Key: "KEY2", Fingerprint: []byte("other"), CreatedAt: now}
+	if err := r.Reserve(ctx, renewed); err != nil {
+		t.Errorf("Reserve over expired: got %v, expected nil", err)
+	}
+}
+
+// TestProductRepository checks transaction.ProductRepository behavior
+func TestProductRepository(t *testing.T, setup Setup) {
+	ctx := context.Background()
+
+	t.Run("FindByID", func(t *testing.T) {
+		seed := DefaultSeed()
+		r := setup(t, seed).Products
+
+		p, err := r.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkProduct(t, p, &seed.Products[0])
+
+		if _, err := r.FindByID(ctx, "UNKNOWN"); !errors.Is(err, transaction.ErrProductNotFound) {
+			t.Errorf("unknown: got %v, expected %v", err, transaction.ErrProductNotFound)
+		}
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		seed := DefaultSeed()
+		r := setup(t, seed).Products
+
+		products, err := r.FindAll(ctx)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if len(products) != len(seed.Products) {
+			t.Fatalf("got %d products, expected %d", len(products), len(seed.Products))
+		}
+		sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+		for i := range products {
+			checkProduct(t, &products[i], &seed.Products[i])
+		}
+	})
+
+	t.Run("FindAll Empty", func(t *testing.T) {
+		r := setup(t, Seed{}).Products
+
+		products, err := r.FindAll(ctx)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if len(products) != 0 {
+			t.Fatalf("got %d products, expected 0", len(products))
+		}
+	})
+
+	t.Run("Store", func(t *testing.T) {
+		r := setup(t, DefaultSeed()).Products
+
+		p, err := transaction.NewProduct("Pocari Sweat 500ml", decimal.NewFromFloat(1.5))
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Store(ctx, p); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if p.ID == "" {
+			t.Fatalf("got empty product id, expected generated by repository")
+		}
+
+		found, err := r.FindByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkProduct(t, found, p)
+
+		if err := found.Archive(); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Update(ctx, found); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		archived, err := r.FindByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkProduct(t, archived, found)
+
+		products, err := r.FindAll(ctx)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if len(products) != 3 {
+			t.Errorf("got %d products, expected 3", len(products))
+		}
+	})
+
+	t.Run("Variants", func(t *testing.T) {
+		r := setup(t, DefaultSeed()).Products
+
+		p, err := transaction.NewProduct("Uniqlo Airism T-Shirt", decimal.NewFromInt(15))
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		xl := decimal.NewFromFloat(17.5)
+		if err := p.AddVariant("AIRISM-M", "M", nil); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := p.AddVariant("AIRISM-XL", "XL", &xl); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := p.AdjustQuantity("AIRISM-XL", 7); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Store(ctx, p); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		found, err := r.FindByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkProduct(t, found, p)
+
+		found.ReserveQuantity("AIRISM-XL", 2)
+		if err := found.EditVariant("AIRISM-M", "Medium", &xl); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Update(ctx, found); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		products, err := r.FindAll(ctx)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		for i := range products {
+			if products[i].ID == p.ID {
+				checkProduct(t, &products[i], found)
+			}
+		}
+	})
+
+	t.Run("Bundle", func(t *testing.T) {
+		r := setup(t, DefaultSeed()).Products
+
+		p, err := transaction.NewProduct("Sony Xperia 10 Starter Kit", decimal.NewFromInt(450))
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		err = p.SetComponents([]transaction.Component{
+			{ProductID: "PRODUCT1", Quantity: 1},
+			{ProductID: "PRODUCT2", Quantity: 2},
+		})
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Store(ctx, p); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		found, err := r.FindByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkProduct(t, found, p)
+
+		if err := found.SetComponents([]transaction.Component{{ProductID: "PRODUCT2", Quantity: 3}}); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Update(ctx, found); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		products, err := r.FindAll(ctx)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		for i := range products {
+			if products[i].ID == p.ID {
+				checkProduct(t, &products[i], found)
+			}
+		}
+	})
+
+	t.Run("Description, Categories And Tags", func(t *testing.T) {
+		r := setup(t, DefaultSeed()).Products
+
+		p, err := r.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := p.Categorize("CATEGORY2", []string{"android", "sony"}); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := p.Describe("6 inch OLED display with triple camera"); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Update(ctx, p); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		found, err := r.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkProduct(t, found, p)
+
+		if err := found.Categorize("", []string{"android"}); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Update(ctx, found); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		products, err := r.FindAll(ctx)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		for i := range products {
+			if products[i].ID == "PRODUCT1" {
+				checkProduct(t, &products[i], found)
+			}
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		r := setup(t, DefaultSeed()).Products
+
+		p, err := r.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		stale := *p
+
+		p.ReserveQuantity("", 5)
+		p.Name = "Sony Xperia 10 II"
+		if err := p.SetReorderThreshold(20); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		availableAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+		if err := p.SetBackorder(transaction.BackorderPreOrder, &availableAt); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Update(ctx, p); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if p.Version != 1 {
+			t.Errorf("got version %d, expected 1", p.Version)
+		}
+
+		found, err := r.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkProduct(t, found, p)
+
+		if err := r.Update(ctx, &stale); !errors.Is(err, transaction.ErrConcurrentModification) {
+			t.Errorf("stale: got %v, expected %v", err, transaction.ErrConcurrentModification)
+		}
+		if err := r.Update(ctx, &transaction.Product{ID: "UNKNOWN", Price: decimal.NewFromInt(1)}); !errors.Is(err, transaction.ErrProductNotFound) {
+			t.Errorf("unknown: got %v, expected %v", err, transaction.ErrProductNotFound)
+		}
+	})
+
+	t.Run("Copy On Read And Write", func(t *testing.T) {
+		seed := DefaultSeed()
+		r := setup(t, seed).Products
+
+		p, err := r.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		p.ReserveQuantity("", 5)
+
+		found, err := r.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkProduct(t, found, &seed.Products[0])
+
+		if err := r.Update(ctx, p); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		expected := *p
+		p.ReserveQuantity("", 5)
+
+		found, err = r.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkProduct(t, found, &expected)
+	})
+}
+
+// TestCategoryRepository checks transaction.CategoryRepository behavior
+func TestCategoryRepository(t *testing.T, setup Setup) {
+	ctx := context.Background()
+
+	t.Run("FindByID", func(t *testing.T) {
+		seed := DefaultSeed()
+		r := setup(t, seed).Categories
+
+		c, err := r.FindByID(ctx, "CATEGORY2")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if diff := cmp.Diff(*c, seed.Categories[1]); diff != "" {
+			t.Errorf("different category:\n%s", diff)
+		}
+
+		if _, err := r.FindByID(ctx, "UNKNOWN"); !errors.Is(err, transaction.ErrCategoryNotFound) {
+			t.Errorf("unknown: got %v, expected %v", err, transaction.ErrCategoryNotFound)
+		}
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		seed := DefaultSeed()
+		r := setup(t, seed).Categories
+
+		categories, err := r.FindAll(ctx)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+		if diff := cmp.Diff(categories, transaction.Categories(seed.Categories)); diff != "" {
+			t.Errorf("different categories:\n%s", diff)
+		}
+	})
+
+	t.Run("Store, Update And Delete", func(t *testing.T) {
+		r := setup(t, DefaultSeed()).Categories
+
+		categories, err := r.FindAll(ctx)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		c, err := transaction.NewCategory("Laptops", "CATEGORY1", categories)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Store(ctx, c); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if c.ID == "" {
+			t.Fatalf("got empty category id, expected generated by repository")
+		}
+
+		stale := *c
+		c.Name = "Notebooks"
+		if err := r.Update(ctx, c); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if c.Version != 1 {
+			t.Errorf("got version %d, expected 1", c.Version)
+		}
+		found, err := r.FindByID(ctx, c.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if diff := cmp.Diff(*found, *c); diff != "" {
+			t.Errorf("different category:\n%s", diff)
+		}
+
+		if err := r.Update(ctx, &stale); !errors.Is(err, transaction.ErrConcurrentModification) {
+			t.Errorf("stale: got %v, expected %v", err, transaction.ErrConcurrentModification)
+		}
+		if err := r.Update(ctx, &transaction.Category{ID: "UNKNOWN", Name: "Unknown"}); !errors.Is(err, transaction.ErrCategoryNotFound) {
+			t.Errorf("unknown: got %v, expected %v", err, transaction.ErrCategoryNotFound)
+		}
+
+		if err := r.Delete(ctx, c.ID); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if _, err := r.FindByID(ctx, c.ID); !errors.Is(err, transaction.ErrCategoryNotFound) {
+			t.Errorf("deleted: got %v, expected %v", err, transaction.ErrCategoryNotFound)
+		}
+		if err := r.Delete(ctx, c.ID); !errors.Is(err, transaction.ErrCategoryNotFound) {
+			t.Errorf("deleted twice: got %v, expected %v", err, transaction.ErrCategoryNotFound)
+		}
+	})
+}
+
+// TestCouponRepository checks transaction.CouponRepository behavior
+func TestCouponRepository(t *testing.T, setup Setup) {
+	ctx := context.Background()
+
+	t.Run("FindByCode", func(t *testing.T) {
+		seed := DefaultSeed()
+		r := setup(t, seed).Coupons
+
+		c, err := r.FindByCode(ctx, "DISCOUNT_20%")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkCoupon(t, c, &seed.Coupons[0])
+
+		if _, err := r.FindByCode(ctx, "UNKNOWN"); !errors.Is(err, transaction.ErrCouponNotFound) {
+			t.Errorf("unknown: got %v, expected %v", err, transaction.ErrCouponNotFound)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		r := setup(t, DefaultSeed()).Coupons
+
+		c, err := r.FindByCode(ctx, "DISCOUNT_20%")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		stale := *c
+
+		if err := c.Use(); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Update(ctx, c); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if c.Version != 1 {
+			t.Errorf("got version %d, expected 1", c.Version)
+		}
+
+		found, err := r.FindByCode(ctx, "DISCOUNT_20%")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkCoupon(t, found, c)
+
+		if err := r.Update(ctx, &stale); !errors.Is(err, transaction.ErrConcurrentModification) {
+			t.Errorf("stale: got %v, expected %v", err, transaction.ErrConcurrentModification)
+		}
+	})
+}
+
+// TestOrderRepository checks transaction.OrderRepository behavior
+func TestOrderRepository(t *testing.T, setup Setup) {
+	ctx := context.Background()
+
+	t.Run("Store And FindByID", func(t *testing.T) {
+		seed := DefaultSeed()
+		r := setup(t, seed)
+
+		o := newOrder(t, r)
+		found, err := r.Orders.FindByID(ctx, o.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkOrder(t, found, o)
+
+		if _, err := r.Orders.FindByID(ctx, "UNKNOWN"); !errors.Is(err, transaction.ErrOrderNotFound) {
+			t.Errorf("unknown: got %v, expected %v", err, transaction.ErrOrderNotFound)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		r := setup(t, DefaultSeed())
+
+		o := newOrder(t, r)
+		stale := copyOrder(o)
+
+		p, err := r.Products.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		c, err := r.Coupons.FindByCode(ctx, "DISCOUNT_20%")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := o.AddProduct(p, "", 5); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := o.ApplyCoupon(*c); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := o.ChangeStatusTo(transaction.OrderStatusSubmitted); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		o.Cart[0].Backordered = 2
+		o.Cart[0].Components = []transaction.Component{{ProductID: "PRODUCT2", Quantity: 2}}
+		if err := r.Orders.Update(ctx, o); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if o.Version != 1 {
+			t.Errorf("got version %d, expected 1", o.Version)
+		}
+
+		found, err := r.Orders.FindByID(ctx, o.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkOrder(t, found, o)
+
+		if err := r.Orders.Update(ctx, stale); !errors.Is(err, transaction.ErrConcurrentModification) {
+			t.Errorf("stale: got %v, expected %v", err, transaction.ErrConcurrentModification)
+		}
+
+		unknown := copyOrder(o)
+		unknown.ID = "UNKNOWN"
+		if err := r.Orders.Update(ctx, unknown); !errors.Is(err, transaction.ErrOrderNotFound) {
+			t.Errorf("unknown: got %v, expected %v", err, transaction.ErrOrderNotFound)
+		}
+	})
+
+	t.Run("Copy On Read And Write", func(t *testing.T) {
+		r := setup(t, DefaultSeed())
+
+		o := newOrder(t, r)
+		p, err := r.Products.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := o.AddProduct(p, "", 5); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Orders.Update(ctx, o); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		expected := copyOrder(o)
+		o.Cart[0].Quantity = 10
+
+		found, err := r.Orders.FindByID(ctx, o.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkOrder(t, found, expected)
+
+		found.Cart[0].Quantity = 10
+		found, err = r.Orders.FindByID(ctx, o.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkOrder(t, found, expected)
+	})
+
+	t.Run("Cart Keeps Product As Added", func(t *testing.T) {
+		r := setup(t, DefaultSeed())
+
+		o := newOrder(t, r)
+		p, err := r.Products.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := o.AddProduct(p, "", 5); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Orders.Update(ctx, o); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		expected := copyOrder(o)
+
+		if err := p.Edit(p.Name+" Renamed", p.Price.Add(decimal.NewFromInt(100))); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := p.Describe("Changed after the order"); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Products.Update(ctx, p); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		found, err := r.Orders.FindByID(ctx, o.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		checkOrder(t, found, expected)
+	})
+}
+
+// TestOrderFind checks transaction.OrderRepository Find: filters, sorting and cursor pagination
+func TestOrderFind(t *testing.T, setup Setup) {
+	ctx := context.Background()
+	r := setup(t, DefaultSeed())
+
+	find := func(id string) (*transaction.Customer, *transaction.Product) {
+		t.Helper()
+		if id[:len("CUSTOMER")] == "CUSTOMER" {
+			c, err := r.Customers.FindByID(ctx, id)
+			if err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			return c, nil
+		}
+		p, err := r.Products.FindByID(ctx, id)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		return nil, p
+	}
+	coupon, err := r.Coupons.FindByCode(ctx, "DISCOUNT_20%")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+
+	base := time.Now().UTC().Truncate(time.Second)
+	store := func(customerID string, createdAt time.Time, status transaction.OrderStatus, withCoupon bool, productIDs ...string) *transaction.Order {
+		t.Helper()
+		c, _ := find(customerID)
+		o := transaction.NewOrder(c)
+		o.CreatedAt = createdAt
+		if err := r.Orders.Store(ctx, o); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		for _, id := range productIDs {
+			_, p := find(id)
+			if err := o.AddProduct(p, "", 1); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+		}
+		if withCoupon {
+			if err := o.ApplyCoupon(*coupon); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+		}
+		if err := o.ChangeStatusTo(status); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if err := r.Orders.Update(ctx, o); err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		return o
+	}
+
+	orders := []*transaction.Order{
+		store("CUSTOMER1", base, transaction.OrderStatusOpen, false, "PRODUCT1"),
+		store("CUSTOMER1", base.Add(time.Minute), transaction.OrderStatusSubmitted, true, "PRODUCT2"),
+		store("CUSTOMER2", base.Add(2*time.Minute), transaction.OrderStatusSubmitted, false, "PRODUCT1"),
+		store("CUSTOMER1", base.Add(3*time.Minute), transaction.OrderStatusCancelled, false, "PRODUCT1", "PRODUCT2"),
+		store("CUSTOMER2", base.Add(3*time.Minute), transaction.OrderStatusPaid, false),
+	}
+	orders[2].SpecifyShippingID("SHIPPING1", transaction.AdminActor("ADMIN1"))
+	if err := r.Orders.Update(ctx, orders[2]); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	shipped, err := r.Orders.FindByID(ctx, orders[2].ID)
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if shipped.ShippingID != "SHIPPING1" || shipped.ShippedBy != transaction.AdminActor("ADMIN1") {
+		t.Errorf("got shipping ID %q shipped by %q, expected %q shipped by %q", shipped.ShippingID, shipped.ShippedBy, "SHIPPING1", transaction.AdminActor("ADMIN1"))
+	}
+
+	// ids lists the expected orders by their index, orders 3 and 4 are created at the same time so
+	// they are swapped when their IDs tell otherwise
+	ids := func(sort transaction.OrderSort, indexes ...int) []string {
+		var expected []string
+		pos3, pos4 := -1, -1
+		for i, index := range indexes {
+			expected = append(expected, orders[index].ID)
+			switch index {
+			case 3:
+				pos3 = i
+			case 4:
+				pos4 = i
+			}
+		}
+		if pos3 != -1 && pos4 != -1 {
+			first, second := expected[pos3], expected[pos4]
+			if pos4 < pos3 {
+				first, second = second, first
+			}
+			if (first > second) == (sort == transaction.OrderSortOldest) {
+				expected[pos3], expected[pos4] = expected[pos4], expected[pos3]
+			}
+		}
+		return expected
+	}
+
+	tt := []struct {
+		name     string
+		filter   transaction.OrderFilter
+		limit    int
+		expected []string
+	}{
+		{
+			name:     "newest",
+			filter:   transaction.OrderFilter{},
+			limit:    2,
+			expected: ids(transaction.OrderSortNewest, 3, 4, 2, 1, 0),
+		},
+		{
+			name:     "oldest",
+			filter:   transaction.OrderFilter{Sort: transaction.OrderSortOldest},
+			limit:    3,
+			expected: ids(transaction.OrderSortOldest, 0, 1, 2, 3, 4),
+		},
+		{
+			name:     "customer",
+			filter:   transaction.OrderFilter{CustomerID: "CUSTOMER2"},
+			limit:    1,
+			expected: ids(transaction.OrderSortNewest, 4, 2),
+		},
+		{
+			name:     "statuses",
+			filter:   transaction.OrderFilter{Statuses: []transaction.OrderStatus{transaction.OrderStatusSubmitted, transaction.OrderStatusPaid}},
+			expected: ids(transaction.OrderSortNewest, 4, 2, 1),
+		},
+		{
+			name:     "created range",
+			filter:   transaction.OrderFilter{CreatedFrom: base.Add(time.Minute), CreatedTo: base.Add(3 * time.Minute), Sort: transaction.OrderSortOldest},
+			expected: ids(transaction.OrderSortOldest, 1, 2),
+		},
+		{
+			name:     "coupon",
+			filter:   transaction.OrderFilter{CouponCode: "DISCOUNT_20%"},
+			expected: ids(transaction.OrderSortNewest, 1),
+		},
+		{
+			name:     "product",
+			filter:   transaction.OrderFilter{ProductID: "PRODUCT2"},
+			expected: ids(transaction.OrderSortNewest, 3, 1),
+		},
+		{
+			name:     "shipping",
+			filter:   transaction.OrderFilter{ShippingID: "SHIPPING1"},
+			expected: ids(transaction.OrderSortNewest, 2),
+		},
+		{
+			name:     "customer and product",
+			filter:   transaction.OrderFilter{CustomerID: "CUSTOMER1", ProductID: "PRODUCT1"},
+			limit:    1,
+			expected: ids(transaction.OrderSortNewest, 3, 0),
+		},
+		{
+			name:     "no match",
+			filter:   transaction.OrderFilter{CustomerID: "UNKNOWN"},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				got  []string
+				page = transaction.Page{Limit: tc.limit}
+			)
+			for i := 0; ; i++ {
+				if i > len(orders) {
+					t.Fatalf("too many pages, next cursor %q", page.Cursor)
+				}
+				result, err := r.Orders.Find(ctx, tc.filter, page)
+				if err != nil {
+					t.Fatalf("got %v, expected nil", err)
+				}
+				if len(result.Orders) > page.Size() {
+					t.Fatalf("got %d orders, expected at most %d", len(result.Orders), page.Size())
+				}
+				for j := range result.Orders {
+					got = append(got, result.Orders[j].ID)
+				}
+				if result.NextCursor == "" {
+					break
+				}
+				page.Cursor = result.NextCursor
+			}
+			if diff := cmp.Diff(got, tc.expected); diff != "" {
+				t.Fatalf("(-got +expected):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("order content", func(t *testing.T) {
+		result, err := r.Orders.Find(ctx, transaction.OrderFilter{CouponCode: "DISCOUNT_20%"}, transaction.Page{})
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if len(result.Orders) != 1 {
+			t.Fatalf("got %d orders, expected 1", len(result.Orders))
+		}
+		checkOrder(t, &result.Orders[0], orders[1])
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, err := r.Orders.Find(ctx, transaction.OrderFilter{}, transaction.Page{Cursor: "not a cursor"})
+		if !errors.Is(err, transaction.ErrInvalidCursor) {
+			t.Errorf("got %v, expected %v", err, transaction.ErrInvalidCursor)
+		}
+
+		result, err := r.Orders.Find(ctx, transaction.OrderFilter{}, transaction.Page{Limit: 1})
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		_, err = r.Orders.Find(ctx, transaction.OrderFilter{Sort: transaction.OrderSortOldest}, transaction.Page{Cursor: result.NextCursor})
+		if !errors.Is(err, transaction.ErrInvalidCursor) {
+			t.Errorf("cursor of other sort: got %v, expected %v", err, transaction.ErrInvalidCursor)
+		}
+	})
+}
+
+// TestInventoryRepository checks transaction.InventoryRepository behavior
+func TestInventoryRepository(t *testing.T, setup Setup) {
+	ctx := context.Background()
+
+	t.Run("FindByProductID", func(t *testing.T) {
+		seed := DefaultSeed()
+		r := setup(t, seed).Inventory
+
+		movements, err := r.FindByProductID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if len(movements) != 1 {
+			t.Fatalf("got %d movements, expected 1", len(movements))
+		}
+		checkMovement(t, &movements[0], &seed.Movements[0])
+
+		movements, err = r.FindByProductID(ctx, "UNKNOWN")
+		if err != nil {
+			t.Fatalf("unknown: got %v, expected nil", err)
+		}
+		if len(movements) != 0 {
+			t.Errorf("unknown: got %d movements, expected 0", len(movements))
+		}
+	})
+
+	t.Run("Store", func(t *testing.T) {
+		seed := DefaultSeed()
+		r := setup(t, seed).Inventory
+
+		reservation := transaction.NewInventoryMovement("PRODUCT1", "", -5, transaction.MovementReasonOrderReservation, transaction.CustomerActor("CUSTOMER1"))
+		reservation.OrderID = "ORDER1"
+		restock := transaction.NewInventoryMovement("PRODUCT1", "", 50, transaction.MovementReasonRestock, transaction.AdminActor("ADMIN1"))
+		restock.Note = "supplier delivery"
+		for _, m := range []*transaction.InventoryMovement{reservation, restock} {
+			if err := r.Store(ctx, m); err != nil {
+				t.Fatalf("got %v, expected nil", err)
+			}
+			if m.ID == "" {
+				t.Fatalf("got empty movement id, expected generated by repository")
+			}
+		}
+
+		movements, err := r.FindByProductID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		expected := []transaction.InventoryMovement{seed.Movements[0], *reservation, *restock}
+		if len(movements) != len(expected) {
+			t.Fatalf("got %d movements, expected %d", len(movements), len(expected))
+		}
+		for i := range movements {
+			checkMovement(t, &movements[i], &expected[i])
+		}
+		if stock := transaction.Stock(movements); stock != 245 {
+			t.Errorf("got stock %d, expected 245", stock)
+		}
+	})
+}
+
+// TestUnitOfWork checks transaction.UnitOfWork behavior
+func TestUnitOfWork(t *testing.T, setup Setup) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	t.Run("Commit", func(t *testing.T) {
+		r := setup(t, DefaultSeed())
+		o := newOrder(t, r)
+
+		err := r.UnitOfWork.Do(ctx, func(ctx context.Context, tx transaction.Repositories) error {
+			p, err := tx.Products().FindByID(ctx, "PRODUCT1")
+			if err != nil {
+				return err
+			}
+			p.ReserveQuantity("", 5)
+			if err := tx.Products().Update(ctx, p); err != nil {
+				return err
+			}
+
+			// changes are visible within the unit of work
+			p, err = tx.Products().FindByID(ctx, "PRODUCT1")
+			if err != nil {
+				return err
+			}
+			if p.Quantity != 195 {
+				t.Errorf("got quantity %d within unit of work, expected 195", p.Quantity)
+			}
+
+			m := transaction.NewInventoryMovement("PRODUCT1", "", -5, transaction.MovementReasonOrderReservation, transaction.CustomerActor("CUSTOMER1"))
+			if err := tx.Inventory().Store(ctx, m); err != nil {
+				return err
+			}
+			movements, err := tx.Inventory().FindByProductID(ctx, "PRODUCT1")
+			if err != nil {
+				return err
+			}
+			if len(movements) != 2 {
+				t.Errorf("got %d movements within unit of work, expected 2", len(movements))
+			}
+
+			found, err := tx.Orders().FindByID(ctx, o.ID)
+			if err != nil {
+				return err
+			}
+			if err := found.ChangeStatusTo(transaction.OrderStatusSubmitted); err != nil {
+				return err
+			}
+			return tx.Orders().Update(ctx, found)
+		})
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+
+		p, err := r.Products.FindByID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if p.Quantity != 195 || p.Version != 1 {
+			t.Errorf("got quantity %d version %d, expected 195 and 1", p.Quantity, p.Version)
+		}
+		movements, err := r.Inventory.FindByProductID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if stock := transaction.Stock(movements); stock != p.Quantity {
+			t.Errorf("got ledger stock %d, expected %d", stock, p.Quantity)
+		}
+		found, err := r.Orders.FindByID(ctx, o.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if found.Status != transaction.OrderStatusSubmitted {
+			t.Errorf("got status %v, expected %v", found.Status, transaction.OrderStatusSubmitted)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		r := setup(t, DefaultSeed())
+		o := newOrder(t, r)
+
+		err := r.UnitOfWork.Do(ctx, func(ctx context.Context, tx transaction.Repositories) error {
+			c, err := tx.Coupons().FindByCode(ctx, "DISCOUNT_20%")
+			if err != nil {
+				return err
+			}
+			if err := c.Use(); err != nil {
+				return err
+			}
+			if err := tx.Coupons().Update(ctx, c); err != nil {
+				return err
+			}
+
+			m := transaction.NewInventoryMovement("PRODUCT1", "", -5, transaction.MovementReasonOrderReservation, transaction.CustomerActor("CUSTOMER1"))
+			if err := tx.Inventory().Store(ctx, m); err != nil {
+				return err
+			}
+
+			found, err := tx.Orders().FindByID(ctx, o.ID)
+			if err != nil {
+				return err
+			}
+			if err := found.ChangeStatusTo(transaction.OrderStatusSubmitted); err != nil {
+				return err
+			}
+			if err := tx.Orders().Update(ctx, found); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("got %v, expected %v", err, errAbort)
+		}
+
+		c, err := r.Coupons.FindByCode(ctx, "DISCOUNT_20%")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if c.Quantity != 100 || c.Version != 0 {
+			t.Errorf("got quantity %d version %d, expected 100 and 0", c.Quantity, c.Version)
+		}
+		movements, err := r.Inventory.FindByProductID(ctx, "PRODUCT1")
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if len(movements) != 1 {
+			t.Errorf("got %d movements, expected 1", len(movements))
+		}
+		found, err := r.Orders.FindByID(ctx, o.ID)
+		if err != nil {
+			t.Fatalf("got %v, expected nil", err)
+		}
+		if found.Status != transaction.OrderStatusOpen || found.Version != 0 {
+			t.Errorf("got status %v version %d, expected %v and 0", found.Status, found.Version, transaction.OrderStatusOpen)
+		}
+	})
+}
+
+// newOrder stores new open order of CUSTOMER1
+func newOrder(t *testing.T, r Repositories) *transaction.Order {
+	t.Helper()
+	c, err := r.Customers.FindByID(context.Background(), "CUSTOMER1")
+	if err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	o := transaction.NewOrder(c)
+	if err := r.Orders.Store(context.Background(), o); err != nil {
+		t.Fatalf("got %v, expected nil", err)
+	}
+	if o.ID == "" {
+		t.Fatalf("got empty order id, expected generated by repository")
+	}
+	return copyOrder(o)
+}
+
+func copyOrder(o *transaction.Order) *transaction.Order {
+	c := *o
+	c.Cart = append([]transaction.CartItem(nil), o.Cart...)
+	for i := range c.Cart {
+		p := *c.Cart[i].Product
+		c.Cart[i].Product = &p
+	}
+	return &c
+}
+
+func checkProduct(t *testing.T, got, expected *transaction.Product) {
+	t.Helper()
+	if got.ID != expected.ID || got.Name != expected.Name || got.Description != expected.Description || !got.Price.Equal(expected.Price) ||
+		got.Quantity != expected.Quantity || got.Archived != expected.Archived || got.Version != expected.Version ||
+		got.CategoryID != expected.CategoryID || got.ReorderThreshold != expected.ReorderThreshold || !cmp.Equal(got.Tags, expected.Tags, cmpopts.EquateEmpty()) ||
+		got.Backorder != expected.Backorder || !cmp.Equal(got.AvailableAt, expected.AvailableAt) ||
+		!cmp.Equal(got.Components, expected.Components, cmpopts.EquateEmpty()) {
+		t.Errorf("got product %+v, expected %+v", got, expected)
+	}
+	if len(got.Variants) != len(expected.Variants) {
+		t.Fatalf("got %d variants, expected %d", len(got.Variants), len(expected.Variants))
+	}
+	for i, v := range got.Variants {
+		e := expected.Variants[i]
+		if v.SKU != e.SKU || v.Name != e.Name || v.Quantity != e.Quantity || (v.Price == nil) != (e.Price == nil) ||
+			(v.Price != nil && !v.Price.Equal(*e.Price)) {
+			t.Errorf("got variant %d %+v, expected %+v", i, v, e)
+		}
+	}
+}
+
+func checkMovement(t *testing.T, got, expected *transaction.InventoryMovement) {
+	t.Helper()
+	if got.ID != expected.ID || got.ProductID != expected.ProductID || got.SKU != expected.SKU || got.Quantity != expected.Quantity ||
+		got.Reason != expected.Reason || got.Actor != expected.Actor || got.OrderID != expected.OrderID ||
+		got.Note != expected.Note || !got.CreatedAt.Equal(expected.CreatedAt) {
+		t.Errorf("got movement %+v, expected %+v", got, expected)
+	}
+}
+
+func checkCoupon(t *testing.T, got, expected *transaction.Coupon) {
+	t.Helper()
+	if got.Code != expected.Code || got.Quantity != expected.Quantity || !got.Amount.Equal(expected.Amount) ||
+		!got.Begin.Equal(expected.Begin) || !got.End.Equal(expected.End) || got.Type != expected.Type ||
+		got.Version != expected.Version {
+		t.Errorf("got coupon %+v, expected %+v", got, expected)
+	}
+}
+
+// checkOrder compares the order aggregate, products in the cart are compared as they were when added to the cart.
+func checkOrder(t *testing.T, got, expected *transaction.Order) {
+	t.Helper()
+	if got.ID != expected.ID || got.Status != expected.Status || got.Version != expected.Version ||
+		got.Customer != expected.Customer || got.Coupon.Code != expected.Coupon.Code ||
+		!got.Price.Equal(expected.Price) || !got.PriceAfterReduction.Equal(expected.PriceAfterReduction) ||
+		got.PaymentSpecification != expected.PaymentSpecification || got.ShippingID != expected.ShippingID ||
+		!got.CreatedAt.Equal(expected.CreatedAt) {
+		t.Errorf("got order %+v, expected %+v", got, expected)
+	}
+	if len(got.Cart) != len(expected.Cart) {
+		t.Fatalf("got %d cart items, expected %d", len(got.Cart), len(expected.Cart))
+	}
+	for i := range got.Cart {
+		if got.Cart[i].Product.ID != expected.Cart[i].Product.ID || got.Cart[i].SKU != expected.Cart[i].SKU ||
+			got.Cart[i].Quantity != expected.Cart[i].Quantity || got.Cart[i].Backordered != expected.Cart[i].Backordered ||
+			!cmp.Equal(got.Cart[i].Components, expected.Cart[i].Components, cmpopts.EquateEmpty()) ||
+			!got.Cart[i].Product.Price.Equal(expected.Cart[i].Product.Price) ||
+			got.Cart[i].Product.Name != expected.Cart[i].Product.Name || got.Cart[i].Product.Description != expected.Cart[i].Product.Description ||
+			got.Cart[i].Product.Quantity != expected.Cart[i].Product.Quantity || got.Cart[i].Product.Version != expected.Cart[i].Product.Version {
+			t.Errorf("got cart item %d: %s %q x %d, expected %s %q x %d", i,
+				got.Cart[i].Product.ID, got.Cart[i].Product.Name, got.Cart[i].Quantity,
+				expected.Cart[i].Product.ID, expected.Cart[i].Product.Name, expected.Cart[i].Quantity)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package transaction
+
+import (
+	"context"
+	"time"
+)
+
+// StockAlertKind tells which threshold the stock of a product crossed
+type StockAlertKind string
+
+const (
+	// StockAlertLow tells that stock fell to or below the reorder threshold of the product
+	StockAlertLow StockAlertKind = "low_stock"
+	// StockAlertOut tells that the product ran out of stock
+	StockAlertOut StockAlertKind = "out_of_stock"
+)
+
+// StockAlert is the event of the stock of a product crossing its reorder threshold or running out
+type StockAlert struct {
+	ProductID        string         `json:"product_id"`
+	Name             string         `json:"name"`
+	Kind             StockAlertKind `json:"kind"`
+	Quantity         int64          `json:"quantity"`
+	ReorderThreshold int64          `json:"reorder_threshold"`
+	CreatedAt        time.Time      `json:"created_at"`
+}
+
+// StockAlert returns the alert of the product when its stock crossed a threshold since it had previousQuantity.
+// Stock staying below the threshold does not alert again, only running out does.
+func (p *Product) StockAlert(previousQuantity int64) (StockAlert, bool) {
+	alert := StockAlert{
+		ProductID:        p.ID,
+		Name:             p.Name,
+		Quantity:         p.Quantity,
+		ReorderThreshold: p.ReorderThreshold,
+		CreatedAt:        time.Now().UTC().Truncate(time.Millisecond),
+	}
+	switch {
+	case p.Quantity <= 0 && previousQuantity > 0:
+		alert.Kind = StockAlertOut
+	case p.Quantity <= p.ReorderThreshold && previousQuantity > p.ReorderThreshold:
+		alert.Kind = StockAlertLow
+	default:
+		return StockAlert{}, false
+	}
+	return alert, true
+}
+
+// StockNotifier delivers stock alerts to whoever reorders products. Delivery is best-effort,
+// a notifier handles its own failures so a failed delivery never fails the change of stock.
+type StockNotifier interface {
+	NotifyStockAlert(ctx context.Context, alert StockAlert)
+}
@@ -0,0 +1,17 @@
+package transaction
+
+import "context"
+
+// Repositories provides access to repositories taking part in a unit of work
+type Repositories interface {
+	Orders() OrderRepository
+	Products() ProductRepository
+	Coupons() CouponRepository
+	Inventory() InventoryRepository
+}
+
+// UnitOfWork runs a business operation spanning orders, products, coupons and the inventory ledger in a single transaction.
+// Changes made through the given repositories are committed when fn returns nil, otherwise they are discarded.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context, r Repositories) error) error
+}